@@ -0,0 +1,44 @@
+package apiConfig
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strings"
+
+	config "github.com/heka-ai/benchmark-cli/pkg/config"
+)
+
+var (
+	// ErrNoAPIKey is returned when the hash of the API key is not on the instance yet
+	ErrNoAPIKey = errors.New("the instance has no API key")
+	// ErrWrongAPIKey is returned when the key of the request is not the API key
+	ErrWrongAPIKey = errors.New("wrong API key")
+)
+
+// CheckAPIKey tells whether the key is the API key of the instance. The
+// instance only knows the salted hash of the key, written by the user data, the
+// file is read on each check as the user data can run after the API starts.
+func CheckAPIKey(key string) error {
+	if key == "" {
+		return ErrWrongAPIKey
+	}
+
+	data, err := os.ReadFile(flag.Lookup("api-key-hash").Value.String())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNoAPIKey
+		}
+		return err
+	}
+
+	if strings.TrimSpace(string(data)) == "" {
+		return ErrNoAPIKey
+	}
+
+	if !config.CheckAPIKeyHash(key, string(data)) {
+		return ErrWrongAPIKey
+	}
+
+	return nil
+}
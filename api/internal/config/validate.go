@@ -1,12 +1,15 @@
 package apiConfig
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 
 	"github.com/fsnotify/fsnotify"
-	"github.com/go-playground/validator/v10"
 	"github.com/heka-ai/benchmark-api/internal/log"
 	config "github.com/heka-ai/benchmark-cli/pkg/config"
 	"github.com/spf13/viper"
@@ -14,9 +17,26 @@ import (
 )
 
 type APIConfig struct {
-	config *config.Config
+	// the active config, swapped as a whole on reload so the readers never
+	// see a partial one
+	config atomic.Pointer[config.Config]
+
+	// mu serializes the reloads, the checks see the config they replace
+	mu     sync.Mutex
+	checks []ReloadCheck
 }
 
+var (
+	// ErrInvalidConfig is returned when the new config cannot be loaded
+	ErrInvalidConfig = errors.New("invalid config")
+	// ErrReloadRejected is returned when a reload check refuses the new config
+	ErrReloadRejected = errors.New("config change rejected")
+)
+
+// ReloadCheck tells whether the active config can be replaced by the new
+// one, the reload is rejected when it returns an error
+type ReloadCheck func(active *config.Config, next *config.Config) error
+
 var logger = log.GetLogger("config")
 
 var ConfigFX = fx.Module("config",
@@ -25,79 +45,166 @@ var ConfigFX = fx.Module("config",
 )
 
 func NewAPIConfig() *APIConfig {
-	config := Init()
-	apiConfig := &APIConfig{
-		config: config,
-	}
+	apiConfig := New(Init())
 
 	apiConfig.WatchConfig()
 
 	return apiConfig
 }
 
-func (c *APIConfig) GetConfig() *config.Config {
-	return c.config
+// New returns an APIConfig holding the config, without watching its file
+func New(conf *config.Config) *APIConfig {
+	apiConfig := &APIConfig{}
+	apiConfig.config.Store(conf)
+
+	return apiConfig
 }
 
-// Init the config and validate it
-func Init() *config.Config {
-	InitFlags()
+func (c *APIConfig) GetConfig() *config.Config {
+	return c.config.Load()
+}
 
-	config := ReadConfig()
+// AddReloadCheck registers a check run before each reload, e.g. the engine
+// refuses new args while it is running
+func (c *APIConfig) AddReloadCheck(check ReloadCheck) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	return config
+	c.checks = append(c.checks, check)
 }
 
-func InitFlags() {
-	flag.String("config", "bench.toml", "Path to the config file")
-	flag.Parse()
+// Hold runs fn with the active config, no reload happens until it returns,
+// e.g. the engine starts from a config that cannot change under it
+func (c *APIConfig) Hold(fn func(active *config.Config) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return fn(c.config.Load())
 }
 
-func ReadConfig() *config.Config {
-	config := &config.Config{}
-	filename := flag.Lookup("config").Value.String()
+// Reload swaps the active config for the new one, which must be valid, when
+// all the reload checks accept it
+func (c *APIConfig) Reload(next *config.Config) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.check(next); err != nil {
+		return err
+	}
+
+	c.config.Store(next)
+
+	return nil
+}
 
-	viper.SetConfigName(filename)
-	viper.SetConfigType("toml")
-	viper.AddConfigPath(".")
+// Replace loads the TOML config sent to the API, writes it over the config
+// file and makes it the active one. The file is left untouched when the
+// config is invalid or rejected by the reload checks.
+func (c *APIConfig) Replace(data []byte) (*config.Config, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	viper.AutomaticEnv()
-	viper.WatchConfig()
+	path := configFile()
 
-	err := viper.ReadInConfig()
+	// the new file is written next to the config so the rename is atomic
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.toml")
 	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed to read config file")
+		return nil, err
 	}
+	defer os.Remove(tmp.Name())
 
-	err = viper.Unmarshal(&config)
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed to unmarshal config")
+		return nil, err
 	}
 
-	validate := validator.New()
-	err = validate.Struct(config)
+	next, err := ReadConfig(tmp.Name())
 	if err != nil {
-		// TODO: improve errors handling
-		for _, err := range err.(validator.ValidationErrors) {
-			logger.Error().Msgf("Failed to validate config, expected %s, got %s (tags: %s)", err.Field(), err.Value(), err.Tag())
-			fmt.Println(err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	if err := c.check(next); err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+
+	c.config.Store(next)
+
+	return next, nil
+}
+
+func (c *APIConfig) check(next *config.Config) error {
+	active := c.config.Load()
+	for _, check := range c.checks {
+		if err := check(active, next); err != nil {
+			return fmt.Errorf("%w: %w", ErrReloadRejected, err)
 		}
+	}
+
+	return nil
+}
+
+// Init the config and validate it
+func Init() *config.Config {
+	InitFlags()
+
+	conf, err := ReadConfig(configFile())
+	if err != nil {
+		config.LogErrors(err, "Invalid config")
 		os.Exit(1)
 	}
 
-	logger.Info().Interface("config", config).Msgf("Config validated successfully")
+	return conf
+}
+
+func InitFlags() {
+	flag.String("config", "bench.toml", "Path to the config file")
+	flag.String("api-key-hash", "api-key.sha256", "Path to the file holding the salted SHA-256 hash of the API key")
+	flag.Parse()
+}
+
+func configFile() string {
+	return flag.Lookup("config").Value.String()
+}
+
+// ReadConfig loads the config written by the CLI, the secrets are not sent
+// to the instances, the HF token comes with the start requests
+func ReadConfig(filename string) (*config.Config, error) {
+	conf, err := config.Load(filename, config.Options{Except: config.SecretFields()})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info().Interface("config", config.Redact(conf)).Msgf("Config validated successfully")
 
-	return config
+	return conf, nil
 }
 
+// WatchConfig reloads the config when its file changes, an invalid or
+// rejected config is logged and the active one is kept
 func (c *APIConfig) WatchConfig() {
-	viper.WatchConfig()
-
-	viper.OnConfigChange(func(e fsnotify.Event) {
-		newConfig := ReadConfig()
+	watcher := viper.New()
+	watcher.SetConfigFile(configFile())
+
+	watcher.OnConfigChange(func(e fsnotify.Event) {
+		newConfig, err := ReadConfig(configFile())
+		if err != nil {
+			config.LogErrors(err, "Invalid config, the previous one is kept")
+			return
+		}
 
-		c.config = newConfig
+		if err := c.Reload(newConfig); err != nil {
+			logger.Error().Err(err).Msg("Config change rejected, the previous one is kept")
+			return
+		}
 
-		logger.Info().Interface("config", c.config).Msgf("Config reloaded successfully")
+		logger.Info().Interface("config", config.Redact(newConfig)).Msgf("Config reloaded successfully")
 	})
+	watcher.WatchConfig()
 }
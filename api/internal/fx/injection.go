@@ -5,6 +5,7 @@ import (
 	"github.com/heka-ai/benchmark-api/internal/log"
 	api_http "github.com/heka-ai/benchmark-api/internal/web"
 	"github.com/heka-ai/benchmark-api/pkg/benchmark"
+	"github.com/heka-ai/benchmark-api/pkg/imds"
 	"github.com/heka-ai/benchmark-api/pkg/vllm"
 	"github.com/ipfans/fxlogger"
 	"go.uber.org/fx"
@@ -26,6 +27,7 @@ func NewAppInjector() *AppInjector {
 		api_http.HttpModule,
 		vllm.VLLMModule,
 		benchmark.BenchmarkModule,
+		imds.IMDSModule,
 
 		fx.Invoke(func(s *api_http.HttpServer) {}),
 	)
@@ -9,9 +9,11 @@ import (
 
 type BenchStartRequest struct {
 	IP string `json:"ip"`
+	// the network of the IP, recorded in the results
+	AddressMode string `json:"address_mode" binding:"omitempty,oneof=private public"`
 }
 
-func (s *HttpServer) generateBenchRouter(router *gin.Engine) {
+func (s *HttpServer) generateBenchRouter(router *gin.RouterGroup) {
 	benchRouter := router.Group("/bench")
 
 	benchRouter.POST("/vllm/start", func(c *gin.Context) {
@@ -22,9 +24,9 @@ func (s *HttpServer) generateBenchRouter(router *gin.Engine) {
 			return
 		}
 
-		logger.Info().Str("ip", req.IP).Msg("Starting benchmark")
+		logger.Info().Str("ip", req.IP).Str("address_mode", req.AddressMode).Msg("Starting benchmark")
 
-		err := s.benchmark.Start(req.IP)
+		err := s.benchmark.Start(req.IP, req.AddressMode, c.GetHeader(HFTokenHeader))
 
 		if err != nil {
 			logger.Error().Err(err).Msg("Failed to start benchmark")
@@ -47,7 +49,7 @@ func (s *HttpServer) generateBenchRouter(router *gin.Engine) {
 	})
 
 	benchRouter.GET("/vllm/status", func(c *gin.Context) {
-		c.JSON(http.StatusNotImplemented, gin.H{"status": "not implemented"})
+		c.JSON(http.StatusOK, s.benchmark.State())
 	})
 
 	benchRouter.GET("/vllm/logs", func(c *gin.Context) {
@@ -0,0 +1,71 @@
+package api_http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	apiConfig "github.com/heka-ai/benchmark-api/internal/config"
+	config "github.com/heka-ai/benchmark-cli/pkg/config"
+)
+
+// maxConfigSize is the size limit of the configs sent to the API
+const maxConfigSize = 1 << 20
+
+func (s *HttpServer) generateConfigRouter(router *gin.RouterGroup) {
+	// the active config in TOML, the secrets that are set are masked
+	router.GET("/config", func(c *gin.Context) {
+		data, err := config.Marshal(config.Mask(s.config.GetConfig()))
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to encode the config")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Data(http.StatusOK, "application/toml", data)
+	})
+
+	// replace the config with the TOML of the body, it is validated then
+	// written over the config file of the instance
+	router.PUT("/config", func(c *gin.Context) {
+		data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxConfigSize))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		conf, err := s.config.Replace(data)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to replace the config")
+			c.JSON(configErrorStatus(err), configErrorBody(err))
+			return
+		}
+
+		logger.Info().Str("bench_id", conf.BenchID).Msg("Config replaced")
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+}
+
+func configErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, apiConfig.ErrInvalidConfig):
+		return http.StatusBadRequest
+	case errors.Is(err, apiConfig.ErrReloadRejected):
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
+
+// configErrorBody lists the problems of an invalid config by key
+func configErrorBody(err error) gin.H {
+	body := gin.H{"error": err.Error()}
+
+	var keyErrors config.KeyErrors
+	if errors.As(err, &keyErrors) {
+		body["errors"] = keyErrors
+	}
+
+	return body
+}
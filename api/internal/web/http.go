@@ -2,35 +2,46 @@ package api_http
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	apiConfig "github.com/heka-ai/benchmark-api/internal/config"
 	"github.com/heka-ai/benchmark-api/internal/log"
 	"github.com/heka-ai/benchmark-api/pkg/benchmark"
+	"github.com/heka-ai/benchmark-api/pkg/imds"
 	"github.com/heka-ai/benchmark-api/pkg/vllm"
 	"go.uber.org/fx"
 )
 
 var logger = log.GetLogger("http")
 
+// HFTokenHeader carries the HF token of the start requests, it is not part of
+// the config of the instances
+const HFTokenHeader = "X-HF-Token"
+
+// APIKeyHeader carries the API key of the config
+const APIKeyHeader = "X-API-Key"
+
 type HttpServer struct {
 	router *gin.Engine
 
 	vllm      *vllm.VLLM
 	benchmark *benchmark.Benchmark
 	config    *apiConfig.APIConfig
+	metadata  *imds.Metadata
 }
 
 var HttpModule = fx.Module("http",
 	fx.Provide(NewHttpServer),
 )
 
-func NewHttpServer(lc fx.Lifecycle, vllm *vllm.VLLM, benchmark *benchmark.Benchmark, config *apiConfig.APIConfig) *HttpServer {
+func NewHttpServer(lc fx.Lifecycle, vllm *vllm.VLLM, benchmark *benchmark.Benchmark, config *apiConfig.APIConfig, metadata *imds.Metadata) *HttpServer {
 	server := &HttpServer{
 		vllm:      vllm,
 		benchmark: benchmark,
 		config:    config,
+		metadata:  metadata,
 	}
 	server.router = server.createRouter()
 
@@ -50,16 +61,49 @@ func (s *HttpServer) createRouter() *gin.Engine {
 	router := gin.Default()
 
 	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok", "provider": s.config.GetConfig().Provider, "inference_engine": s.config.GetConfig().InferenceEngine, "bench_id": s.config.GetConfig().BenchID, "model": s.config.GetConfig().VLLMConfig.Model})
+		conf := s.config.GetConfig()
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "provider": conf.Provider, "inference_engine": conf.InferenceEngine, "bench_id": conf.BenchID, "model": conf.VLLMConfig.Model})
+	})
+
+	// every other route needs the API key, the health check is polled before
+	// the instances know it
+	authorized := router.Group("/", requireAPIKey)
+
+	// the spot interruption notice of the instance, polled by the CLI
+	authorized.GET("/instance/interruption", func(c *gin.Context) {
+		action, err := s.metadata.InstanceAction(c.Request.Context())
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to get the instance action")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, action)
 	})
 
 	// generate the vllm routes
-	s.generateVLLMRouter(router)
-	s.generateBenchRouter(router)
+	s.generateConfigRouter(authorized)
+	s.generateVLLMRouter(authorized)
+	s.generateBenchRouter(authorized)
 
 	return router
 }
 
+// requireAPIKey rejects the requests without the API key of the instance
+func requireAPIKey(c *gin.Context) {
+	err := apiConfig.CheckAPIKey(c.GetHeader(APIKeyHeader))
+	if err == nil {
+		c.Next()
+		return
+	}
+
+	if !errors.Is(err, apiConfig.ErrWrongAPIKey) && !errors.Is(err, apiConfig.ErrNoAPIKey) {
+		logger.Error().Err(err).Msg("Failed to check the API key")
+	}
+
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
+
 func (s *HttpServer) Start(ctx context.Context) error {
 	logger.Info().Str("address", ":8001").Msg("Starting the HTTP server")
 
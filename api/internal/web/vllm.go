@@ -8,11 +8,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func (s *HttpServer) generateVLLMRouter(router *gin.Engine) {
+func (s *HttpServer) generateVLLMRouter(router *gin.RouterGroup) {
 	vllmRouter := router.Group("/vllm")
 
 	vllmRouter.GET("/start", func(c *gin.Context) {
-		err := s.vllm.Start(context.Background())
+		err := s.vllm.Start(context.Background(), c.GetHeader(HFTokenHeader))
 		if err != nil {
 			logger.Error().Err(err).Msg("Failed to start VLLM")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -3,11 +3,12 @@ package benchmark
 import (
 	"bufio"
 	"context"
-	"encoding/json"
+	"errors"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 
 	"github.com/go-playground/validator/v10"
 	apiConfig "github.com/heka-ai/benchmark-api/internal/config"
@@ -22,6 +23,14 @@ var PATH_TO_RESULTS = "/home/ubuntu/metrics.json"
 
 var logger = log.GetLogger("benchmark")
 
+// Status of the benchmark process
+const (
+	StatusIdle     = "idle"
+	StatusRunning  = "running"
+	StatusFinished = "finished"
+	StatusFailed   = "failed"
+)
+
 type Benchmark struct {
 	args    []string
 	cmd     *exec.Cmd
@@ -32,6 +41,16 @@ type Benchmark struct {
 	logsArchive []string
 
 	config *apiConfig.APIConfig
+
+	mu     sync.Mutex
+	status string
+	err    error
+}
+
+// State is the status of the last benchmark started on the instance
+type State struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
 }
 
 var BenchmarkModule = fx.Module("benchmark",
@@ -51,6 +70,7 @@ func NewBenchmark(lc fx.Lifecycle, config *apiConfig.APIConfig) *Benchmark {
 		logsArchive: []string{},
 		running:     0,
 		config:      config,
+		status:      StatusIdle,
 	}
 
 	lc.Append(fx.StopHook(func(ctx context.Context) error {
@@ -60,7 +80,22 @@ func NewBenchmark(lc fx.Lifecycle, config *apiConfig.APIConfig) *Benchmark {
 	return benchmark
 }
 
-func (b *Benchmark) Start(ip string) error {
+// Start runs the benchmark against the vllm server at ip, the address mode
+// tells whether ip is its private or its public address. The HF token comes
+// from the request as it is not in the config of the instance
+func (b *Benchmark) Start(ip string, addressMode string, hfToken string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.status == StatusRunning {
+		return errors.New("a benchmark is already running")
+	}
+
+	// the results of a previous run must not be taken for the ones of this run
+	if err := os.Remove(PATH_TO_RESULTS); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
 	localArgs, err := cliConfig.GenerateBenchmarkCommand(b.config.GetConfig(), ip)
 	if err != nil {
 		return err
@@ -68,10 +103,23 @@ func (b *Benchmark) Start(ip string) error {
 
 	localArgs = append(localArgs, "--save-result", "--result-filename", PATH_TO_RESULTS)
 
+	// the script does not know which network it uses, the address mode is
+	// written in its results file so it outlives a restart of the API
+	if addressMode != "" {
+		localArgs = append(localArgs, "--metadata", "address_mode="+addressMode)
+	}
+
 	logger.Info().Str("command", PATH_TO_PYTHON+" "+strings.Join(localArgs, " ")).Msg("Starting benchmark")
 
 	b.cmd = exec.CommandContext(context.Background(), PATH_TO_PYTHON, localArgs...)
-	b.cmd.Env = append(os.Environ(), "HF_TOKEN="+b.config.GetConfig().BenchmarkConfig.Token)
+	if hfToken == "" {
+		hfToken = b.config.GetConfig().BenchmarkConfig.Token
+	}
+
+	b.cmd.Env = os.Environ()
+	if hfToken != "" {
+		b.cmd.Env = append(b.cmd.Env, "HF_TOKEN="+hfToken)
+	}
 
 	stdout, err := b.cmd.StdoutPipe()
 	if err != nil {
@@ -105,14 +153,41 @@ func (b *Benchmark) Start(ip string) error {
 		return err
 	}
 
-	go func() {
-		b.cmd.Wait()
-		close(b.doneCh)
-	}()
+	b.status, b.err = StatusRunning, nil
+	b.doneCh = make(chan struct{})
+
+	go func(cmd *exec.Cmd, doneCh chan struct{}) {
+		err := cmd.Wait()
+
+		b.mu.Lock()
+		if err != nil {
+			b.status, b.err = StatusFailed, err
+			logger.Error().Err(err).Msg("Benchmark failed")
+		} else {
+			b.status = StatusFinished
+			logger.Info().Msg("Benchmark finished")
+		}
+		b.mu.Unlock()
+
+		close(doneCh)
+	}(b.cmd, b.doneCh)
 
 	return nil
 }
 
+// State returns the status of the last benchmark
+func (b *Benchmark) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := State{Status: b.status}
+	if b.err != nil {
+		state.Error = b.err.Error()
+	}
+
+	return state
+}
+
 func (b *Benchmark) GetResult() (*results.Results, error) {
 	file, err := os.Open(PATH_TO_RESULTS)
 	if err != nil {
@@ -126,18 +201,20 @@ func (b *Benchmark) GetResult() (*results.Results, error) {
 		return nil, err
 	}
 
-	var results results.Results
-	if err := json.Unmarshal(bytes, &results); err != nil {
+	// the benchmark script writes unversioned results, parse migrates them
+	// and moves the address mode of the metadata to the environment
+	parsed, err := results.Parse(bytes)
+	if err != nil {
 		return nil, err
 	}
 
 	// validate
 	val := validator.New()
-	if err := val.Struct(results); err != nil {
+	if err := val.Struct(parsed); err != nil {
 		return nil, err
 	}
 
-	return &results, nil
+	return parsed, nil
 }
 
 func (b *Benchmark) Stop() error {
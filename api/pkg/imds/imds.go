@@ -0,0 +1,131 @@
+package imds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/heka-ai/benchmark-api/internal/log"
+	"go.uber.org/fx"
+)
+
+var logger = log.GetLogger("imds")
+
+// BASE_URL is the instance metadata service of EC2
+var BASE_URL = "http://169.254.169.254/latest"
+
+const tokenTTL = "60"
+
+var IMDSModule = fx.Module("imds",
+	fx.Provide(NewMetadata),
+)
+
+// Metadata reads the instance metadata with IMDSv2 tokens
+type Metadata struct {
+	httpClient *http.Client
+}
+
+// InstanceAction is the interruption notice of a spot instance
+type InstanceAction struct {
+	Interrupted bool `json:"interrupted"`
+	// terminate, stop or hibernate
+	Action string `json:"action,omitempty"`
+	// when the action happens, in UTC
+	Time string `json:"time,omitempty"`
+}
+
+func NewMetadata() *Metadata {
+	return &Metadata{
+		httpClient: &http.Client{Timeout: 2 * time.Second},
+	}
+}
+
+// InstanceAction returns the interruption notice of the instance, an
+// instance without a notice, or which is not a spot instance, is not
+// interrupted
+func (m *Metadata) InstanceAction(ctx context.Context) (*InstanceAction, error) {
+	body, found, err := m.get(ctx, "/meta-data/spot/instance-action")
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return &InstanceAction{Interrupted: false}, nil
+	}
+
+	action := &InstanceAction{}
+	if err := json.Unmarshal(body, action); err != nil {
+		return nil, fmt.Errorf("failed to parse the instance action: %w", err)
+	}
+	action.Interrupted = true
+
+	logger.Warn().Str("action", action.Action).Str("time", action.Time).Msg("The spot instance received an interruption notice")
+
+	return action, nil
+}
+
+// get returns the metadata at path, found is false when it does not exist
+func (m *Metadata) get(ctx context.Context, path string) ([]byte, bool, error) {
+	token, err := m.token(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, BASE_URL+path, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	request.Header.Set("X-aws-ec2-metadata-token", token)
+
+	resp, err := m.httpClient.Do(request)
+	if err != nil {
+		return nil, false, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("failed to read the instance metadata %s: %s", path, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return body, true, nil
+}
+
+func (m *Metadata) token(ctx context.Context) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, BASE_URL+"/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", tokenTTL)
+
+	resp, err := m.httpClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach the instance metadata service: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get an instance metadata token: %s", resp.Status)
+	}
+
+	token, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(token)), nil
+}
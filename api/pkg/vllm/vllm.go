@@ -3,9 +3,13 @@ package vllm
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	apiConfig "github.com/heka-ai/benchmark-api/internal/config"
 	"github.com/heka-ai/benchmark-api/internal/log"
@@ -22,64 +26,150 @@ var VLLMModule = fx.Module("vllm",
 )
 
 type VLLM struct {
+	running int64
+	config  *apiConfig.APIConfig
+
+	// mu serializes Start, Stop and the reload check, and guards the fields
+	// below, Start takes it under the reload lock of the config
+	mu sync.Mutex
+	// the args of the running vllm
 	args        []string
 	cmd         *exec.Cmd
 	doneCh      chan struct{}
-	waitCh      chan struct{}
-	running     int64
 	logsArchive []string
-	config      *apiConfig.APIConfig
 }
 
 func NewVLLM(lc fx.Lifecycle, config *apiConfig.APIConfig) *VLLM {
+	vllm := New(config)
+
+	lc.Append(fx.StopHook(func(ctx context.Context) error {
+		return vllm.Stop(ctx)
+	}))
+
+	return vllm
+}
+
+// New returns the vllm service of the config, its reload check refuses the
+// configs changing the args of a running vllm
+func New(config *apiConfig.APIConfig) *VLLM {
 	vllm := &VLLM{
 		args:        []string{},
 		doneCh:      make(chan struct{}),
-		waitCh:      make(chan struct{}),
 		logsArchive: []string{},
 		running:     0,
 		config:      config,
 	}
 
-	lc.Append(fx.StopHook(func(ctx context.Context) error {
-		return vllm.Stop(ctx)
-	}))
+	config.AddReloadCheck(vllm.checkReload)
 
 	return vllm
 }
 
+// Running tells whether the vllm process is alive
+func (v *VLLM) Running() bool {
+	return atomic.LoadInt64(&v.running) == 1
+}
+
+// checkReload rejects the configs changing the args of a running vllm, they
+// would only apply to its next start and the config would not describe it
+func (v *VLLM) checkReload(active *cliConfig.Config, next *cliConfig.Config) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.Running() {
+		return nil
+	}
+
+	if active.InferenceEngine != next.InferenceEngine || active.InferenceEngineVersion != next.InferenceEngineVersion {
+		return fmt.Errorf("the inference engine cannot change while vllm is running")
+	}
+
+	activeArgs, err := cliConfig.GenerateVLLMCommand(active.VLLMConfig)
+	if err != nil {
+		return err
+	}
+
+	nextArgs, err := cliConfig.GenerateVLLMCommand(next.VLLMConfig)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Equal(activeArgs, nextArgs) {
+		return fmt.Errorf("the vllm args cannot change while vllm is running, stop it first")
+	}
+
+	return nil
+}
+
 func (v *VLLM) GetLogsArchive() []string {
-	return v.logsArchive
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return slices.Clone(v.logsArchive)
+}
+
+func (v *VLLM) archive(line string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.logsArchive = append(v.logsArchive, line)
 }
 
-func (v *VLLM) Start(ctx context.Context) error {
-	logger.Info().Str("model", v.config.GetConfig().VLLMConfig.Model).Str("token", v.config.GetConfig().BenchmarkConfig.Token).Msg("Starting the VLLM service")
+// Start launches vllm, the HF token comes from the request as it is not in
+// the config of the instance, the one of the config is used when it is empty.
+// The config cannot be reloaded until vllm is running with its args.
+func (v *VLLM) Start(ctx context.Context, hfToken string) error {
+	return v.config.Hold(func(conf *cliConfig.Config) error {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		return v.start(ctx, conf, hfToken)
+	})
+}
+
+func (v *VLLM) start(ctx context.Context, conf *cliConfig.Config, hfToken string) error {
+	if v.Running() {
+		return fmt.Errorf("vllm is already running, stop it first")
+	}
+
+	logger.Info().Str("model", conf.VLLMConfig.Model).Msg("Starting the VLLM service")
 
-	localArgs, err := cliConfig.GenerateVLLMCommand(v.config.GetConfig().VLLMConfig)
+	localArgs, err := cliConfig.GenerateVLLMCommand(conf.VLLMConfig)
 	if err != nil {
 		return err
 	}
 
 	logger.Info().Str("command", "vllm "+strings.Join(localArgs, " ")).Msg("Launching VLLM with the following command")
 
-	v.cmd = exec.CommandContext(ctx, PATH_TO_VLLM, localArgs...)
-	v.cmd.Env = append(os.Environ(), "HF_TOKEN="+v.config.GetConfig().BenchmarkConfig.Token)
+	cmd := exec.CommandContext(ctx, PATH_TO_VLLM, localArgs...)
+	if hfToken == "" {
+		hfToken = conf.BenchmarkConfig.Token
+	}
+
+	cmd.Env = os.Environ()
+	if hfToken != "" {
+		cmd.Env = append(cmd.Env, "HF_TOKEN="+hfToken)
+	}
 
-	stdout, err := v.cmd.StdoutPipe()
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return err
 	}
 
-	stderr, err := v.cmd.StderrPipe()
+	stderr, err := cmd.StderrPipe()
 	if err != nil {
 		return err
 	}
 
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
 	go func() {
 		scanner := bufio.NewScanner(stdout)
 		for scanner.Scan() {
 			line := scanner.Text()
-			v.logsArchive = append(v.logsArchive, line)
+			v.archive(line)
 			logger.Info().Msg(line)
 		}
 	}()
@@ -88,25 +178,34 @@ func (v *VLLM) Start(ctx context.Context) error {
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
 			line := scanner.Text()
-			v.logsArchive = append(v.logsArchive, line)
+			v.archive(line)
 			logger.Warn().Msg(line)
 		}
 	}()
 
-	if err := v.cmd.Start(); err != nil {
-		return err
-	}
+	atomic.StoreInt64(&v.running, 1)
+	v.args = localArgs
+	v.cmd = cmd
+	v.doneCh = make(chan struct{})
 
-	go func() {
-		v.cmd.Wait()
-		close(v.doneCh)
-	}()
+	go func(cmd *exec.Cmd, doneCh chan struct{}) {
+		cmd.Wait()
+		atomic.StoreInt64(&v.running, 0)
+		close(doneCh)
+	}(cmd, v.doneCh)
 
 	return nil
 }
 
 func (v *VLLM) Stop(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
 	logger.Info().Msg("Stopping VLLM")
 
+	if v.cmd == nil || v.cmd.Process == nil {
+		return nil
+	}
+
 	return v.cmd.Process.Kill()
 }
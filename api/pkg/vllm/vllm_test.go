@@ -0,0 +1,92 @@
+package vllm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	apiConfig "github.com/heka-ai/benchmark-api/internal/config"
+	cliConfig "github.com/heka-ai/benchmark-cli/pkg/config"
+)
+
+func testConfig(model string) *cliConfig.Config {
+	return &cliConfig.Config{
+		InferenceEngine: "vllm",
+		VLLMConfig:      &cliConfig.VLLMConfig{Model: model},
+		BenchmarkConfig: &cliConfig.BenchmarkConfig{},
+	}
+}
+
+// fakeVLLM replaces the vllm binary by a script running until it is killed
+func fakeVLLM(t *testing.T) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "vllm")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho started\nexec sleep 60\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := PATH_TO_VLLM
+	PATH_TO_VLLM = path
+	t.Cleanup(func() { PATH_TO_VLLM = previous })
+}
+
+// a reload racing a start is either applied before the start, and vllm runs
+// with its args, or rejected, the running vllm always matches the config
+func TestReloadDuringStart(t *testing.T) {
+	fakeVLLM(t)
+
+	for i := 0; i < 50; i++ {
+		config := apiConfig.New(testConfig("model-a"))
+		v := New(config)
+
+		var wg sync.WaitGroup
+		var startErr, reloadErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			startErr = v.Start(context.Background(), "")
+		}()
+		go func() {
+			defer wg.Done()
+			reloadErr = config.Reload(testConfig("model-b"))
+		}()
+		wg.Wait()
+
+		if startErr != nil {
+			t.Fatalf("Start() error = %v", startErr)
+		}
+
+		want, err := cliConfig.GenerateVLLMCommand(config.GetConfig().VLLMConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		v.mu.Lock()
+		got := v.args
+		v.mu.Unlock()
+
+		if !slices.Equal(got, want) {
+			t.Fatalf("vllm runs with %v but the config has %v (reload error: %v)", got, want, reloadErr)
+		}
+
+		v.Stop(context.Background())
+	}
+}
+
+func TestStartTwice(t *testing.T) {
+	fakeVLLM(t)
+
+	v := New(apiConfig.New(testConfig("model-a")))
+	t.Cleanup(func() { v.Stop(context.Background()) })
+
+	if err := v.Start(context.Background(), ""); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := v.Start(context.Background(), ""); err == nil {
+		t.Errorf("the second Start() error = nil, want vllm already running")
+	}
+}
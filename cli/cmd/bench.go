@@ -1,29 +1,52 @@
 package main
 
 import (
-	"flag"
+	"os"
 	"time"
 
 	"github.com/getsentry/sentry-go"
+	"github.com/heka-ai/benchmark-cli/internal/cloud/aws"
 	log "github.com/heka-ai/benchmark-cli/internal/logs"
+	"github.com/heka-ai/benchmark-cli/pkg/config"
 )
 
 var logger = log.GetLogger("cli")
 
+// the config file and its layers, set by the root command from its flags
+var (
+	configPath    = "bench.toml"
+	configOptions = config.Options{}
+)
+
 func main() {
-	disableTelemetry := flag.Bool("disable-telemetry", false, "Disable telemetry")
-	flag.Parse()
-
-	if !*disableTelemetry {
-		err := sentry.Init(sentry.ClientOptions{
-			Dsn: "https://0bf0fc25cd64524694b6dc78ada647e2@sentry.sia.partners/71",
-		})
-		if err != nil {
-			logger.Error().Err(err).Msg("sentry.Init")
-		}
-		defer sentry.Flush(2 * time.Second)
-	}
+	// the telemetry is initialized by the root command, once the flags are parsed
+	defer sentry.Flush(2 * time.Second)
+
+	config.RegisterSecretBackend(aws.SecretsManagerScheme, &aws.SecretsManagerBackend{})
 
 	rootCmd := RootCmd()
 	rootCmd.Execute()
 }
+
+func initTelemetry() {
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn: "https://0bf0fc25cd64524694b6dc78ada647e2@sentry.sia.partners/71",
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg("sentry.Init")
+	}
+}
+
+// loadConfig loads and validates the config selected by the flags, the
+// command stops when it is not valid
+func loadConfig() config.Config {
+	c, err := config.Load(configPath, configOptions)
+	if err != nil {
+		config.LogErrors(err, "Invalid config "+configPath)
+		os.Exit(1)
+	}
+
+	logger.Info().Msgf("Config validated successfully")
+
+	return *c
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/heka-ai/benchmark-cli/internal/jsonschema"
+	"github.com/heka-ai/benchmark-cli/pkg/config"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+)
+
+// Tools around the config file
+func ConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Generate a config file or its JSON Schema",
+	}
+
+	cmd.AddCommand(ConfigSchemaCmd())
+	cmd.AddCommand(ConfigInitCmd())
+
+	return cmd
+}
+
+// Print the JSON Schema of the config, for the editors
+func ConfigSchemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the config file",
+		Long:  `Print the JSON Schema of bench.toml, editors such as Taplo / Even Better TOML use it to autocomplete and validate the config as it is written.`,
+		Example: `
+		bench config schema -o bench.schema.json
+		`,
+		Run: func(cmd *cobra.Command, args []string) {
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the output flag")
+			}
+
+			configSchema(output)
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "-", "The file to write the schema to, - for stdout")
+
+	return cmd
+}
+
+// Write a minimal config from the answers to the required fields
+func ConfigInitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Interactively write a minimal valid config file",
+		Long:  `Ask for the fields required by the config JSON Schema, the default answer is in brackets, and write a minimal valid config file.`,
+		Example: `
+		bench config init
+		bench config init -o configs/llama.toml
+		`,
+		Run: func(cmd *cobra.Command, args []string) {
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the output flag")
+			}
+
+			force, err := cmd.Flags().GetBool("force")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the force flag")
+			}
+
+			configInit(output, force)
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "bench.toml", "The file to write the config to")
+	cmd.Flags().Bool("force", false, "Overwrite the file if it exists")
+
+	return cmd
+}
+
+func configSchema(output string) {
+	schema, err := config.SchemaJSON()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot encode the config schema")
+	}
+
+	if output == "-" {
+		os.Stdout.Write(schema)
+		return
+	}
+
+	if err := os.WriteFile(output, schema, 0644); err != nil {
+		logger.Fatal().Err(err).Msg("Cannot write the config schema")
+	}
+
+	logger.Info().Msgf("Config schema written to %s", output)
+}
+
+// configSuggestions are the default answers of the fields without choices
+var configSuggestions = map[string]string{
+	"bench_id":               "my-benchmark",
+	"benchmark.token":        "${env:HF_TOKEN}",
+	"benchmark.dataset_name": "hf",
+	"benchmark.hf_revision":  "main",
+	"benchmark.hf_split":     "train",
+	"benchmark.num_prompts":  "500",
+	"benchmark.seed":         "42",
+	"aws.region":             "us-east-1",
+	"aws.cpu_instance_type":  "t3.micro",
+	"aws.gpu_instance_type":  "g5.xlarge",
+	"aws.profile_name":       "default",
+	"aws.access_key":         "${env:AWS_ACCESS_KEY_ID}",
+	"aws.secret_key":         "${env:AWS_SECRET_ACCESS_KEY}",
+	"vllm.model":             "meta-llama/Llama-3.2-3B-Instruct",
+}
+
+func configInit(output string, force bool) {
+	if _, err := os.Stat(output); err == nil && !force {
+		logger.Fatal().Msgf("%s already exists, use --force to overwrite it", output)
+	}
+
+	// the API key protects the instances, a random one is a good default
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err == nil {
+		configSuggestions["api_key"] = hex.EncodeToString(key)
+	}
+
+	schema := config.Schema()
+	prompt := &configPrompt{in: bufio.NewReader(os.Stdin), out: os.Stdout, declined: map[string]bool{}}
+
+	values := map[string]any{}
+	if err := prompt.object(schema, "", values); err != nil {
+		logger.Fatal().Err(err).Msg("Cannot generate the config")
+	}
+
+	if errs := validateAnswers(schema, values); len(errs) > 0 {
+		for _, e := range errs {
+			logger.Error().Str("key", strings.TrimPrefix(e.Path, "$.")).Msg(e.Message)
+		}
+		logger.Fatal().Msg("The generated config is not valid")
+	}
+
+	data, err := toml.Marshal(values)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot encode the config")
+	}
+
+	if err := os.WriteFile(output, data, 0600); err != nil {
+		logger.Fatal().Err(err).Msg("Cannot write the config")
+	}
+
+	logger.Info().Msgf("Config written to %s, check it with bench validate -c %s", output, output)
+}
+
+// validateAnswers checks the answers against the schema, in their JSON form
+func validateAnswers(schema *jsonschema.Schema, values map[string]any) []jsonschema.Error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return []jsonschema.Error{{Path: "$", Message: err.Error()}}
+	}
+
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return []jsonschema.Error{{Path: "$", Message: err.Error()}}
+	}
+
+	return schema.Validate(document)
+}
+
+// configPrompt asks for the fields required by the schema
+type configPrompt struct {
+	in  *bufio.Reader
+	out io.Writer
+	// the fields the user chose not to set among alternatives
+	declined map[string]bool
+}
+
+// object asks for the required properties of the object, then for the ones
+// required by its conditions (required_if, required_without)
+func (p *configPrompt) object(schema *jsonschema.Schema, prefix string, values map[string]any) error {
+	for _, name := range schema.Required {
+		if err := p.property(schema, prefix, name, values); err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range schema.AllOf {
+		switch {
+		case rule.If != nil && rule.Then != nil:
+			if len(rule.If.Validate(values)) > 0 {
+				continue
+			}
+
+			for _, name := range rule.Then.Required {
+				if err := p.property(schema, prefix, name, values); err != nil {
+					return err
+				}
+			}
+		case len(rule.AnyOf) > 0:
+			if err := p.alternatives(schema, prefix, rule.AnyOf, values); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// alternatives asks for one of the properties when none is set
+func (p *configPrompt) alternatives(schema *jsonschema.Schema, prefix string, anyOf []*jsonschema.Schema, values map[string]any) error {
+	choices := []string{}
+	for _, alternative := range anyOf {
+		for _, name := range alternative.Required {
+			if _, ok := values[name]; ok {
+				return nil
+			}
+			if !p.declined[prefix+name] {
+				choices = append(choices, name)
+			}
+		}
+	}
+
+	if len(choices) == 0 {
+		return nil
+	}
+
+	chosen := choices[0]
+	if len(choices) > 1 {
+		answer, err := p.ask(fmt.Sprintf("%s: set one of %s", strings.TrimSuffix(prefix, "."), strings.Join(choices, ", ")), choices[0])
+		if err != nil {
+			return err
+		}
+
+		if !slices.Contains(choices, answer) {
+			fmt.Fprintf(p.out, "  expected one of %s\n", strings.Join(choices, ", "))
+			return p.alternatives(schema, prefix, anyOf, values)
+		}
+		chosen = answer
+	}
+
+	for _, name := range choices {
+		if name != chosen {
+			p.declined[prefix+name] = true
+		}
+	}
+
+	return p.property(schema, prefix, chosen, values)
+}
+
+func (p *configPrompt) property(schema *jsonschema.Schema, prefix string, name string, values map[string]any) error {
+	if _, ok := values[name]; ok {
+		return nil
+	}
+
+	property := schema.Properties[name]
+	path := prefix + name
+
+	if property.Properties != nil {
+		table := map[string]any{}
+		if err := p.object(property, path+".", table); err != nil {
+			return err
+		}
+		values[name] = table
+		return nil
+	}
+
+	value, err := p.scalar(property, path)
+	if err != nil {
+		return err
+	}
+
+	values[name] = value
+	return nil
+}
+
+// scalar asks for a value until it matches the type and the choices of the schema
+func (p *configPrompt) scalar(schema *jsonschema.Schema, path string) (any, error) {
+	choices := []string{}
+	for _, value := range schema.Enum {
+		choices = append(choices, fmt.Sprint(value))
+	}
+
+	// nothing to ask
+	if len(choices) == 1 {
+		fmt.Fprintf(p.out, "%s = %s\n", path, choices[0])
+		return schema.Enum[0], nil
+	}
+
+	question := path
+	if len(choices) > 0 {
+		question = fmt.Sprintf("%s (%s)", path, strings.Join(choices, ", "))
+	}
+	if schema.Description != "" {
+		fmt.Fprintf(p.out, "  %s\n", schema.Description)
+	}
+
+	defaultAnswer, ok := configSuggestions[path]
+	if !ok && len(choices) > 0 {
+		defaultAnswer = choices[0]
+	}
+
+	for {
+		answer, err := p.ask(question, defaultAnswer)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := parseAnswer(schema, answer)
+		if err != nil {
+			fmt.Fprintf(p.out, "  %v\n", err)
+			continue
+		}
+
+		if len(choices) > 0 && !slices.Contains(choices, answer) {
+			fmt.Fprintf(p.out, "  expected one of %s\n", strings.Join(choices, ", "))
+			continue
+		}
+
+		return value, nil
+	}
+}
+
+func (p *configPrompt) ask(question string, defaultAnswer string) (string, error) {
+	if defaultAnswer != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, defaultAnswer)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+
+	line, err := p.in.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", fmt.Errorf("no answer for %s: %w", question, err)
+	}
+
+	answer := strings.TrimSpace(line)
+	if answer == "" {
+		answer = defaultAnswer
+	}
+
+	if answer == "" {
+		fmt.Fprintln(p.out, "  a value is required")
+		return p.ask(question, defaultAnswer)
+	}
+
+	return answer, nil
+}
+
+func parseAnswer(schema *jsonschema.Schema, answer string) (any, error) {
+	if len(schema.Type) == 0 {
+		return answer, nil
+	}
+
+	switch schema.Type[0] {
+	case "integer":
+		n, err := strconv.Atoi(answer)
+		if err != nil {
+			return nil, fmt.Errorf("expected an integer, got %q", answer)
+		}
+		return n, nil
+	case "number":
+		f, err := strconv.ParseFloat(answer, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", answer)
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(answer)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", answer)
+		}
+		return b, nil
+	case "array":
+		items := []string{}
+		for _, item := range strings.Split(answer, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+		return items, nil
+	}
+
+	return answer, nil
+}
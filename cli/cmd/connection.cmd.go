@@ -3,7 +3,6 @@ package main
 import (
 	bench "github.com/heka-ai/benchmark-cli/internal/bench"
 	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
-	"github.com/heka-ai/benchmark-cli/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -20,8 +19,7 @@ func ConnectionCmd() *cobra.Command {
 // test the connection to the two instances
 func connect() {
 	logger.Info().Msg("Trying to connect to the instances")
-	config.Init()
-	c := config.GetConfig()
+	c := loadConfig()
 
 	cloud := cloud_generator.NewCloud(&c)
 	client := bench.NewClient(c.APIKey)
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/heka-ai/benchmark-cli/internal/imagebuilder"
+	"github.com/spf13/cobra"
+)
+
+func CpCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "cp <src> <dst>",
+		Short: "Copy files between this machine and an instance of the benchmark",
+		Long: `Copy a file or a directory between this machine and the llm or bench instance
+of the benchmark over SFTP. The remote side is written instance:path and the
+destination is the path of the copy, not its parent directory, e.g.
+
+  bench cp ./prompts.json bench:/home/ubuntu/prompts.json
+  bench cp llm:/var/log/vllm ./logs`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			cp(args[0], args[1])
+		},
+	}
+
+	return command
+}
+
+// remotePath splits instance:path, the role is empty for a local path
+func remotePath(arg string) (string, string) {
+	for _, role := range []string{imagebuilder.RoleLLM, imagebuilder.RoleBench} {
+		if path, ok := strings.CutPrefix(arg, role+":"); ok {
+			return role, path
+		}
+	}
+
+	return "", arg
+}
+
+func cp(src string, dst string) {
+	srcRole, srcPath := remotePath(src)
+	dstRole, dstPath := remotePath(dst)
+
+	if (srcRole == "") == (dstRole == "") {
+		logger.Fatal().Msgf("One of the paths must be on an instance, written %s:path or %s:path", imagebuilder.RoleLLM, imagebuilder.RoleBench)
+	}
+
+	if srcRole != "" {
+		client, access := sshClient(srcRole)
+		defer client.Close()
+
+		logger.Info().Str("host", access.Host).Str("remote", srcPath).Str("local", dstPath).Msg("Downloading")
+		if err := client.Download(srcPath, dstPath); err != nil {
+			logger.Fatal().Err(err).Msg("Cannot download the files")
+		}
+	} else {
+		client, access := sshClient(dstRole)
+		defer client.Close()
+
+		logger.Info().Str("host", access.Host).Str("local", srcPath).Str("remote", dstPath).Msg("Uploading")
+		if err := client.Upload(srcPath, dstPath); err != nil {
+			logger.Fatal().Err(err).Msg("Cannot upload the files")
+		}
+	}
+
+	logger.Info().Msg("Copy done")
+}
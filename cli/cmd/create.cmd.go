@@ -3,7 +3,6 @@ package main
 import (
 	"github.com/heka-ai/benchmark-cli/internal/bench"
 	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
-	"github.com/heka-ai/benchmark-cli/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -29,9 +28,7 @@ func InstanceCmd() *cobra.Command {
 
 func create(wait bool) {
 	logger.Info().Msg("Creating the instances to run the benchmark")
-	config.Init()
-
-	c := config.GetConfig()
+	c := loadConfig()
 
 	cloud := cloud_generator.NewCloud(&c)
 	cloud.Create()
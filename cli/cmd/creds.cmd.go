@@ -1,7 +1,15 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/heka-ai/benchmark-cli/internal/cloud"
 	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
+	"github.com/heka-ai/benchmark-cli/internal/huggingface"
 	"github.com/heka-ai/benchmark-cli/pkg/config"
 	"github.com/spf13/cobra"
 )
@@ -10,8 +18,8 @@ import (
 func CredsCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "creds",
-		Short: "Validate the cloud credentials",
-		Long:  `Validate the cloud credentials by running dry runs of the command we will use`,
+		Short: "Validate the cloud and Hugging Face credentials",
+		Long:  `Simulate the IAM policies of the credentials for every action create, deploy, run and destroy call with the config, the actions of plan, reap and create-instance are checked too but only warn. The launches of the instances are checked with dry runs. The missing permissions are listed in a table. The Hugging Face token is checked against the model, a gated model needs its access to be granted.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			validate()
 		},
@@ -21,13 +29,106 @@ func CredsCmd() *cobra.Command {
 // Check if the credentials are valid (cloud and huggingface)
 func validate() {
 	logger.Info().Msg("Validating credentials")
-	config.Init()
-	c := config.GetConfig()
+	c := loadConfig()
+
+	provider := cloud_generator.NewCloud(&c)
+	checks, err := provider.ValidateCredentials()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot check the cloud credentials")
+	}
+
+	denied, optionalDenied := printPermissionChecks(checks)
 
-	cloud := cloud_generator.NewCloud(&c)
-	cloud.ValidateCredentials()
+	hfErr := validateHuggingFace(&c)
 
-	// todo: validate the huggingface cred
+	if optionalDenied > 0 {
+		logger.Warn().Int("denied", optionalDenied).Msg("Some optional commands are not allowed, the benchmark can still run")
+	}
+
+	if denied > 0 || hfErr != nil {
+		logger.Fatal().Int("denied", denied).Msg("The credentials cannot run the benchmark")
+	}
 
 	logger.Info().Msg("Credentials validated")
 }
+
+// printPermissionChecks prints the checks as a table and counts the denied
+// actions, the required ones and the optional ones
+func printPermissionChecks(checks []cloud.PermissionCheck) (int, int) {
+	denied, optionalDenied := 0, 0
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tRESOURCE\tNEEDED BY\tRESULT\t")
+	for _, check := range checks {
+		result := "allowed"
+		switch {
+		case check.Allowed:
+		case check.Optional:
+			result = "denied (optional)"
+			optionalDenied++
+		default:
+			result = "DENIED"
+			denied++
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", check.Action, check.Resource, strings.Join(check.Steps, ", "), result)
+	}
+	w.Flush()
+
+	for _, check := range checks {
+		if !check.Allowed {
+			logger.Error().Str("action", check.Action).Str("resource", check.Resource).Msg(check.Details)
+		}
+	}
+
+	return denied, optionalDenied
+}
+
+// validateHuggingFace checks the token and its access to the models of the
+// config and of the matrix, the instance downloads them with it. The revision
+// of the config is the one of its model, the other models of the matrix are
+// checked on main.
+func validateHuggingFace(c *config.Config) error {
+	models := []string{c.VLLMConfig.Model}
+	if c.Matrix != nil {
+		for _, model := range c.Matrix.Models {
+			if !slices.Contains(models, model) {
+				models = append(models, model)
+			}
+		}
+	}
+
+	client := huggingface.NewClient(c.BenchmarkConfig.Token)
+
+	user, err := client.WhoAmI()
+	if err != nil {
+		logger.Error().Err(err).Msg("Cannot check the Hugging Face token")
+		return err
+	}
+
+	logger.Info().Str("user", user.Name).Str("role", user.Auth.AccessToken.Role).Msg("OK - The Hugging Face token is valid")
+
+	var modelErr error
+	for _, model := range models {
+		if huggingface.IsLocal(model) {
+			logger.Info().Str("model", model).Msg("The model is a local path, its access is not checked")
+			continue
+		}
+
+		revision := "main"
+		if model == c.VLLMConfig.Model && c.VLLMConfig.Revision != nil {
+			revision = *c.VLLMConfig.Revision
+		}
+
+		info, err := client.CheckModelAccess(model, revision)
+		if err != nil {
+			logger.Error().Err(err).Str("model", model).Msg("Cannot download the model")
+			modelErr = err
+			continue
+		}
+
+		logger.Info().Str("model", model).Bool("gated", info.IsGated()).Msg("OK - The model can be downloaded")
+	}
+
+	return modelErr
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+
 	bench "github.com/heka-ai/benchmark-cli/internal/bench"
 	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
 	"github.com/heka-ai/benchmark-cli/pkg/config"
@@ -11,7 +13,7 @@ import (
 func DeployCmd() *cobra.Command {
 	command := &cobra.Command{
 		Use:   "deploy",
-		Short: "Run the setup of the model on the instance. This is long",
+		Short: "Push the config to the instances and start the model on the LLM instance. This is long",
 		Run: func(cmd *cobra.Command, args []string) {
 			wait, err := cmd.Flags().GetBool("wait")
 			if err != nil {
@@ -31,8 +33,7 @@ func DeployCmd() *cobra.Command {
 func deploy(wait bool) {
 	logger.Info().Msg("Deploying and starting the LLM on the GPU instance")
 
-	config.Init()
-	c := config.GetConfig()
+	c := loadConfig()
 
 	cloud := cloud_generator.NewCloud(&c)
 	llmInstanceIP, err := cloud.GetLLMInstanceIP()
@@ -40,8 +41,19 @@ func deploy(wait bool) {
 		logger.Fatal().Err(err).Msg("Cannot get the LLM instance IP")
 	}
 
+	benchInstanceIP, err := cloud.GetBenchInstanceIP()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot get the bench instance IP")
+	}
+
 	llmClient := bench.NewClient(c.APIKey)
-	err = llmClient.Deploy(llmInstanceIP, c.InferenceEngine)
+
+	// the instances run with the config they were last sent, a changed
+	// config does not need new instances
+	pushConfig(llmClient, &c, llmInstanceIP)
+	pushConfig(llmClient, &c, benchInstanceIP)
+
+	err = llmClient.Deploy(llmInstanceIP, c.InferenceEngine, c.BenchmarkConfig.Token)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Failed to deploy the LLM instance")
 	}
@@ -57,3 +69,14 @@ func deploy(wait bool) {
 	// todo: add a command to get the logs from the LLM instance / engine
 
 }
+
+// pushConfig sends the config to the instance, the command stops when the
+// instance refuses it
+func pushConfig(client *bench.Client, c *config.Config, ip string) {
+	if err := client.PushConfig(ip, c); err != nil {
+		config.LogErrors(err, "The instance "+ip+" refused the config")
+		os.Exit(1)
+	}
+
+	logger.Info().Str("ip", ip).Msg("Config pushed to the instance")
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
-	"github.com/heka-ai/benchmark-cli/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -20,11 +19,12 @@ func DestroyCmd() *cobra.Command {
 
 func DestroyCmdExec() {
 	logger.Info().Msg("Destroying the instance")
-	config.Init()
-	c := config.GetConfig()
+	c := loadConfig()
 
 	cloud := cloud_generator.NewCloud(&c)
-	cloud.Destroy()
+	if err := cloud.Destroy(); err != nil {
+		logger.Fatal().Err(err).Msg("Cannot destroy the instances")
+	}
 
 	logger.Info().Msg("Instances destroyed")
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/heka-ai/benchmark-cli/internal/export"
+	resultsPkg "github.com/heka-ai/benchmark-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+// Export the results in a format usable by notebooks and BI tools
+func ExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export [results files...]",
+		Short: "Export results files as CSV, Parquet or JSONL",
+		Long:  `Export results files with one row per request and every latency metric, or with one row per run when --summary is set.`,
+		Example: `
+		bench export --format csv -o requests.csv results.json
+		bench export --format parquet --summary -o runs.parquet run-1.json run-2.json
+		`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			formatName, err := cmd.Flags().GetString("format")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the format flag")
+			}
+
+			format, err := export.ParseFormat(formatName)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Invalid export format")
+			}
+
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the output flag")
+			}
+
+			summary, err := cmd.Flags().GetBool("summary")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the summary flag")
+			}
+
+			exportExec(args, format, output, summary)
+		},
+	}
+
+	cmd.Flags().StringP("format", "F", "csv", "The export format (csv, parquet, jsonl)")
+	cmd.Flags().StringP("output", "o", "-", "The file to write the export to, - for stdout")
+	cmd.Flags().Bool("summary", false, "Export one row per run instead of one row per request")
+
+	return cmd
+}
+
+func exportExec(files []string, format export.Format, output string, summary bool) {
+	requests := []resultsPkg.RequestRow{}
+	summaries := []resultsPkg.SummaryRow{}
+
+	for _, file := range files {
+		r, err := resultsPkg.ReadFile(file)
+		if err != nil {
+			logger.Fatal().Err(err).Str("file", file).Msg("Cannot read the results")
+		}
+
+		if summary {
+			summaries = append(summaries, r.Summary())
+		} else {
+			requests = append(requests, r.Rows()...)
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if output != "-" {
+		file, err := os.Create(output)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Cannot create the export file")
+		}
+		defer file.Close()
+
+		out = file
+	}
+
+	var err error
+	if summary {
+		err = export.Write(out, format, summaries)
+	} else {
+		err = export.Write(out, format, requests)
+	}
+
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot export the results")
+	}
+
+	if output != "-" {
+		logger.Info().Msgf("Results exported to %s", output)
+	}
+}
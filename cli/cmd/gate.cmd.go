@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/heka-ai/benchmark-cli/pkg/gate"
+	resultsPkg "github.com/heka-ai/benchmark-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+// Compare two runs and fail on performance regressions, made for CI pipelines
+func GateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gate",
+		Short: "Fail when the current run regresses compared to a baseline",
+		Long:  `Evaluate threshold rules comparing the current results to the baseline results. Exits with a non-zero code when a rule fails.`,
+		Example: `
+		bench gate --baseline main.json --current pr.json --rules rules.toml --junit gate.xml
+		`,
+		Run: func(cmd *cobra.Command, args []string) {
+			baseline, err := cmd.Flags().GetString("baseline")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the baseline flag")
+			}
+
+			current, err := cmd.Flags().GetString("current")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the current flag")
+			}
+
+			rules, err := cmd.Flags().GetString("rules")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the rules flag")
+			}
+
+			junit, err := cmd.Flags().GetString("junit")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the junit flag")
+			}
+
+			gateExec(baseline, current, rules, junit)
+		},
+	}
+
+	cmd.Flags().String("baseline", "", "The results of the reference run")
+	cmd.Flags().String("current", "", "The results of the run to check")
+	cmd.Flags().String("rules", "rules.toml", "The TOML file describing the rules")
+	cmd.Flags().String("junit", "", "Write the outcome as JUnit XML to this file")
+
+	cmd.MarkFlagRequired("baseline")
+	cmd.MarkFlagRequired("current")
+
+	return cmd
+}
+
+func gateExec(baselineFile, currentFile, rulesFile, junitFile string) {
+	rules, err := gate.LoadRules(rulesFile)
+	if err != nil {
+		logger.Fatal().Err(err).Str("file", rulesFile).Msg("Cannot load the rules")
+	}
+
+	baseline, err := resultsPkg.ReadFile(baselineFile)
+	if err != nil {
+		logger.Fatal().Err(err).Str("file", baselineFile).Msg("Cannot read the baseline results")
+	}
+
+	current, err := resultsPkg.ReadFile(currentFile)
+	if err != nil {
+		logger.Fatal().Err(err).Str("file", currentFile).Msg("Cannot read the current results")
+	}
+
+	report, err := gate.Evaluate(baseline, current, rules)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot evaluate the rules")
+	}
+
+	printGateReport(report)
+
+	if junitFile != "" {
+		file, err := os.Create(junitFile)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Cannot create the JUnit file")
+		}
+
+		err = report.WriteJUnit(file)
+		file.Close()
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Cannot write the JUnit file")
+		}
+
+		logger.Info().Msgf("JUnit report written to %s", junitFile)
+	}
+
+	if !report.Passed() {
+		logger.Error().Msg("Performance regression detected")
+		os.Exit(1)
+	}
+
+	logger.Info().Msg("No performance regression")
+}
+
+func printGateReport(report *gate.Report) {
+	fmt.Printf("baseline: %s\ncurrent:  %s\n\n", report.BaselineID, report.CurrentID)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tRULE\tBASELINE\tCURRENT\tCHANGE\tTHRESHOLD\t")
+
+	for _, o := range report.Outcomes {
+		status := "PASS"
+		if !o.Passed {
+			status = "FAIL"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%.4f\t%.4f\t%+.2f%%\t%s\t\n", status, o.Rule.Name, o.Baseline, o.Current, o.ChangePct, o.Rule.Threshold())
+	}
+	w.Flush()
+
+	for _, o := range report.Outcomes {
+		if !o.Passed {
+			fmt.Printf("\n%s: %s", o.Rule.Name, strings.Join(o.Failures, "; "))
+		}
+	}
+	fmt.Println()
+}
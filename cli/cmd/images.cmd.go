@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/heka-ai/benchmark-cli/internal/cloud"
+	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
+	"github.com/spf13/cobra"
+)
+
+// Manage the images built with create-instance
+func ImagesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "images",
+		Short: "Manage the instance images",
+	}
+
+	cmd.AddCommand(ImagesPublishCmd())
+
+	return cmd
+}
+
+func ImagesPublishCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Copy the instance images to other regions and share them",
+		Long:  `Copy the images of the region of the config to the regions of --regions, then make them and the source images public or share them with accounts. The latest llm and bench images built with create-instance are published unless --image is given. A region that already has an image with the same name keeps it, publishing again only shares the images.`,
+		Example: `
+		bench images publish --regions eu-west-1,us-west-2 --public
+		bench images publish --regions eu-west-3 --image ami-0123456789abcdef0 --share-with 123456789012
+		`,
+		Run: func(cmd *cobra.Command, args []string) {
+			regions, err := cmd.Flags().GetStringSlice("regions")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the regions flag")
+			}
+
+			imageIDs, err := cmd.Flags().GetStringSlice("image")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the image flag")
+			}
+
+			public, err := cmd.Flags().GetBool("public")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the public flag")
+			}
+
+			accountIDs, err := cmd.Flags().GetStringSlice("share-with")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the share-with flag")
+			}
+
+			publishImages(cloud.PublishOptions{
+				ImageIDs:   imageIDs,
+				Regions:    regions,
+				Public:     public,
+				AccountIDs: accountIDs,
+			})
+		},
+	}
+
+	cmd.Flags().StringSlice("regions", []string{}, "Regions to copy the images to")
+	cmd.Flags().StringSlice("image", []string{}, "Images of the region of the config to publish, the latest llm and bench images by default")
+	cmd.Flags().Bool("public", false, "Make the images public")
+	cmd.Flags().StringSlice("share-with", []string{}, "Accounts allowed to launch the images")
+	cmd.MarkFlagRequired("regions")
+
+	return cmd
+}
+
+func publishImages(options cloud.PublishOptions) {
+	c := loadConfig()
+
+	if !options.Public && len(options.AccountIDs) == 0 {
+		logger.Warn().Msg("The images are copied but not shared, set --public or --share-with to share them")
+	}
+
+	provider := cloud_generator.NewCloud(&c)
+
+	// the copies go on on AWS, publish again to wait for them and share them
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	images, err := provider.PublishImages(ctx, options)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot publish the images")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REGION\tROLE\tIMAGE\tNAME\tSTATUS\t")
+
+	failed := 0
+	for _, image := range images {
+		status := "already copied"
+		switch {
+		case image.Err != nil:
+			status = "failed"
+			failed++
+		case image.Region == c.AWSConfig.Region:
+			status = "source"
+		case image.Copied:
+			status = "copied"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", image.Region, orNone(image.Role), orNone(image.ImageID), image.Name, status)
+	}
+	w.Flush()
+
+	for _, image := range images {
+		if image.Err != nil {
+			logger.Error().Err(image.Err).Str("region", image.Region).Str("name", image.Name).Msg("Cannot publish the image")
+		}
+	}
+
+	if failed > 0 {
+		logger.Fatal().Int("failed", failed).Int("images", len(images)).Msg("Some images were not published, run bench images publish again")
+	}
+
+	logger.Info().Int("images", len(images)).Msg("Images published")
+}
@@ -1,40 +1,77 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
-	"github.com/heka-ai/benchmark-cli/pkg/config"
+	"github.com/heka-ai/benchmark-cli/internal/imagebuilder"
 	"github.com/spf13/cobra"
 )
 
 func InstanceBuildCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "create-instance [instance-type]",
-		Short: "Create a the instance image for the cloud provider",
-		Args:  cobra.ExactArgs(1),
+	cmd := &cobra.Command{
+		Use:   "create-instance [llm|bench]",
+		Short: "Create the instance image for the cloud provider",
+		Long:  `Launch a builder instance from the base image, upload the local install scripts over SSH and run them step by step, then create the image and tag it with the git commit of the scripts. The output of the scripts is streamed. The builder, its key pair and the image of a failed build are deleted even when the build fails or is interrupted.`,
+		Example: `
+		bench create-instance llm
+		bench create-instance bench --api-binary ../api/dist/api
+		`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{imagebuilder.RoleLLM, imagebuilder.RoleBench},
 		Run: func(cmd *cobra.Command, args []string) {
-			instanceType := args[0]
-			buildImageInstance(instanceType)
+			artifactsDir, err := cmd.Flags().GetString("artifacts")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the artifacts flag")
+			}
+
+			apiBinary, err := cmd.Flags().GetString("api-binary")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the api-binary flag")
+			}
+
+			buildImageInstance(args[0], artifactsDir, apiBinary)
 		},
 	}
-}
 
-func buildImageInstance(instanceType string) {
-	config.Init()
-	config := config.GetConfig()
+	cmd.Flags().String("artifacts", "", "Directory of the install scripts, "+imagebuilder.ArtifactsPath+" of the repository by default")
+	cmd.Flags().String("api-binary", "", "Local linux/amd64 build of the API to install, the latest release is downloaded by default")
 
-	cloud := cloud_generator.NewCloud(&config)
+	return cmd
+}
 
-	var err error
+func buildImageInstance(role string, artifactsDir string, apiBinary string) {
+	if role != imagebuilder.RoleLLM && role != imagebuilder.RoleBench {
+		logger.Fatal().Str("instance-type", role).Msg("Invalid instance type, must be llm or bench")
+	}
 
-	if instanceType == "llm" {
-		err = cloud.CreateLLMInstance()
-	} else if instanceType == "bench" {
-		err = cloud.CreateBenchInstance()
-	} else {
-		logger.Error().Str("instance-type", instanceType).Msg("Invalid instance type")
+	if artifactsDir == "" {
+		var err error
+		artifactsDir, err = imagebuilder.FindArtifacts()
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Cannot find the install scripts")
+		}
 	}
 
+	config := loadConfig()
+
+	cloud := cloud_generator.NewCloud(&config)
+
+	// stop the build on ctrl-c, the builder is still terminated
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	imageID, err := cloud.BuildImage(ctx, imagebuilder.Options{
+		Role:         role,
+		ArtifactsDir: artifactsDir,
+		APIBinary:    apiBinary,
+	})
 	if err != nil {
-		logger.Error().Err(err).Msg("Error occurred while creating the instance")
+		logger.Fatal().Err(err).Msg("Error occurred while creating the instance")
 	}
+
+	logger.Info().Str("ami", imageID).Str("role", role).Msg("Image built, set it as the gpu_ami or cpu_ami of the config")
 }
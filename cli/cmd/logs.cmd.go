@@ -5,7 +5,6 @@ import (
 
 	bench "github.com/heka-ai/benchmark-cli/internal/bench"
 	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
-	"github.com/heka-ai/benchmark-cli/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -27,8 +26,7 @@ func LogCmd() *cobra.Command {
 }
 
 func logs(logsType string) {
-	config.Init()
-	c := config.GetConfig()
+	c := loadConfig()
 
 	cloud := cloud_generator.NewCloud(&c)
 	llmInstanceIP, err := cloud.GetLLMInstanceIP()
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/heka-ai/benchmark-cli/internal/export"
+	"github.com/heka-ai/benchmark-cli/pkg/matrix"
+	resultsPkg "github.com/heka-ai/benchmark-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+// Expand and run the [matrix] of the config
+func MatrixCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "matrix",
+		Short: "Benchmark every combination of the [matrix] axes",
+	}
+
+	cmd.AddCommand(MatrixPlanCmd())
+	cmd.AddCommand(MatrixRunCmd())
+
+	return cmd
+}
+
+func MatrixPlanCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "plan",
+		Short: "List the variants of the matrix without running them",
+		Run: func(cmd *cobra.Command, args []string) {
+			matrixPlan()
+		},
+	}
+}
+
+func MatrixRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run the variants of the matrix and compare their results",
+		Long:  `Run each variant of the matrix on its own instances: create, deploy, benchmark, collect the results and destroy. The results of each variant are written to the output directory with a summary.csv comparing them.`,
+		Example: `
+		bench matrix run
+		bench matrix run -j 3 -o results/llama
+		`,
+		Run: func(cmd *cobra.Command, args []string) {
+			concurrency, err := cmd.Flags().GetInt("concurrency")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the concurrency flag")
+			}
+
+			outputDir, err := cmd.Flags().GetString("output-dir")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the output-dir flag")
+			}
+
+			keep, err := cmd.Flags().GetBool("keep")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the keep flag")
+			}
+
+			matrixRun(concurrency, outputDir, keep)
+		},
+	}
+
+	cmd.Flags().IntP("concurrency", "j", 1, "Number of variants running at the same time")
+	cmd.Flags().StringP("output-dir", "o", "matrix-results", "The directory to write the results to")
+	cmd.Flags().Bool("keep", false, "Keep the instances of the variants once they are done")
+
+	return cmd
+}
+
+func expandMatrix() []matrix.Variant {
+	c := loadConfig()
+
+	variants, err := matrix.Expand(&c)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot expand the matrix")
+	}
+
+	return variants
+}
+
+func matrixPlan() {
+	variants := expandMatrix()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BENCH ID\tMODEL\tGPU INSTANCE\tDTYPE\tQUANTIZATION\tMAX NUM SEQS\tVALID\t")
+
+	invalid := 0
+	for _, v := range variants {
+		vllm := v.Config.VLLMConfig
+
+		gpuInstanceType := ""
+		if v.Config.AWSConfig != nil {
+			gpuInstanceType = v.Config.AWSConfig.GPUInstanceType
+		}
+
+		valid := "yes"
+		if v.Err != nil {
+			valid = "no"
+			invalid++
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", v.BenchID, vllm.Model, gpuInstanceType, orDefault(vllm.Dtype), orDefault(vllm.Quantization), orDefault(vllm.MaxNumSeqs), valid)
+	}
+	w.Flush()
+
+	for _, v := range variants {
+		if v.Err != nil {
+			logger.Error().Str("bench_id", v.BenchID).Msg(v.Err.Error())
+		}
+	}
+
+	logger.Info().Int("variants", len(variants)).Int("invalid", invalid).Msg("Matrix expanded")
+}
+
+func matrixRun(concurrency int, outputDir string, keep bool) {
+	variants := expandMatrix()
+
+	// a variant is cheaper to fix before any instance is created
+	for _, v := range variants {
+		if v.Err != nil {
+			logger.Fatal().Err(v.Err).Str("bench_id", v.BenchID).Msg("Invalid variant, run bench matrix plan to list them")
+		}
+	}
+
+	// stop the variants on ctrl-c, their instances are still destroyed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Info().Int("variants", len(variants)).Int("concurrency", concurrency).Msg("Running the matrix")
+
+	runner := &matrix.Runner{Concurrency: concurrency, OutputDir: outputDir, Keep: keep}
+	outcomes := runner.Run(ctx, variants)
+
+	printMatrixResults(outcomes)
+
+	summaries := []resultsPkg.SummaryRow{}
+	failed := 0
+	for _, o := range outcomes {
+		if o.Interrupted() {
+			failed++
+			logger.Error().Err(o.Err).Str("bench_id", o.Variant.BenchID).Int("attempts", o.Attempts).Msg("Variant interrupted, its results are not kept, raise aws.spot_retries to retry it")
+			continue
+		}
+
+		if o.Err != nil {
+			failed++
+			logger.Error().Err(o.Err).Str("bench_id", o.Variant.BenchID).Msg("Variant failed")
+			continue
+		}
+		summaries = append(summaries, o.Results.Summary())
+	}
+
+	if len(summaries) > 0 {
+		summaryFile := filepath.Join(outputDir, "summary.csv")
+		if err := writeMatrixSummary(summaryFile, summaries); err != nil {
+			logger.Error().Err(err).Msg("Cannot write the summary of the matrix")
+		} else {
+			logger.Info().Msgf("Summary written to %s", summaryFile)
+		}
+	}
+
+	if failed > 0 {
+		logger.Fatal().Int("failed", failed).Int("variants", len(outcomes)).Msg("Some variants failed or were interrupted")
+	}
+
+	logger.Info().Int("variants", len(outcomes)).Msg("Matrix done")
+}
+
+func printMatrixResults(outcomes []matrix.Outcome) {
+	// only the axes telling the variants apart are shown
+	axes := []string{}
+	if len(outcomes) > 0 {
+		for _, v := range outcomes[0].Variant.Values {
+			axes = append(axes, v.Axis)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "BENCH ID\t%sREQ/S\tOUTPUT TOK/S\tMEAN TTFT (ms)\tP99 TTFT (ms)\tMEAN ITL (ms)\tSTATUS\t\n", axesHeader(axes))
+
+	for _, o := range outcomes {
+		values := ""
+		for _, v := range o.Variant.Values {
+			values += v.Value + "\t"
+		}
+
+		if o.Interrupted() {
+			fmt.Fprintf(w, "%s\t%s-\t-\t-\t-\t-\tinterrupted\t\n", o.Variant.BenchID, values)
+			continue
+		}
+
+		if o.Err != nil {
+			fmt.Fprintf(w, "%s\t%s-\t-\t-\t-\t-\tfailed\t\n", o.Variant.BenchID, values)
+			continue
+		}
+
+		s := o.Results.Summary()
+		fmt.Fprintf(w, "%s\t%s%.2f\t%.2f\t%.2f\t%.2f\t%.2f\tok\t\n", o.Variant.BenchID, values, s.RequestThroughput, s.OutputThroughput, s.MeanTtftMs, s.P99TtftMs, s.MeanItlMs)
+	}
+	w.Flush()
+}
+
+func axesHeader(axes []string) string {
+	header := ""
+	for _, axis := range axes {
+		header += strings.ToUpper(strings.ReplaceAll(axis, "_", " ")) + "\t"
+	}
+
+	return header
+}
+
+func writeMatrixSummary(file string, summaries []resultsPkg.SummaryRow) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return export.Write(out, export.CSV, summaries)
+}
+
+// orDefault shows the optional vllm values, unset ones keep the vllm default
+func orDefault[T any](v *T) string {
+	if v == nil {
+		return "default"
+	}
+
+	return fmt.Sprint(*v)
+}
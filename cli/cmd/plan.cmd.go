@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heka-ai/benchmark-cli/internal/cloud"
+	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
+	"github.com/spf13/cobra"
+)
+
+// Show what create would do and what it would cost, without creating anything
+func PlanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "List the resources bench create would create or reuse and their cost",
+		Long:  `List the instances, images, volumes, IAM role and network resources bench create would create or reuse, with the estimated hourly cost of the instances and their total cost for the expected duration of the run. The launches are checked with dry runs, the missing permissions and the vCPU quotas too low are reported. Nothing is created.`,
+		Example: `
+		bench plan
+		bench plan --duration 3h
+		`,
+		Run: func(cmd *cobra.Command, args []string) {
+			duration, err := cmd.Flags().GetDuration("duration")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the duration flag")
+			}
+
+			plan(duration)
+		},
+	}
+
+	cmd.Flags().Duration("duration", 2*time.Hour, "Expected duration of the run, from bench create to bench destroy")
+
+	return cmd
+}
+
+func plan(duration time.Duration) {
+	c := loadConfig()
+
+	provider := cloud_generator.NewCloud(&c)
+	p, err := provider.Plan()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot plan the benchmark")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tKIND\tNAME\tDETAILS\t")
+	for _, r := range p.Resources {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", r.Action, r.Kind, r.Name, orNone(r.Details))
+	}
+	w.Flush()
+
+	fmt.Println()
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INSTANCE\tTYPE\tMARKET\tVCPUS\tUSD/HOUR\tVOLUME USD/HOUR\t")
+	for _, i := range p.Instances {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t\n", i.Role, i.InstanceType, i.Market, i.VCPUs, usd(i.HourlyUSD, i.PriceKnown), usd(i.VolumeHourlyUSD, i.PriceKnown))
+	}
+	w.Flush()
+
+	printPlanCost(p, duration)
+
+	for _, problem := range p.Problems {
+		logger.Error().Msg(problem.Error())
+	}
+
+	if len(p.Problems) > 0 {
+		logger.Fatal().Int("problems", len(p.Problems)).Msg("bench create would fail, fix the problems first")
+	}
+
+	logger.Info().Msg("Nothing was created, run bench create to create the resources")
+}
+
+func printPlanCost(p *cloud.Plan, duration time.Duration) {
+	hourly, known := p.HourlyUSD()
+
+	fmt.Println()
+	if !known {
+		fmt.Println("The price of some instance types is not known, they are left out of the estimate.")
+	}
+	fmt.Printf("Estimated cost: %.2f USD per hour, %.2f USD for %s\n", hourly, hourly*duration.Hours(), duration)
+	fmt.Println("The on-demand prices are the us-east-1 ones, the spot prices are the current ones.")
+	fmt.Println()
+}
+
+func usd(value float64, known bool) string {
+	if !known {
+		return "unknown"
+	}
+
+	return fmt.Sprintf("%.4f", value)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/heka-ai/benchmark-cli/internal/publish"
+	resultsPkg "github.com/heka-ai/benchmark-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+// Upload the results to the Sia Benchmark website
+func PublishCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "publish",
+		Short: "Publish the results to the Sia Benchmark website",
+		Long:  `Validate the results file and upload it to the results ingestion API. Uploads are keyed by bench id and can safely be retried.`,
+		Example: `
+		bench publish --file results.json --dry-run
+		bench publish --file results.json
+		`,
+		Run: func(cmd *cobra.Command, args []string) {
+			file, err := cmd.Flags().GetString("file")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the file flag")
+			}
+
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the dry-run flag")
+			}
+
+			endpoint, err := cmd.Flags().GetString("endpoint")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the endpoint flag")
+			}
+
+			token, err := cmd.Flags().GetString("token")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the token flag")
+			}
+
+			benchID, err := cmd.Flags().GetString("bench-id")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the bench-id flag")
+			}
+
+			publishExec(file, endpoint, token, benchID, dryRun)
+		},
+	}
+
+	cmd.Flags().StringP("file", "f", "", "The results file to publish")
+	cmd.Flags().Bool("dry-run", false, "Validate the results and show what would be sent without uploading")
+	cmd.Flags().String("endpoint", "", "Override the endpoint of the [publish] config")
+	cmd.Flags().String("token", "", "Override the token of the [publish] config")
+	cmd.Flags().String("bench-id", "", "The bench id of the results, checked against the results file")
+
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+// publishExec publishes the results under their own bench id, the config is
+// only read for the [publish] section when it exists
+func publishExec(file string, endpoint string, token string, benchID string, dryRun bool) {
+	configBenchID := ""
+	if _, err := os.Stat(configPath); err == nil {
+		c := loadConfig()
+		configBenchID = c.BenchID
+
+		if c.PublishConfig != nil {
+			if endpoint == "" {
+				endpoint = c.PublishConfig.Endpoint
+			}
+			if token == "" {
+				token = c.PublishConfig.Token
+			}
+		}
+	} else {
+		logger.Debug().Str("config", configPath).Msg("No config, the endpoint and the token come from the flags")
+	}
+
+	r, err := resultsPkg.ReadFile(file)
+	if err != nil {
+		logger.Fatal().Err(err).Str("file", file).Msg("Cannot read the results")
+	}
+
+	if r.BenchmarkID == nil || *r.BenchmarkID == "" {
+		switch {
+		case benchID != "":
+			r.BenchmarkID = &benchID
+		case configBenchID != "":
+			r.BenchmarkID = &configBenchID
+		default:
+			logger.Fatal().Msg("The results have no bench id, set it with --bench-id")
+		}
+	}
+
+	if benchID != "" && *r.BenchmarkID != benchID {
+		logger.Fatal().Str("results", *r.BenchmarkID).Str("flag", benchID).Msg("The results were produced by another benchmark than the one of --bench-id")
+	}
+
+	// the variants of a matrix derive their bench id from the one of the config
+	if configBenchID != "" && *r.BenchmarkID != configBenchID {
+		logger.Warn().Str("results", *r.BenchmarkID).Str("config", configBenchID).Msg("The results were produced by another benchmark than the one of the config, publishing them under their own bench id")
+	}
+
+	err = r.Validate()
+	if err != nil {
+		var validationErrors resultsPkg.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			for _, e := range validationErrors {
+				logger.Error().Str("path", e.Path).Msg(e.Message)
+			}
+		}
+		logger.Fatal().Msg("The results are not valid, nothing was published")
+	}
+
+	payload, err := json.Marshal(r)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot marshal the results")
+	}
+
+	client := publish.NewClient(endpoint, token)
+	client.DryRun = dryRun
+
+	if !dryRun && endpoint == "" {
+		logger.Fatal().Msg("No endpoint set, add it to the [publish] section of the config or use --endpoint")
+	}
+
+	if !dryRun && token == "" {
+		logger.Fatal().Msg("No token set, add it to the [publish] section of the config or use --token")
+	}
+
+	publication, err := client.Publish(*r.BenchmarkID, payload)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot publish the results")
+	}
+
+	if dryRun {
+		return
+	}
+
+	if !publication.Created {
+		logger.Info().Str("digest", publication.Digest).Msg("These results were already published, nothing changed")
+		return
+	}
+
+	logger.Info().Str("digest", publication.Digest).Str("url", publication.URL).Msg("Results published")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
+	"github.com/spf13/cobra"
+)
+
+// Delete the resources left by the benchmarks, whatever their bench id
+func ReapCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reap",
+		Short: "Delete the old resources created by the CLI for any benchmark",
+		Long:  `Terminate the instances and delete the key pairs, security groups, placement groups and failed images tagged managed-by=benchmark-cli that are older than --older-than, in the region of the config, whatever their bench id and state. The images that were built successfully are kept.`,
+		Example: `
+		bench reap --dry-run
+		bench reap --older-than 6h
+		`,
+		Run: func(cmd *cobra.Command, args []string) {
+			olderThan, err := cmd.Flags().GetDuration("older-than")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the older-than flag")
+			}
+
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the dry-run flag")
+			}
+
+			reap(olderThan, dryRun)
+		},
+	}
+
+	cmd.Flags().Duration("older-than", 24*time.Hour, "Only delete the resources older than this duration")
+	cmd.Flags().Bool("dry-run", false, "List the resources without deleting them")
+
+	return cmd
+}
+
+func reap(olderThan time.Duration, dryRun bool) {
+	c := loadConfig()
+
+	cloud := cloud_generator.NewCloud(&c)
+	resources, err := cloud.Reap(olderThan, dryRun)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot reap the resources")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tID\tBENCH ID\tCREATED\tSTATUS\t")
+
+	failed := 0
+	for _, r := range resources {
+		created := "unknown"
+		if !r.CreatedAt.IsZero() {
+			created = r.CreatedAt.Local().Format(time.DateTime)
+		}
+
+		status := "deleted"
+		switch {
+		case dryRun:
+			status = "to delete"
+		case r.Err != nil:
+			status = "failed"
+			failed++
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n", r.Kind, r.ID, orNone(r.BenchID), created, status)
+	}
+	w.Flush()
+
+	for _, r := range resources {
+		if r.Err != nil {
+			logger.Error().Err(r.Err).Str("kind", r.Kind).Str("id", r.ID).Msg("Cannot delete the resource")
+		}
+	}
+
+	if failed > 0 {
+		logger.Fatal().Int("failed", failed).Int("resources", len(resources)).Msg("Some resources were not deleted, run bench reap again")
+	}
+
+	if dryRun {
+		logger.Info().Int("resources", len(resources)).Msg("Nothing was deleted, run without --dry-run to delete them")
+		return
+	}
+
+	logger.Info().Int("resources", len(resources)).Msg("Resources reaped")
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
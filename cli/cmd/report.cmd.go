@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+
+	"github.com/heka-ai/benchmark-cli/internal/report"
+	resultsPkg "github.com/heka-ai/benchmark-cli/pkg/results"
+	"github.com/spf13/cobra"
+)
+
+// Render an offline HTML report from one or more results files
+func ReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report [results files...]",
+		Short: "Generate a self-contained HTML report from results files",
+		Long:  `Generate a self-contained HTML report from one or more results files. The report embeds the full dataset so it can be shared as an email attachment.`,
+		Example: `
+		bench report results.json
+		bench report -o comparison.html run-1.json run-2.json
+		`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the output flag")
+			}
+
+			title, err := cmd.Flags().GetString("title")
+			if err != nil {
+				logger.Fatal().Err(err).Msg("Error getting the title flag")
+			}
+
+			reportExec(args, output, title)
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "report.html", "The file to write the report to")
+	cmd.Flags().StringP("title", "t", "Sia Benchmark report", "The title of the report")
+
+	return cmd
+}
+
+// the report only reads the results files, they record the environment of
+// their run
+func reportExec(files []string, output string, title string) {
+	runs := []report.Run{}
+	for _, file := range files {
+		r, err := resultsPkg.ReadFile(file)
+		if err != nil {
+			logger.Fatal().Err(err).Str("file", file).Msg("Cannot read the results")
+		}
+
+		runs = append(runs, report.Run{Source: file, Results: r})
+	}
+
+	out, err := os.Create(output)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot create the report file")
+	}
+	defer out.Close()
+
+	err = report.Render(out, title, runs)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot render the report")
+	}
+
+	logger.Info().Msgf("Report written to %s", output)
+}
@@ -6,7 +6,7 @@ import (
 
 	bench "github.com/heka-ai/benchmark-cli/internal/bench"
 	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
-	"github.com/heka-ai/benchmark-cli/pkg/config"
+	resultsPkg "github.com/heka-ai/benchmark-cli/pkg/results"
 	"github.com/spf13/cobra"
 )
 
@@ -31,12 +31,59 @@ func ResultsCmd() *cobra.Command {
 
 	cmd.Flags().StringP("file", "f", "", "The file to write the results to")
 
+	cmd.AddCommand(ResultsValidateCmd())
+	cmd.AddCommand(ResultsSchemaCmd())
+
 	return cmd
 }
 
+// Check a results file against the results schema
+func ResultsValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Report the structural problems of a results file",
+		Long:  `Validate a results file against the results JSON Schema, older files are migrated to the current schema version first.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			resultsValidate(args[0])
+		},
+	}
+}
+
+// Print the JSON Schema of the results
+func ResultsSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the results files",
+		Run: func(cmd *cobra.Command, args []string) {
+			os.Stdout.Write(resultsPkg.Schema())
+		},
+	}
+}
+
+func resultsValidate(file string) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot read the results file")
+	}
+
+	errs, err := resultsPkg.ValidateDocument(data)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Cannot validate the results file")
+	}
+
+	if len(errs) > 0 {
+		for _, e := range errs {
+			logger.Error().Str("path", e.Path).Msg(e.Message)
+		}
+		logger.Fatal().Int("errors", len(errs)).Msg("The results file is not valid")
+	}
+
+	logger.Info().Int("schema_version", resultsPkg.CurrentSchemaVersion).Msg("The results file is valid")
+}
+
 func results(file string) {
-	config.Init()
-	config := config.GetConfig()
+	config := loadConfig()
 	client := bench.NewClient(config.APIKey)
 
 	cloud := cloud_generator.NewCloud(&config)
@@ -46,11 +93,22 @@ func results(file string) {
 		logger.Fatal().Err(err).Msg("Cannot get the bench instance IP")
 	}
 
+	// the results of a run stopped by a spot interruption are truncated
+	watched := []string{benchInstanceIP}
+	if llmInstanceIP, err := cloud.GetLLMInstanceIP(); err == nil {
+		watched = append(watched, llmInstanceIP)
+	}
+	if err := client.CheckInterruptions(watched...); err != nil {
+		logger.Fatal().Err(err).Msg("The benchmark was interrupted, run it again on new instances")
+	}
+
 	results, err := client.GetResults(benchInstanceIP, config.InferenceEngine)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Cannot get the results")
 	}
 
+	results.FillFromConfig(&config)
+
 	json, err := json.Marshal(results)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Cannot marshal the results")
@@ -9,19 +9,42 @@ func RootCmd() *cobra.Command {
 		Use:   "bench",
 		Short: "Run business oriented LLM benchmarks",
 		Long:  `Sia Benchmark is the cli to run business oriented LLM benchmarks`,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if disabled, _ := cmd.Flags().GetBool("disable-telemetry"); !disabled {
+				initTelemetry()
+			}
+
+			configPath, _ = cmd.Flags().GetString("config")
+			configOptions.Profile, _ = cmd.Flags().GetString("profile")
+			configOptions.Set, _ = cmd.Flags().GetStringArray("set")
+		},
 	}
 
 	rootCmd.AddCommand(ValidateCmd())
+	rootCmd.AddCommand(ConfigCmd())
 	rootCmd.AddCommand(CredsCmd())
+	rootCmd.AddCommand(PlanCmd())
 	rootCmd.AddCommand(InstanceCmd())
 	rootCmd.AddCommand(ConnectionCmd())
+	rootCmd.AddCommand(SSHCmd())
+	rootCmd.AddCommand(CpCmd())
 	rootCmd.AddCommand(DeployCmd())
 	rootCmd.AddCommand(BenchCmd())
 	rootCmd.AddCommand(ResultsCmd())
+	rootCmd.AddCommand(ReportCmd())
+	rootCmd.AddCommand(ExportCmd())
+	rootCmd.AddCommand(GateCmd())
+	rootCmd.AddCommand(PublishCmd())
+	rootCmd.AddCommand(MatrixCmd())
 	rootCmd.AddCommand(DestroyCmd())
+	rootCmd.AddCommand(ReapCmd())
 	rootCmd.AddCommand(InstanceBuildCmd())
+	rootCmd.AddCommand(ImagesCmd())
 
-	rootCmd.Flags().StringP("config", "c", "bench.toml", "Path to the config file")
+	rootCmd.PersistentFlags().Bool("disable-telemetry", false, "Disable telemetry")
+	rootCmd.PersistentFlags().StringP("config", "c", "bench.toml", "Path to the config file")
+	rootCmd.PersistentFlags().StringP("profile", "p", "", "Profile of the config file to apply")
+	rootCmd.PersistentFlags().StringArray("set", []string{}, "Override a config value, e.g. --set vllm.max-num-seqs=256 (repeatable)")
 
 	return rootCmd
 }
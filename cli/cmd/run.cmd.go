@@ -3,7 +3,6 @@ package main
 import (
 	bench "github.com/heka-ai/benchmark-cli/internal/bench"
 	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
-	"github.com/heka-ai/benchmark-cli/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -19,8 +18,7 @@ func BenchCmd() *cobra.Command {
 }
 
 func RunExec() {
-	config.Init()
-	c := config.GetConfig()
+	c := loadConfig()
 
 	client := bench.NewClient(c.APIKey)
 	cloud := cloud_generator.NewCloud(&c)
@@ -30,12 +28,20 @@ func RunExec() {
 		logger.Fatal().Err(err).Msg("Cannot get the bench instance IP")
 	}
 
-	llmInstanceIP, err := cloud.GetLLMInstanceIP()
+	llmAddresses, err := cloud.GetLLMInstanceAddresses()
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Cannot get the LLM instance IP")
 	}
 
-	err = client.RunBenchmark(benchInstanceIP, llmInstanceIP, c.InferenceEngine)
+	// the load goes through the private network when there is one, the
+	// latencies do not include the internet path
+	llmInstanceIP, addressMode := llmAddresses.LoadAddress()
+	logger.Info().Str("ip", llmInstanceIP).Str("address_mode", addressMode).Msg("The bench instance reaches the LLM instance through this address")
+
+	// the benchmark settings may have changed since the deploy
+	pushConfig(client, &c, benchInstanceIP)
+
+	err = client.RunBenchmark(benchInstanceIP, llmInstanceIP, addressMode, c.InferenceEngine, c.BenchmarkConfig.Token)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Cannot run benchmark on bench instance")
 	}
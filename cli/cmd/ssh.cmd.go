@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/heka-ai/benchmark-cli/internal/cloud"
+	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
+	"github.com/heka-ai/benchmark-cli/internal/imagebuilder"
+	"github.com/heka-ai/benchmark-cli/internal/ssh"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func SSHCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "ssh llm|bench [-- command]",
+		Short: "Open a shell on an instance of the benchmark, or run a command on it",
+		Long: `Open a shell on the llm or bench instance of the benchmark with the key pair
+registered by bench create. The command after -- is run instead of the shell,
+its output is streamed and bench exits with its status.`,
+		Args:      cobra.MinimumNArgs(1),
+		ValidArgs: []string{imagebuilder.RoleLLM, imagebuilder.RoleBench},
+		Run: func(cmd *cobra.Command, args []string) {
+			runSSH(args[0], strings.Join(args[1:], " "))
+		},
+	}
+
+	return command
+}
+
+// sshClient connects to the llm or bench instance of the benchmark
+func sshClient(role string) (*ssh.SSHClient, *cloud.SSHAccess) {
+	c := loadConfig()
+
+	access, err := cloud_generator.NewCloud(&c).SSHAccess(role)
+	if err != nil {
+		logger.Fatal().Err(err).Str("instance", role).Msg("Cannot connect to the instance")
+	}
+
+	client := ssh.NewSSHClient(access.KeyPath, access.Host, access.User, access.KnownHostsPath)
+
+	return client, access
+}
+
+func runSSH(role string, command string) {
+	client, access := sshClient(role)
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fd := int(os.Stdin.Fd())
+
+	var err error
+	if term.IsTerminal(fd) {
+		err = runTerminal(ctx, client, command, fd)
+	} else if command != "" {
+		// the output of the command can be piped
+		err = client.Stream(ctx, command, os.Stdout, os.Stderr)
+	} else {
+		logger.Fatal().Msg("The input is not a terminal, give the command to run after --")
+	}
+
+	if err == nil {
+		return
+	}
+
+	status, exited := ssh.ExitStatus(err)
+	if !exited {
+		logger.Error().Err(err).Str("host", access.Host).Msg("The connection to the instance failed")
+	}
+
+	client.Close()
+	os.Exit(status)
+}
+
+// runTerminal puts the local terminal in raw mode, the keys like Ctrl-C go
+// to the remote shell
+func runTerminal(ctx context.Context, client *ssh.SSHClient, command string, fd int) error {
+	width, height, err := term.GetSize(fd)
+	if err != nil {
+		width, height = 80, 24
+	}
+
+	termType := os.Getenv("TERM")
+	if termType == "" {
+		termType = "xterm-256color"
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	return client.Shell(ctx, command, termType, width, height, os.Stdin, os.Stdout, os.Stderr)
+}
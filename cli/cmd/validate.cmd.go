@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/heka-ai/benchmark-cli/pkg/config"
@@ -15,6 +16,7 @@ func ValidateCmd() *cobra.Command {
 		Long:  `Validate the benchmark by checking the toml config file`,
 		Example: `
 		bench validate
+		bench validate --profile large --set vllm.max-num-seqs=256 --print
 		`,
 		Run: func(cmd *cobra.Command, args []string) {
 			vllmModel, err := cmd.Flags().GetBool("vllm-command")
@@ -29,24 +31,47 @@ func ValidateCmd() *cobra.Command {
 				return
 			}
 
-			ValidateExec(vllmModel, benchmarkModel)
+			printConfig, err := cmd.Flags().GetBool("print")
+			if err != nil {
+				logger.Error().Err(err).Msg("Error getting the print flag")
+				return
+			}
+
+			ValidateExec(vllmModel, benchmarkModel, printConfig)
 		},
 	}
 
 	cmd.Flags().Bool("vllm-command", false, "The model to use for the VLLM command")
 	cmd.Flags().Bool("benchmark-command", false, "The model to use for the benchmark command")
+	cmd.Flags().Bool("print", false, "Print the resolved config, after the extends, profile and overrides are applied")
 
 	return cmd
 }
 
 // This only validate that the TOML config file is valid
-func ValidateExec(vllmModel bool, benchmarkModel bool) {
+func ValidateExec(vllmModel bool, benchmarkModel bool, printConfig bool) {
 	logger.Info().Msg("Validating the config file")
-	config.Init()
+	cfg := loadConfig()
+	warnings, err := config.CheckEngineFlags(&cfg)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error checking the engine flags")
+	}
 
-	if vllmModel {
-		cfg := config.GetConfig()
+	for _, warning := range warnings {
+		logger.Warn().Str("version", cfg.EngineVersion()).Msg(warning)
+	}
 
+	if printConfig {
+		resolved, err := config.Marshal(config.Mask(&cfg))
+		if err != nil {
+			logger.Error().Err(err).Msg("Error encoding the config")
+			return
+		}
+
+		fmt.Print(string(resolved))
+	}
+
+	if vllmModel {
 		localArgs, err := config.GenerateVLLMCommand(cfg.VLLMConfig)
 		if err != nil {
 			logger.Error().Err(err).Msg("Error generating the VLLM command")
@@ -57,8 +82,6 @@ func ValidateExec(vllmModel bool, benchmarkModel bool) {
 	}
 
 	if benchmarkModel {
-		cfg := config.GetConfig()
-
 		localArgs, err := config.GenerateBenchmarkCommand(&cfg, "127.0.0.1")
 		if err != nil {
 			logger.Error().Err(err).Msg("Error generating the benchmark command")
@@ -2,13 +2,16 @@ package bench
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
 
 	log "github.com/heka-ai/benchmark-cli/internal/logs"
+	"github.com/heka-ai/benchmark-cli/pkg/config"
 	"github.com/heka-ai/benchmark-cli/pkg/results"
 )
 
@@ -49,6 +52,10 @@ func (c *Client) WaitForInstances(benchIP, llmIP string) error {
 			llmDone = true
 		}
 
+		if cpuDone && llmDone {
+			return nil
+		}
+
 		time.Sleep(waitInterval)
 	}
 
@@ -56,8 +63,8 @@ func (c *Client) WaitForInstances(benchIP, llmIP string) error {
 }
 
 // deploy the model on the instance
-// will also upload the config to the instance
-func (c *Client) Deploy(ip string, engine string) error {
+// the HF token is sent with the request, it is not part of the config of the instance
+func (c *Client) Deploy(ip string, engine string, hfToken string) error {
 	// config to string
 	request, err := http.NewRequest("GET", fmt.Sprintf("http://%s:8001/%s/start", ip, engine), nil)
 	if err != nil {
@@ -65,6 +72,7 @@ func (c *Client) Deploy(ip string, engine string) error {
 	}
 
 	request.Header.Add("X-API-Key", c.APIKey)
+	request.Header.Add("X-HF-Token", hfToken)
 
 	resp, err := c.httpClient.Do(request)
 	if err != nil {
@@ -80,6 +88,49 @@ func (c *Client) Deploy(ip string, engine string) error {
 	return nil
 }
 
+// PushConfig replaces the config of the instance, the instance validates it
+// and reloads it. The secrets are left out, they never reach the instances.
+// The problems of a config refused as invalid are returned as KeyErrors.
+func (c *Client) PushConfig(ip string, conf *config.Config) error {
+	body, err := config.Marshal(config.Redact(conf))
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest("PUT", fmt.Sprintf("http://%s:8001/config", ip), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Add("X-API-Key", c.APIKey)
+	request.Header.Add("Content-Type", "application/toml")
+
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var result struct {
+		Error  string           `json:"error"`
+		Errors config.KeyErrors `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to push the config: %s", resp.Status)
+	}
+
+	if len(result.Errors) > 0 {
+		return result.Errors
+	}
+
+	return fmt.Errorf("failed to push the config: %s: %s", resp.Status, result.Error)
+}
+
 func (c *Client) HealthCheck(ip string) error {
 	request, err := http.NewRequest("GET", fmt.Sprintf("http://%s:8001/health", ip), nil)
 	if err != nil {
@@ -103,9 +154,7 @@ func (c *Client) HealthCheck(ip string) error {
 }
 
 func (c *Client) WaitForLLM(ip string) error {
-	done := false
-
-	for i := 0; i < maxIterations && !done; i++ {
+	for i := 0; i < maxIterations; i++ {
 		done, _ := c.ModelStatus(ip)
 
 		if done {
@@ -124,6 +173,8 @@ func (c *Client) ModelStatus(ip string) (bool, error) {
 		return false, err
 	}
 
+	defer res.Body.Close()
+
 	if res.StatusCode != http.StatusOK {
 		return false, fmt.Errorf("failed to get model status: %s", res.Status)
 	}
@@ -133,27 +184,28 @@ func (c *Client) ModelStatus(ip string) (bool, error) {
 		return false, err
 	}
 
-	// check if the body is a json and the vllm model is ready
-	var result map[string]interface{}
+	// the server only answers once the model is loaded, it is ready when it lists it
+	var result struct {
+		Data []map[string]interface{} `json:"data"`
+	}
 	if err := json.Unmarshal(body, &result); err != nil {
 		return false, fmt.Errorf("failed to parse model status response: %v", err)
 	}
 
-	if status, ok := result["status"].(string); ok && status == "ready" {
-		return true, nil
-	}
-
-	return false, nil
+	return len(result.Data) > 0, nil
 }
 
-func (c *Client) RunBenchmark(ip string, llmIp string, engineType string) error {
+// RunBenchmark starts the benchmark on the bench instance at ip against the
+// LLM instance at llmIp, the address mode tells which network llmIp is on
+func (c *Client) RunBenchmark(ip string, llmIp string, addressMode string, engineType string, hfToken string) error {
 	request, err := http.NewRequest("POST", fmt.Sprintf("http://%s:8001/bench/%s/start", ip, engineType), nil)
 	if err != nil {
 		return err
 	}
 
 	body, err := json.Marshal(map[string]string{
-		"ip": llmIp,
+		"ip":           llmIp,
+		"address_mode": addressMode,
 	})
 
 	if err != nil {
@@ -161,6 +213,7 @@ func (c *Client) RunBenchmark(ip string, llmIp string, engineType string) error
 	}
 
 	request.Header.Add("X-API-Key", c.APIKey)
+	request.Header.Add("X-HF-Token", hfToken)
 	request.Body = io.NopCloser(bytes.NewBuffer(body))
 
 	resp, err := c.httpClient.Do(request)
@@ -177,6 +230,164 @@ func (c *Client) RunBenchmark(ip string, llmIp string, engineType string) error
 	return nil
 }
 
+// Status of the benchmark running on the bench instance, see the API
+const (
+	BenchmarkIdle     = "idle"
+	BenchmarkRunning  = "running"
+	BenchmarkFinished = "finished"
+	BenchmarkFailed   = "failed"
+)
+
+type BenchmarkState struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (c *Client) BenchmarkStatus(ip string, engineType string) (*BenchmarkState, error) {
+	request, err := http.NewRequest("GET", fmt.Sprintf("http://%s:8001/bench/%s/status", ip, engineType), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Add("X-API-Key", c.APIKey)
+
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get the benchmark status: %s", resp.Status)
+	}
+
+	state := &BenchmarkState{}
+	if err := json.NewDecoder(resp.Body).Decode(state); err != nil {
+		return nil, fmt.Errorf("failed to parse the benchmark status: %v", err)
+	}
+
+	return state, nil
+}
+
+// ErrInterrupted is matched by the errors of a run stopped by a spot
+// interruption, the run can be retried on new instances
+var ErrInterrupted = errors.New("spot instance interrupted")
+
+// InterruptedError is returned when an instance received a spot
+// interruption notice, the results of its run would be truncated
+type InterruptedError struct {
+	IP string
+	// terminate, stop or hibernate
+	Action string
+	Time   string
+}
+
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("the spot instance %s is interrupted, it will %s at %s", e.IP, e.Action, e.Time)
+}
+
+func (e *InterruptedError) Is(target error) bool {
+	return target == ErrInterrupted
+}
+
+// InstanceInterruption returns an InterruptedError when the instance
+// received a spot interruption notice, the notice comes two minutes before
+// the instance is reclaimed
+func (c *Client) InstanceInterruption(ip string) error {
+	request, err := http.NewRequest("GET", fmt.Sprintf("http://%s:8001/instance/interruption", ip), nil)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Add("X-API-Key", c.APIKey)
+
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get the instance interruption: %s", resp.Status)
+	}
+
+	var notice struct {
+		Interrupted bool   `json:"interrupted"`
+		Action      string `json:"action"`
+		Time        string `json:"time"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&notice); err != nil {
+		return fmt.Errorf("failed to parse the instance interruption: %v", err)
+	}
+
+	if !notice.Interrupted {
+		return nil
+	}
+
+	return &InterruptedError{IP: ip, Action: notice.Action, Time: notice.Time}
+}
+
+// CheckInterruptions returns the InterruptedError of the first interrupted
+// instance, the instances that cannot be reached are not interrupted yet
+func (c *Client) CheckInterruptions(ips ...string) error {
+	for _, ip := range ips {
+		err := c.InstanceInterruption(ip)
+		if errors.Is(err, ErrInterrupted) {
+			return err
+		}
+
+		if err != nil {
+			logger.Debug().Err(err).Str("ip", ip).Msg("Cannot check the instance interruption")
+		}
+	}
+
+	return nil
+}
+
+// WaitForBenchmark polls the bench instance until the benchmark is done,
+// every interval, there is no iteration limit as a run can take hours.
+// The bench and the LLM instances are checked for a spot interruption at
+// each poll, an interrupted run returns an InterruptedError.
+func (c *Client) WaitForBenchmark(ctx context.Context, ip string, llmIP string, engineType string, interval time.Duration) error {
+	for {
+		if err := c.CheckInterruptions(ip, llmIP); err != nil {
+			return err
+		}
+
+		state, err := c.BenchmarkStatus(ip, engineType)
+		if err != nil {
+			// the instance may have been reclaimed since the last poll
+			if interruptErr := c.CheckInterruptions(ip, llmIP); interruptErr != nil {
+				return interruptErr
+			}
+
+			return err
+		}
+
+		switch state.Status {
+		case BenchmarkFinished:
+			return nil
+		case BenchmarkFailed:
+			// the load fails once the LLM instance is reclaimed
+			if err := c.CheckInterruptions(ip, llmIP); err != nil {
+				return err
+			}
+
+			return fmt.Errorf("benchmark failed: %s", state.Error)
+		case BenchmarkIdle:
+			return errors.New("no benchmark was started on the bench instance")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
 func (c *Client) GetResults(ip string, engineType string) (*results.Results, error) {
 	request, err := http.NewRequest("GET", fmt.Sprintf("http://%s:8001/bench/%s/results", ip, engineType), nil)
 	if err != nil {
@@ -204,12 +415,12 @@ func (c *Client) GetResults(ip string, engineType string) (*results.Results, err
 		return nil, err
 	}
 
-	var results results.Results
-	if err := json.Unmarshal(body, &results); err != nil {
+	results, err := results.Parse(body)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse results: %v", err)
 	}
 
-	return &results, nil
+	return results, nil
 }
 
 func (c *Client) GetLogs(ip string, logsType string) (string, error) {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/smithy-go"
@@ -16,6 +17,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/heka-ai/benchmark-cli/internal/bench"
 	"github.com/heka-ai/benchmark-cli/internal/cloud"
 	"github.com/heka-ai/benchmark-cli/internal/constants"
@@ -25,9 +28,18 @@ import (
 
 var logger = log.GetLogger("aws")
 
+// the states of the instances that are not terminated
+var liveStates = []string{"pending", "running", "shutting-down", "stopping", "stopped"}
+
+// ErrNoInstance is returned when the benchmark has no instance left
+var ErrNoInstance = errors.New("no instance found for this benchmark")
+
 const (
 	instanceProfileName = "benchmark-cli-ec2-instance-profile"
 	roleName            = "benchmark-cli-ec2-role"
+
+	// size of the root volume of the instances in GB, it holds the model
+	rootVolumeSize = 200
 )
 
 type AWSClient struct {
@@ -37,7 +49,15 @@ type AWSClient struct {
 	config  *config.Config
 	svc     *ec2.Client
 	iam     *iam.Client
+	sts     *sts.Client
+	quotas  *servicequotas.Client
 	wasInit bool
+
+	// the clients of the other regions are created from it
+	conf aws.Config
+
+	// resolved on the first instance creation
+	network *network
 }
 
 func NewClient(config *config.Config) *AWSClient {
@@ -71,39 +91,17 @@ func (c *AWSClient) Init() cloud.Cloud {
 		os.Exit(1)
 	}
 
+	c.conf = conf
 	c.svc = ec2.NewFromConfig(conf)
 	c.iam = iam.NewFromConfig(conf)
+	c.sts = sts.NewFromConfig(conf)
+	c.quotas = servicequotas.NewFromConfig(conf)
 
 	c.wasInit = true
 
 	return c
 }
 
-func (c *AWSClient) validateCredentials() error {
-	if !c.wasInit {
-		return errors.New("client not initialized")
-	}
-
-	err := c.createInstance(c.config.AWSConfig.CPUInstanceType, true, c.config.AWSConfig.GPU_AMI, []types.Tag{}, "")
-	if err != nil {
-		logger.Error().Msgf("Cannot create an EC2 instance")
-		return err
-	}
-
-	logger.Info().Msg("OK - Can create an instance")
-
-	// this instance id do not exists
-	err = c.deleteInstance("i-123456", true)
-	if err != nil {
-		logger.Error().Msgf("Cannot delete an EC2 instance")
-		return err
-	}
-
-	logger.Info().Msg("OK - Can delete an instance")
-
-	return nil
-}
-
 func isDryRunError(err error) bool {
 	var apiErr smithy.APIError
 	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "DryRunOperation"
@@ -127,25 +125,42 @@ func (c *AWSClient) deleteInstance(instanceID string, dryRun bool) error {
 	return nil
 }
 
-func (c *AWSClient) createInstance(instanceType string, dryRun bool, ami string, tags []types.Tag, userData string) error {
-	instanceName := fmt.Sprintf("benchmark-%s", c.config.BenchID)
-
-	defaultTags := []types.Tag{
+// managedTags are set on all the resources created by the CLI, bench reap
+// finds the old ones with them
+func managedTags() []types.Tag {
+	return []types.Tag{
 		{
-			Key:   aws.String("managed-by"),
-			Value: aws.String("benchmark-cli"),
+			Key:   aws.String(constants.ManagedByTag),
+			Value: aws.String(constants.ManagedByValue),
 		},
 		{
-			Key:   aws.String("bench-id"),
+			Key:   aws.String(constants.CreatedAtTag),
+			Value: aws.String(time.Now().UTC().Format(time.RFC3339)),
+		},
+	}
+}
+
+// defaultTags are set on all the resources created for the benchmark
+func (c *AWSClient) defaultTags() []types.Tag {
+	return append(managedTags(), []types.Tag{
+		{
+			Key:   aws.String(constants.BenchIDTag),
 			Value: aws.String(c.config.BenchID),
 		},
 		{
 			Key:   aws.String("Name"),
-			Value: aws.String(instanceName),
+			Value: aws.String(fmt.Sprintf("benchmark-%s", c.config.BenchID)),
 		},
-	}
+	}...)
+}
 
-	allTags := slices.Concat(tags, defaultTags)
+func (c *AWSClient) createInstance(instanceType string, maxPrice float64, dryRun bool, ami string, tags []types.Tag, userData string) error {
+	allTags := slices.Concat(tags, c.defaultTags(), []types.Tag{
+		{
+			Key:   aws.String(constants.ExpiresAtTag),
+			Value: aws.String(time.Now().Add(c.ttl()).UTC().Format(time.RFC3339)),
+		},
+	})
 	base64UserData := base64.StdEncoding.EncodeToString([]byte(userData))
 
 	logger.Debug().Str("instance-type", instanceType).Str("ami", ami).Str("user-data", base64UserData).Interface("tags", allTags).Msg("Creating the instance")
@@ -157,13 +172,16 @@ func (c *AWSClient) createInstance(instanceType string, dryRun bool, ami string,
 		return err
 	}
 
-	_, err = c.svc.RunInstances(context.TODO(), &ec2.RunInstancesInput{
+	input := &ec2.RunInstancesInput{
 		InstanceType: types.InstanceType(instanceType),
 		ImageId:      aws.String(ami),
 		MinCount:     aws.Int32(1),
 		MaxCount:     aws.Int32(1),
 		DryRun:       aws.Bool(dryRun),
 		UserData:     aws.String(base64UserData),
+		// the watchdog of the user data powers the instance off at the end
+		// of its TTL, it is then terminated
+		InstanceInitiatedShutdownBehavior: types.ShutdownBehaviorTerminate,
 		TagSpecifications: []types.TagSpecification{
 			{
 				ResourceType: types.ResourceTypeInstance,
@@ -177,18 +195,61 @@ func (c *AWSClient) createInstance(instanceType string, dryRun bool, ami string,
 			{
 				DeviceName: aws.String("/dev/sda1"),
 				Ebs: &types.EbsBlockDevice{
-					VolumeSize: aws.Int32(200),
+					VolumeSize: aws.Int32(rootVolumeSize),
 				},
 			},
 		},
-	})
+		InstanceMarketOptions: c.marketOptions(maxPrice),
+	}
+
+	// the credentials check does not create the security group
+	if !dryRun {
+		network, err := c.getNetwork()
+		if err != nil {
+			logger.Error().Err(err).Msg("Error while preparing the network of the instance")
+			return err
+		}
+
+		// the interface of a subnet chosen by the config needs a public IP
+		// to be reached, whatever the setting of the subnet
+		input.NetworkInterfaces = []types.InstanceNetworkInterfaceSpecification{
+			{
+				DeviceIndex:              aws.Int32(0),
+				AssociatePublicIpAddress: aws.Bool(true),
+				Groups:                   []string{network.securityGroupID},
+			},
+		}
+		if network.subnetID != "" {
+			input.NetworkInterfaces[0].SubnetId = aws.String(network.subnetID)
+		}
+
+		if network.placementGroup != "" {
+			input.Placement = &types.Placement{GroupName: aws.String(network.placementGroup)}
+		}
+
+		// bench ssh and bench cp connect with it
+		keyName, err := c.getOrCreateKeyPair()
+		if err != nil {
+			logger.Error().Err(err).Msg("Error while preparing the key pair of the instance")
+			return err
+		}
+		input.KeyName = aws.String(keyName)
+	}
+
+	output, err := c.svc.RunInstances(context.TODO(), input)
 
 	if err != nil {
 		if isDryRunError(err) {
 			return nil
 		}
 
-		return err
+		return c.capacityError(err, instanceType)
+	}
+
+	// the next instance goes to the same subnet, the latency between the
+	// instances does not depend on the availability zones AWS picks
+	if c.network != nil && c.network.subnetID == "" && len(output.Instances) > 0 {
+		c.network.subnetID = aws.ToString(output.Instances[0].SubnetId)
 	}
 
 	return nil
@@ -267,7 +328,13 @@ func (c *AWSClient) getOrCreateEC2InstanceProfile() (*string, error) {
 	return instanceProfile.InstanceProfile.Arn, nil
 }
 
+// GetBenchmarkInstances returns the running instances of the benchmark
 func (c *AWSClient) GetBenchmarkInstances() ([]types.Instance, error) {
+	return c.benchmarkInstances("running")
+}
+
+// benchmarkInstances returns the instances of the benchmark in the states
+func (c *AWSClient) benchmarkInstances(states ...string) ([]types.Instance, error) {
 	describeInstanceOutput, err := c.svc.DescribeInstances(context.TODO(), &ec2.DescribeInstancesInput{
 		Filters: []types.Filter{
 			{
@@ -276,7 +343,7 @@ func (c *AWSClient) GetBenchmarkInstances() ([]types.Instance, error) {
 			},
 			{
 				Name:   aws.String("instance-state-name"),
-				Values: []string{"running"},
+				Values: states,
 			},
 		},
 	})
@@ -294,12 +361,12 @@ func (c *AWSClient) GetBenchmarkInstances() ([]types.Instance, error) {
 	return allInstances, nil
 }
 
-func (c *AWSClient) CreateInstance(instanceType string, ami string, tags []types.Tag, userData string) error {
+func (c *AWSClient) CreateInstance(instanceType string, maxPrice float64, ami string, tags []types.Tag, userData string) error {
 	if !c.wasInit {
 		return errors.New("client not initialized")
 	}
 
-	err := c.createInstance(instanceType, false, ami, tags, userData)
+	err := c.createInstance(instanceType, maxPrice, false, ami, tags, userData)
 	if err != nil {
 		return err
 	}
@@ -314,14 +381,15 @@ func (c *AWSClient) DeleteInstance() error {
 		return errors.New("client not initialized")
 	}
 
-	instances, err := c.GetBenchmarkInstances()
+	// an instance that was just created is still pending
+	instances, err := c.benchmarkInstances(liveStates...)
 
 	if err != nil {
 		return err
 	}
 
 	if len(instances) == 0 {
-		return errors.New("no instance found for this benchmark")
+		return ErrNoInstance
 	}
 
 	for _, v := range instances {
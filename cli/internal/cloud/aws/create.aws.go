@@ -1,58 +1,94 @@
 package aws
 
 import (
-	"flag"
+	"context"
 	"fmt"
-	"os"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/heka-ai/benchmark-cli/internal/constants"
+	"github.com/heka-ai/benchmark-cli/pkg/config"
 )
 
+// apiKeyHashFile is read by the API of the instances, see api.service
+const apiKeyHashFile = "/home/ubuntu/api-key.sha256"
+
 func (c *AWSClient) Create() error {
-	filename := flag.Lookup("config").Value.String()
-	configString, err := os.ReadFile(filename)
+	if err := c.resolveImages(); err != nil {
+		logger.Error().Err(err).Msg("Cannot find the images of the instances")
+		return err
+	}
+
+	if err := c.checkPlacement(); err != nil {
+		logger.Error().Err(err).Msg("Cannot place the instances")
+		return err
+	}
 
+	// each instance gets its own salt
+	llmUserData, err := c.userData()
 	if err != nil {
-		logger.Error().Err(err).Msg("Error while reading the config file")
 		return err
 	}
 
-	// set the basic benchmark config env var
-	userData := fmt.Sprintf(`#!/bin/bash
-echo "API_KEY=%s" > /home/ubuntu/.bashrc
-TOML_FILE='
-%s
-'
-touch /home/ubuntu/config.toml
-echo "$TOML_FILE" > /home/ubuntu/config.toml
-		`, c.config.APIKey, configString)
+	benchUserData, err := c.userData()
+	if err != nil {
+		return err
+	}
 
-	err = c.CreateInstance(c.config.AWSConfig.GPUInstanceType, c.config.AWSConfig.GPU_AMI, []types.Tag{
+	err = c.CreateInstance(c.config.AWSConfig.GPUInstanceType, c.config.AWSConfig.GPUMaxPrice, c.config.AWSConfig.GPU_AMI, []types.Tag{
 		{
 			Key:   aws.String(constants.BenchInstanceLabelKey),
 			Value: aws.String(constants.LLMInstanceLabelValue),
 		},
-	}, userData)
+	}, llmUserData)
 
 	if err != nil {
 		logger.Error().Err(err).Msg("Error while creating the GPU instance")
 		return err
 	}
 
-	err = c.CreateInstance(c.config.AWSConfig.CPUInstanceType, c.config.AWSConfig.CPU_AMI, []types.Tag{
+	err = c.CreateInstance(c.config.AWSConfig.CPUInstanceType, c.config.AWSConfig.CPUMaxPrice, c.config.AWSConfig.CPU_AMI, []types.Tag{
 		{
 			Key:   aws.String(constants.BenchInstanceLabelKey),
 			Value: aws.String(constants.BenchInstanceLabelValue),
 		},
-	}, userData)
+	}, benchUserData)
 
 	if err != nil {
-		// TODO: delete the GPU instance
 		logger.Error().Err(err).Msg("Error while creating the CPU instance")
+
+		// the GPU instance is useless alone, and costly
+		if destroyErr := c.Destroy(); destroyErr != nil {
+			logger.Error().Err(destroyErr).Msg("Cannot destroy the GPU instance, run bench destroy")
+		}
+
 		return err
 	}
 
+	// the instances are usable without, bench ssh retries to pin the keys
+	logger.Info().Msg("Waiting for the host keys of the instances")
+	if err := c.pinBenchmarkHostKeys(context.TODO()); err != nil {
+		logger.Warn().Err(err).Msg("Cannot pin the host keys of the instances")
+	}
+
+	if err := c.openEngineToBench(); err != nil {
+		logger.Warn().Err(err).Msg("The engine is only reachable over the private network")
+	}
+
 	return nil
 }
+
+// userData writes the salted hash of the API key on the instance. The user
+// data can be read by anyone allowed to describe the instances, the config is
+// pushed over the API once the instances are up (see bench deploy).
+func (c *AWSClient) userData() (string, error) {
+	hash, err := config.HashAPIKey(c.config.APIKey)
+	if err != nil {
+		return "", fmt.Errorf("cannot hash the API key: %w", err)
+	}
+
+	return fmt.Sprintf(`#!/bin/bash
+echo '%s' > %s
+chown ubuntu:ubuntu %s
+%s`, hash, apiKeyHashFile, apiKeyHashFile, c.watchdogScript()), nil
+}
@@ -1,5 +1,318 @@
 package aws
 
-func (c *AWSClient) ValidateCredentials() error {
-	return c.validateCredentials()
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/heka-ai/benchmark-cli/internal/cloud"
+)
+
+// Steps of the pipeline, the actions they call are checked by bench creds
+const (
+	stepCreate  = "create"
+	stepRun     = "deploy/run"
+	stepDestroy = "destroy"
+	stepPlan    = "plan"
+	stepReap    = "reap"
+	stepImages  = "create-instance"
+	stepPublish = "images publish"
+)
+
+// the steps the benchmark can run without
+var optionalSteps = map[string]bool{
+	stepPlan:    true,
+	stepReap:    true,
+	stepImages:  true,
+	stepPublish: true,
+}
+
+// permission is an action called by a step on a resource
+type permission struct {
+	step     string
+	action   string
+	resource string
+}
+
+// ValidateCredentials simulates the IAM policies of the caller for every
+// action the pipeline of the config calls, then runs the launches with
+// DryRun. The simulation is skipped when the caller cannot be simulated, the
+// root user or a caller without iam:SimulatePrincipalPolicy.
+func (c *AWSClient) ValidateCredentials() ([]cloud.PermissionCheck, error) {
+	if !c.wasInit {
+		return nil, errors.New("client not initialized")
+	}
+
+	identity, err := c.sts.GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("the credentials are not valid: %w", err)
+	}
+
+	logger.Info().Str("arn", aws.ToString(identity.Arn)).Msg("OK - The credentials are valid")
+
+	checks, err := c.simulatePermissions(aws.ToString(identity.Arn), aws.ToString(identity.Account))
+	if err != nil {
+		logger.Warn().Err(err).Msg("Cannot simulate the IAM policies, only the dry runs are checked")
+	}
+
+	// the dry runs need the images
+	if err := c.resolveImages(); err != nil {
+		logger.Warn().Err(err).Msg("The launches are checked without their image")
+	}
+
+	return append(checks, c.dryRunChecks()...), nil
+}
+
+// requiredPermissions lists the actions of the pipeline of the config, the
+// network and placement actions depend on the [aws] settings
+func (c *AWSClient) requiredPermissions(accountID string) []permission {
+	roleARN := fmt.Sprintf("arn:aws:iam::%s:role/%s", accountID, roleName)
+	instanceProfileARN := fmt.Sprintf("arn:aws:iam::%s:instance-profile/%s", accountID, instanceProfileName)
+
+	permissions := []permission{
+		{stepCreate, "ec2:RunInstances", "*"},
+		{stepCreate, "ec2:CreateTags", "*"},
+		{stepCreate, "ec2:DescribeInstances", "*"},
+		{stepCreate, "ec2:GetConsoleOutput", "*"},
+		{stepCreate, "ec2:DescribeKeyPairs", "*"},
+		{stepCreate, "ec2:ImportKeyPair", "*"},
+		{stepCreate, "ec2:DeleteKeyPair", "*"},
+		{stepCreate, "iam:GetRole", roleARN},
+		{stepCreate, "iam:CreateRole", roleARN},
+		{stepCreate, "iam:AttachRolePolicy", roleARN},
+		{stepCreate, "iam:PassRole", roleARN},
+		{stepCreate, "iam:GetInstanceProfile", instanceProfileARN},
+		{stepCreate, "iam:CreateInstanceProfile", instanceProfileARN},
+		{stepCreate, "iam:AddRoleToInstanceProfile", instanceProfileARN},
+	}
+
+	networkConfig := c.networkConfig()
+	if networkConfig.VPCID != "" || networkConfig.SubnetID != "" {
+		permissions = append(permissions,
+			permission{stepCreate, "ec2:DescribeSubnets", "*"},
+			permission{stepImages, "ec2:DescribeSubnets", "*"},
+		)
+	}
+
+	if networkConfig.SecurityGroupID == "" {
+		permissions = append(permissions,
+			permission{stepCreate, "ec2:DescribeSecurityGroups", "*"},
+			permission{stepCreate, "ec2:CreateSecurityGroup", "*"},
+			permission{stepCreate, "ec2:AuthorizeSecurityGroupIngress", "*"},
+			permission{stepDestroy, "ec2:DescribeSecurityGroups", "*"},
+			permission{stepDestroy, "ec2:DeleteSecurityGroup", "*"},
+			// the builder of the images gets its own group
+			permission{stepImages, "ec2:CreateSecurityGroup", "*"},
+			permission{stepImages, "ec2:AuthorizeSecurityGroupIngress", "*"},
+			permission{stepImages, "ec2:DeleteSecurityGroup", "*"},
+		)
+	}
+
+	if c.clusterPlacement() {
+		permissions = append(permissions,
+			permission{stepCreate, "ec2:DescribePlacementGroups", "*"},
+			permission{stepCreate, "ec2:CreatePlacementGroup", "*"},
+			permission{stepDestroy, "ec2:DescribePlacementGroups", "*"},
+			permission{stepDestroy, "ec2:DeletePlacementGroup", "*"},
+		)
+	}
+
+	permissions = append(permissions,
+		permission{stepRun, "ec2:DescribeInstances", "*"},
+		permission{stepRun, "ec2:GetConsoleOutput", "*"},
+		permission{stepDestroy, "ec2:DescribeInstances", "*"},
+		permission{stepDestroy, "ec2:TerminateInstances", "*"},
+		permission{stepDestroy, "ec2:DescribeKeyPairs", "*"},
+		permission{stepDestroy, "ec2:DeleteKeyPair", "*"},
+
+		permission{stepPlan, "ec2:DescribeImages", "*"},
+		permission{stepPlan, "ec2:DescribeInstanceTypes", "*"},
+		permission{stepPlan, "ec2:DescribeKeyPairs", "*"},
+		permission{stepPlan, "ec2:DescribeInstances", "*"},
+		permission{stepPlan, "servicequotas:GetServiceQuota", "*"},
+		permission{stepPlan, "servicequotas:GetAWSDefaultServiceQuota", "*"},
+
+		permission{stepReap, "ec2:DescribeInstances", "*"},
+		permission{stepReap, "ec2:TerminateInstances", "*"},
+		permission{stepReap, "ec2:DescribeKeyPairs", "*"},
+		permission{stepReap, "ec2:DeleteKeyPair", "*"},
+		permission{stepReap, "ec2:DescribeImages", "*"},
+		permission{stepReap, "ec2:DeregisterImage", "*"},
+		permission{stepReap, "ec2:DeleteSnapshot", "*"},
+		permission{stepReap, "ec2:DescribeSecurityGroups", "*"},
+		permission{stepReap, "ec2:DeleteSecurityGroup", "*"},
+		permission{stepReap, "ec2:DescribePlacementGroups", "*"},
+		permission{stepReap, "ec2:DeletePlacementGroup", "*"},
+
+		permission{stepImages, "ec2:CreateKeyPair", "*"},
+		permission{stepImages, "ec2:DeleteKeyPair", "*"},
+		permission{stepImages, "ec2:RunInstances", "*"},
+		permission{stepImages, "ec2:DescribeInstanceStatus", "*"},
+		permission{stepImages, "ec2:CreateImage", "*"},
+		permission{stepImages, "ec2:DescribeImages", "*"},
+		permission{stepImages, "ec2:DeleteTags", "*"},
+		permission{stepImages, "ec2:DescribeInstances", "*"},
+		permission{stepImages, "ec2:GetConsoleOutput", "*"},
+		permission{stepImages, "ec2:TerminateInstances", "*"},
+		permission{stepImages, "ec2:DeregisterImage", "*"},
+		permission{stepImages, "ec2:DeleteSnapshot", "*"},
+
+		permission{stepPublish, "ec2:DescribeImages", "*"},
+		permission{stepPublish, "ec2:CopyImage", "*"},
+		permission{stepPublish, "ec2:CreateTags", "*"},
+		permission{stepPublish, "ec2:DeleteTags", "*"},
+		permission{stepPublish, "ec2:ModifyImageAttribute", "*"},
+	)
+
+	// the latest images are looked up when the config has none
+	if c.config.AWSConfig.GPU_AMI == "" || c.config.AWSConfig.CPU_AMI == "" {
+		permissions = append(permissions, permission{stepCreate, "ec2:DescribeImages", "*"})
+	}
+
+	if c.spotMarket() {
+		permissions = append(permissions, permission{stepPlan, "ec2:DescribeSpotPriceHistory", "*"})
+	}
+
+	return permissions
+}
+
+// simulatePermissions evaluates the IAM policies of the caller for the
+// actions of the pipeline, an action called by several steps is checked once
+func (c *AWSClient) simulatePermissions(callerARN string, accountID string) ([]cloud.PermissionCheck, error) {
+	principalARN, err := c.principalARN(callerARN)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := []cloud.PermissionCheck{}
+	index := map[string]int{}
+	actionsByResource := map[string][]string{}
+	resources := []string{}
+
+	for _, p := range c.requiredPermissions(accountID) {
+		key := p.action + " " + p.resource
+		if i, ok := index[key]; ok {
+			if !slices.Contains(checks[i].Steps, p.step) {
+				checks[i].Steps = append(checks[i].Steps, p.step)
+			}
+			checks[i].Optional = checks[i].Optional && optionalSteps[p.step]
+			continue
+		}
+
+		index[key] = len(checks)
+		checks = append(checks, cloud.PermissionCheck{
+			Action:   p.action,
+			Resource: p.resource,
+			Steps:    []string{p.step},
+			Optional: optionalSteps[p.step],
+		})
+
+		if _, ok := actionsByResource[p.resource]; !ok {
+			resources = append(resources, p.resource)
+		}
+		actionsByResource[p.resource] = append(actionsByResource[p.resource], p.action)
+	}
+
+	for _, resource := range resources {
+		paginator := iam.NewSimulatePrincipalPolicyPaginator(c.iam, &iam.SimulatePrincipalPolicyInput{
+			PolicySourceArn: aws.String(principalARN),
+			ActionNames:     actionsByResource[resource],
+			ResourceArns:    []string{resource},
+		})
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(context.TODO())
+			if err != nil {
+				return nil, fmt.Errorf("cannot simulate the policies of %s: %w", principalARN, err)
+			}
+
+			for _, result := range output.EvaluationResults {
+				i := index[aws.ToString(result.EvalActionName)+" "+resource]
+				checks[i].Allowed = result.EvalDecision == iamTypes.PolicyEvaluationDecisionTypeAllowed
+				checks[i].Details = "policy simulation"
+				if !checks[i].Allowed {
+					checks[i].Details = string(result.EvalDecision)
+				}
+			}
+		}
+	}
+
+	return checks, nil
+}
+
+// principalARN returns the IAM user or role to simulate for the caller, the
+// session of an assumed role is simulated with the policies of its role
+func (c *AWSClient) principalARN(callerARN string) (string, error) {
+	parts := strings.SplitN(callerARN, ":", 6)
+	if len(parts) < 6 {
+		return "", fmt.Errorf("unexpected caller ARN %s", callerARN)
+	}
+	resource := parts[5]
+
+	switch {
+	case resource == "root":
+		return "", errors.New("the root user has every permission and cannot be simulated, use an IAM user or role")
+	case strings.HasPrefix(resource, "user/"), strings.HasPrefix(resource, "role/"):
+		return callerARN, nil
+	case strings.HasPrefix(resource, "assumed-role/"):
+		roleSessionName := strings.Split(strings.TrimPrefix(resource, "assumed-role/"), "/")
+
+		// the ARN of the role has its path, the one of the session has not
+		role, err := c.iam.GetRole(context.TODO(), &iam.GetRoleInput{RoleName: aws.String(roleSessionName[0])})
+		if err != nil {
+			return "", fmt.Errorf("cannot read the role %s of the session: %w", roleSessionName[0], err)
+		}
+
+		return aws.ToString(role.Role.Arn), nil
+	}
+
+	return "", fmt.Errorf("the caller %s cannot be simulated", callerARN)
+}
+
+// dryRunChecks launches the instances of the config with DryRun, each type
+// with its own image and market, then terminates an instance that does not
+// exist with DryRun
+func (c *AWSClient) dryRunChecks() []cloud.PermissionCheck {
+	checks := []cloud.PermissionCheck{}
+
+	for _, instance := range []plannedInstance{
+		{role: "llm", instanceType: c.config.AWSConfig.GPUInstanceType, ami: c.config.AWSConfig.GPU_AMI, maxPrice: c.config.AWSConfig.GPUMaxPrice},
+		{role: "bench", instanceType: c.config.AWSConfig.CPUInstanceType, ami: c.config.AWSConfig.CPU_AMI, maxPrice: c.config.AWSConfig.CPUMaxPrice},
+	} {
+		check := cloud.PermissionCheck{
+			Action:   "ec2:RunInstances",
+			Resource: fmt.Sprintf("%s %s", instance.instanceType, instance.ami),
+			Steps:    []string{stepCreate},
+			Allowed:  true,
+			Details:  "dry run",
+		}
+
+		if err := c.dryRunInstance(instance); err != nil {
+			check.Allowed = false
+			check.Details = err.Error()
+		}
+
+		checks = append(checks, check)
+	}
+
+	check := cloud.PermissionCheck{
+		Action:   "ec2:TerminateInstances",
+		Resource: "*",
+		Steps:    []string{stepDestroy},
+		Allowed:  true,
+		Details:  "dry run",
+	}
+
+	// this instance id does not exist
+	if err := c.deleteInstance("i-123456", true); err != nil && !isDryRunError(err) {
+		check.Allowed = false
+		check.Details = err.Error()
+	}
+
+	return append(checks, check)
 }
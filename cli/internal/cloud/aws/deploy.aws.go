@@ -24,7 +24,12 @@ func (c *AWSClient) Deploy() error {
 			logger.Fatal().Msg("Instance has no public IP address")
 		}
 
-		err := c.cli.Deploy(*instance.PublicIpAddress, c.config.InferenceEngine)
+		if err := c.cli.PushConfig(*instance.PublicIpAddress, c.config); err != nil {
+			logger.Error().Err(err).Msg("Cannot push the config to the instance")
+			continue
+		}
+
+		err := c.cli.Deploy(*instance.PublicIpAddress, c.config.InferenceEngine, c.config.BenchmarkConfig.Token)
 		logger.Info().Str("ip", *instance.PublicIpAddress).Msg("Deployment started")
 
 		if err != nil {
@@ -1,11 +1,31 @@
 package aws
 
+import "errors"
+
 func (c *AWSClient) Destroy() error {
 	err := c.DeleteInstance()
-	if err != nil {
+	if errors.Is(err, ErrNoInstance) {
+		logger.Info().Msg("No instance to terminate")
+	} else if err != nil {
 		logger.Error().Err(err).Msg("Error while deleting the instance")
 		return err
 	}
 
+	// the groups outlive their instances when a creation failed
+	if err := c.deleteSecurityGroups(); err != nil {
+		logger.Error().Err(err).Msg("Error while deleting the security group")
+		return err
+	}
+
+	if err := c.deletePlacementGroups(); err != nil {
+		logger.Error().Err(err).Msg("Error while deleting the placement group")
+		return err
+	}
+
+	if err := c.deleteKeyPair(); err != nil {
+		logger.Error().Err(err).Msg("Error while deleting the key pair")
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,140 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/heka-ai/benchmark-cli/internal/constants"
+	"github.com/heka-ai/benchmark-cli/internal/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// pinBenchmarkHostKeys pins the host keys of the llm and bench instances in
+// the known_hosts file of the benchmark, bench ssh and bench cp refuse any
+// other key
+func (c *AWSClient) pinBenchmarkHostKeys(ctx context.Context) error {
+	dir, err := c.sshDir()
+	if err != nil {
+		return err
+	}
+
+	instances, err := c.benchmarkInstances("pending", "running")
+	if err != nil {
+		return err
+	}
+
+	instanceIDs := []string{}
+	for _, instance := range instances {
+		instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+	}
+	if len(instanceIDs) == 0 {
+		return errors.New("no instance found")
+	}
+
+	err = ec2.NewInstanceRunningWaiter(c.svc).Wait(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: instanceIDs,
+	}, 10*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	// the public addresses are given once the instances run
+	instances, err = c.GetBenchmarkInstances()
+	if err != nil {
+		return err
+	}
+
+	for _, labelValue := range []string{constants.LLMInstanceLabelValue, constants.BenchInstanceLabelValue} {
+		instance := findInstance(instances, labelValue)
+		if instance == nil || instance.PublicIpAddress == nil {
+			return fmt.Errorf("the %s instance has no public IP address", labelValue)
+		}
+
+		err := c.pinHostKeys(ctx, aws.ToString(instance.InstanceId), aws.ToString(instance.PublicIpAddress), filepath.Join(dir, knownHostsFile), 10*time.Minute)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureHostKeys pins the host keys of the running instance when none are
+// pinned for its address
+func (c *AWSClient) ensureHostKeys(ctx context.Context, instance *types.Instance, knownHostsPath string) error {
+	host := aws.ToString(instance.PublicIpAddress)
+
+	pinned, err := ssh.HostKeysPinned(knownHostsPath, host)
+	if err != nil || pinned {
+		return err
+	}
+
+	return c.pinHostKeys(ctx, aws.ToString(instance.InstanceId), host, knownHostsPath, 2*time.Minute)
+}
+
+// pinHostKeys reads the host keys cloud-init prints on the console of the
+// instance at boot and pins them for its address, the console output is
+// retried until the keys are printed
+func (c *AWSClient) pinHostKeys(ctx context.Context, instanceID string, host string, knownHostsPath string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	logger.Info().Str("instanceId", instanceID).Msg("Reading the host keys of the instance from its console output")
+
+	for {
+		output, err := c.consoleOutput(ctx, instanceID)
+		if err == nil {
+			keys, parseErr := ssh.ConsoleHostKeys(output)
+			if parseErr == nil {
+				for _, key := range keys {
+					logger.Debug().Str("instanceId", instanceID).Str("fingerprint", gossh.FingerprintSHA256(key)).Msg("Pinning the host key")
+				}
+
+				return ssh.PinHostKeys(knownHostsPath, host, keys)
+			}
+			err = parseErr
+		}
+
+		logger.Debug().Err(err).Str("instanceId", instanceID).Msg("The host keys are not printed yet")
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cannot read the host keys of the instance %s from its console output: %w", instanceID, err)
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+// consoleOutput returns the console output of the instance, the latest one
+// when the instance type supports it
+func (c *AWSClient) consoleOutput(ctx context.Context, instanceID string) (string, error) {
+	output, err := c.svc.GetConsoleOutput(ctx, &ec2.GetConsoleOutputInput{
+		InstanceId: aws.String(instanceID),
+		Latest:     aws.Bool(true),
+	})
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "UnsupportedOperation" {
+		output, err = c.svc.GetConsoleOutput(ctx, &ec2.GetConsoleOutputInput{
+			InstanceId: aws.String(instanceID),
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(aws.ToString(output.Output))
+	if err != nil {
+		return "", err
+	}
+
+	return string(decoded), nil
+}
@@ -0,0 +1,367 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/heka-ai/benchmark-cli/internal/cloud"
+	"github.com/heka-ai/benchmark-cli/internal/constants"
+	"github.com/heka-ai/benchmark-cli/internal/imagebuilder"
+)
+
+// the images are named benchmark-ami-<role>-<date>, the copies in the other
+// regions keep the name of their source
+const imageNamePrefix = "benchmark-ami-"
+
+// the official images of the README, their owner is the account publishing
+// the official images in every region
+const officialImageRegion = "us-east-1"
+
+var officialImageIDs = []string{"ami-09cba9350fc25f2a5", "ami-0cd317320985b1898"}
+
+// imageOwners returns the accounts whose images are looked up, by default
+// the account publishing the official images and this account
+func (c *AWSClient) imageOwners() []string {
+	if len(c.config.AWSConfig.ImageOwners) > 0 {
+		return c.config.AWSConfig.ImageOwners
+	}
+
+	owner, err := c.officialImageOwner()
+	if err != nil {
+		logger.Warn().Err(err).Msg("Cannot find the account of the official images, only the images of this account are looked up")
+		return []string{"self"}
+	}
+
+	return []string{owner, "self"}
+}
+
+// officialImageOwner returns the account owning the official images
+func (c *AWSClient) officialImageOwner() (string, error) {
+	svc := c.svc
+	if c.config.AWSConfig.Region != officialImageRegion {
+		svc = ec2.NewFromConfig(c.conf, func(o *ec2.Options) {
+			o.Region = officialImageRegion
+		})
+	}
+
+	// a filter rather than the ids, an id that is gone would fail the call
+	output, err := svc.DescribeImages(context.TODO(), &ec2.DescribeImagesInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("image-id"),
+				Values: officialImageIDs,
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot read the official images of %s: %w", officialImageRegion, err)
+	}
+
+	for _, image := range output.Images {
+		if owner := aws.ToString(image.OwnerId); owner != "" {
+			return owner, nil
+		}
+	}
+
+	return "", fmt.Errorf("the official images %s are not available in %s", strings.Join(officialImageIDs, ", "), officialImageRegion)
+}
+
+// latestImage returns the newest available image of the role owned by the
+// owners. The names are matched rather than the tags, the tags of an image
+// are only visible to its owner.
+func latestImage(ctx context.Context, svc *ec2.Client, owners []string, role string) (*types.Image, error) {
+	output, err := svc.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		Owners: owners,
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("name"),
+				Values: []string{fmt.Sprintf("%s%s-*", imageNamePrefix, role)},
+			},
+			{
+				Name:   aws.String("state"),
+				Values: []string{string(types.ImageStateAvailable)},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the %s images: %w", role, err)
+	}
+
+	images := []types.Image{}
+	for _, image := range output.Images {
+		// a copy that is not shared yet
+		if tagValue(image.Tags, constants.ImageBuildTag) == constants.ImageBuildPendingValue {
+			continue
+		}
+		images = append(images, image)
+	}
+
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no %s image of %s", role, strings.Join(owners, ", "))
+	}
+
+	// the creation dates are RFC 3339 in UTC, they sort as strings
+	sort.Slice(images, func(i, j int) bool {
+		return aws.ToString(images[i].CreationDate) > aws.ToString(images[j].CreationDate)
+	})
+
+	return &images[0], nil
+}
+
+// resolveImages sets the AMIs missing from the config to the latest images
+// of the region
+func (c *AWSClient) resolveImages() error {
+	var owners []string
+	for _, image := range []struct {
+		role string
+		key  string
+		ami  *string
+	}{
+		{imagebuilder.RoleLLM, "gpu_ami", &c.config.AWSConfig.GPU_AMI},
+		{imagebuilder.RoleBench, "cpu_ami", &c.config.AWSConfig.CPU_AMI},
+	} {
+		if *image.ami != "" {
+			continue
+		}
+
+		if owners == nil {
+			owners = c.imageOwners()
+		}
+
+		latest, err := latestImage(context.TODO(), c.svc, owners, image.role)
+		if err != nil {
+			return fmt.Errorf("aws.%s is not set and cannot be resolved in %s, set it or publish the images with bench images publish: %w", image.key, c.config.AWSConfig.Region, err)
+		}
+
+		*image.ami = aws.ToString(latest.ImageId)
+
+		logger.Info().Str(image.key, *image.ami).Str("name", aws.ToString(latest.Name)).Msg("Using the latest image")
+	}
+
+	return nil
+}
+
+// PublishImages copies the images to the regions then shares them and the
+// source images. A region that already has an image with the same name keeps
+// it, publishing again only shares the images.
+func (c *AWSClient) PublishImages(ctx context.Context, options cloud.PublishOptions) ([]cloud.PublishedImage, error) {
+	if !c.wasInit {
+		return nil, errors.New("client not initialized")
+	}
+
+	sources, err := c.publishSources(ctx, options.ImageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	regions := []string{c.config.AWSConfig.Region}
+	for _, region := range options.Regions {
+		if !slices.Contains(regions, region) {
+			regions = append(regions, region)
+		}
+	}
+
+	published := []cloud.PublishedImage{}
+	clients := map[string]*ec2.Client{}
+
+	// the copies run at the same time on AWS, they are waited for after
+	for _, region := range regions {
+		svc := c.svc
+		if region != c.config.AWSConfig.Region {
+			svc = ec2.NewFromConfig(c.conf, func(o *ec2.Options) {
+				o.Region = region
+			})
+		}
+		clients[region] = svc
+
+		for _, source := range sources {
+			image := cloud.PublishedImage{
+				Role:    imageRole(source),
+				Region:  region,
+				ImageID: aws.ToString(source.ImageId),
+				Name:    aws.ToString(source.Name),
+			}
+
+			if region != c.config.AWSConfig.Region {
+				image.ImageID, image.Copied, image.Err = c.copyImage(ctx, svc, source)
+			}
+
+			published = append(published, image)
+		}
+	}
+
+	for i := range published {
+		image := &published[i]
+		if image.Err != nil {
+			continue
+		}
+
+		svc := clients[image.Region]
+
+		if image.Copied {
+			logger.Info().Str("region", image.Region).Str("ami", image.ImageID).Msg("Waiting for the copy to be available")
+
+			image.Err = ec2.NewImageAvailableWaiter(svc).Wait(ctx, &ec2.DescribeImagesInput{
+				ImageIds: []string{image.ImageID},
+			}, 60*time.Minute)
+			if image.Err != nil {
+				continue
+			}
+
+			_, image.Err = svc.DeleteTags(ctx, &ec2.DeleteTagsInput{
+				Resources: []string{image.ImageID},
+				Tags:      []types.Tag{{Key: aws.String(constants.ImageBuildTag)}},
+			})
+			if image.Err != nil {
+				continue
+			}
+		}
+
+		image.Err = shareImage(ctx, svc, image.ImageID, options)
+	}
+
+	return published, nil
+}
+
+// publishSources describes the images to publish, the latest image of each
+// role when no id is given
+func (c *AWSClient) publishSources(ctx context.Context, imageIDs []string) ([]types.Image, error) {
+	if len(imageIDs) == 0 {
+		sources := []types.Image{}
+		for _, role := range []string{imagebuilder.RoleLLM, imagebuilder.RoleBench} {
+			latest, err := latestImage(ctx, c.svc, []string{"self"}, role)
+			if err != nil {
+				return nil, fmt.Errorf("%w, build it with bench create-instance %s", err, role)
+			}
+			sources = append(sources, *latest)
+		}
+
+		return sources, nil
+	}
+
+	output, err := c.svc.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		ImageIds: imageIDs,
+		Owners:   []string{"self"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe the images %s: %w", strings.Join(imageIDs, ", "), err)
+	}
+
+	if len(output.Images) != len(imageIDs) {
+		return nil, fmt.Errorf("only the images of the account in %s can be published", c.config.AWSConfig.Region)
+	}
+
+	for _, image := range output.Images {
+		if image.State != types.ImageStateAvailable {
+			return nil, fmt.Errorf("the image %s is %s, it must be available", aws.ToString(image.ImageId), image.State)
+		}
+	}
+
+	return output.Images, nil
+}
+
+// copyImage copies the source image to the region of svc, the copy is
+// tagged as pending until it is available
+func (c *AWSClient) copyImage(ctx context.Context, svc *ec2.Client, source types.Image) (string, bool, error) {
+	existing, err := svc.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		Owners: []string{"self"},
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("name"),
+				Values: []string{aws.ToString(source.Name)},
+			},
+		},
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("cannot list the images: %w", err)
+	}
+
+	for _, image := range existing.Images {
+		switch image.State {
+		case types.ImageStateAvailable:
+			return aws.ToString(image.ImageId), false, nil
+		case types.ImageStatePending:
+			// copied by a publish that was interrupted
+			return aws.ToString(image.ImageId), true, nil
+		}
+	}
+
+	tags := append(managedTags(),
+		types.Tag{Key: aws.String(constants.ImageBuildTag), Value: aws.String(constants.ImageBuildPendingValue)},
+		types.Tag{Key: aws.String("Name"), Value: source.Name},
+	)
+	for _, key := range []string{constants.GitCommitTag, constants.ImageRoleTag} {
+		if value := tagValue(source.Tags, key); value != "" {
+			tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+		}
+	}
+
+	output, err := svc.CopyImage(ctx, &ec2.CopyImageInput{
+		SourceImageId: source.ImageId,
+		SourceRegion:  aws.String(c.config.AWSConfig.Region),
+		Name:          source.Name,
+		Description:   source.Description,
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeImage,
+				Tags:         tags,
+			},
+			{
+				ResourceType: types.ResourceTypeSnapshot,
+				Tags:         tags,
+			},
+		},
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("cannot copy the image: %w", err)
+	}
+
+	return aws.ToString(output.ImageId), true, nil
+}
+
+// shareImage gives the launch permission of the image to everyone or to the
+// accounts
+func shareImage(ctx context.Context, svc *ec2.Client, imageID string, options cloud.PublishOptions) error {
+	permissions := []types.LaunchPermission{}
+	if options.Public {
+		permissions = append(permissions, types.LaunchPermission{Group: types.PermissionGroupAll})
+	}
+	for _, accountID := range options.AccountIDs {
+		permissions = append(permissions, types.LaunchPermission{UserId: aws.String(accountID)})
+	}
+
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	_, err := svc.ModifyImageAttribute(ctx, &ec2.ModifyImageAttributeInput{
+		ImageId: aws.String(imageID),
+		LaunchPermission: &types.LaunchPermissionModifications{
+			Add: permissions,
+		},
+	})
+	if err != nil && options.Public {
+		return fmt.Errorf("cannot share the image, the block public access for AMIs of the region may be enabled: %w", err)
+	}
+
+	return err
+}
+
+// imageRole reads the role from the tag of the image or from its name
+func imageRole(image types.Image) string {
+	if role := tagValue(image.Tags, constants.ImageRoleTag); role != "" {
+		return role
+	}
+
+	role, _, _ := strings.Cut(strings.TrimPrefix(aws.ToString(image.Name), imageNamePrefix), "-")
+
+	return role
+}
@@ -2,6 +2,8 @@ package aws
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -11,202 +13,381 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/google/uuid"
 	"github.com/heka-ai/benchmark-cli/internal/constants"
+	"github.com/heka-ai/benchmark-cli/internal/imagebuilder"
 	"github.com/heka-ai/benchmark-cli/internal/ssh"
 )
 
 const BASE_AMI_ID = "ami-009604998d7aa26d4"
 const AMI_INSTANCE_TEMPLATE_TAG = "ami-instance-template"
 
-func (c *AWSClient) CreateTemplateInstance(installType string) error {
-	keyPairName := fmt.Sprintf("benchmark-key-pair-%s", uuid.New().String())
-	logger.Debug().Str("keyName", keyPairName).Msg("Generating a key pair")
+// the user of the base image
+const baseImageUser = "ubuntu"
 
-	keyPair, err := c.svc.CreateKeyPair(context.TODO(), &ec2.CreateKeyPairInput{
-		KeyName: aws.String(keyPairName),
-	})
+// the security groups of the builders are named after it, one per build
+const builderSecurityGroupPrefix = "benchmark-builder-"
 
+// BuildImage launches a builder instance from the base image in the network
+// of the config, runs the steps of the role on it over SSH then creates the
+// image. The key pair, the security group, the builder and the image of a
+// failed build are deleted whatever happens, even when the context is
+// cancelled.
+func (c *AWSClient) BuildImage(ctx context.Context, options imagebuilder.Options) (string, error) {
+	steps, err := imagebuilder.Recipe(options)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	defer func() {
-		logger.Debug().Str("keyName", keyPairName).Msg("Deleting the key pair")
-		c.svc.DeleteKeyPair(context.TODO(), &ec2.DeleteKeyPairInput{
-			KeyName: aws.String(keyPairName),
-		})
-		logger.Debug().Str("keyName", keyPairName).Msg("Key pair deleted from AWS")
-	}()
-
-	if keyPair.KeyMaterial == nil {
-		return fmt.Errorf("failed to create key pair")
+	commit, err := imagebuilder.GitCommit(options.ArtifactsDir)
+	if err != nil {
+		logger.Warn().Err(err).Msg("The image is not tagged with its commit")
+		commit = "unknown"
 	}
 
-	// write the key to a temp file
-	keyFile, err := os.CreateTemp("/tmp", "tmp-key-ssh-benchmark-*.pem")
-
-	if err != nil {
-		return err
+	instanceType := c.config.AWSConfig.CPUInstanceType
+	if options.Role == imagebuilder.RoleLLM {
+		instanceType = c.config.AWSConfig.GPUInstanceType
 	}
 
+	// the cleanups run in the reverse order, the builder is terminated
+	// before its key pair is deleted
+	cleanups := []func(){}
 	defer func() {
-		logger.Debug().Str("keyName", keyPairName).Msg("Deleting the key pair file")
-		os.Remove(keyFile.Name())
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
 	}()
 
-	// write the key to the file
-	_, err = keyFile.WriteString(*keyPair.KeyMaterial)
+	keyPairName, keyPath, err := c.createBuilderKeyPair()
+	if err != nil {
+		return "", err
+	}
+	cleanups = append(cleanups, func() {
+		logger.Debug().Str("keyName", keyPairName).Msg("Deleting the key pair")
+		os.Remove(keyPath)
+		_, err := c.svc.DeleteKeyPair(context.Background(), &ec2.DeleteKeyPairInput{
+			KeyName: aws.String(keyPairName),
+		})
+		if err != nil {
+			logger.Error().Err(err).Str("keyName", keyPairName).Msg("Cannot delete the key pair, bench reap deletes it")
+		}
+	})
 
+	subnetID, securityGroupID, deleteSecurityGroup, err := c.builderNetwork()
 	if err != nil {
-		return err
+		return "", err
 	}
+	cleanups = append(cleanups, deleteSecurityGroup)
 
-	logger.Debug().Str("keyName", keyPairName).Msg("Key pair generated")
-	logger.Debug().Str("instanceType", c.config.AWSConfig.GPUInstanceType).Msg("Creating the template instance")
+	logger.Info().Str("instanceType", instanceType).Str("role", options.Role).Msg("Creating the builder instance")
+
+	// the builder is reached over SSH from this machine, it needs a public IP
+	// whatever the setting of the subnet
+	networkInterface := types.InstanceNetworkInterfaceSpecification{
+		DeviceIndex:              aws.Int32(0),
+		AssociatePublicIpAddress: aws.Bool(true),
+		Groups:                   []string{securityGroupID},
+	}
+	if subnetID != "" {
+		networkInterface.SubnetId = aws.String(subnetID)
+	}
 
-	instance, err := c.svc.RunInstances(context.TODO(), &ec2.RunInstancesInput{
+	instance, err := c.svc.RunInstances(ctx, &ec2.RunInstancesInput{
 		ImageId:      aws.String(BASE_AMI_ID),
-		InstanceType: types.InstanceType(c.config.AWSConfig.GPUInstanceType),
+		InstanceType: types.InstanceType(instanceType),
 		MinCount:     aws.Int32(1),
 		MaxCount:     aws.Int32(1),
 		KeyName:      aws.String(keyPairName),
+		NetworkInterfaces: []types.InstanceNetworkInterfaceSpecification{
+			networkInterface,
+		},
+		// the builder is terminated by the watchdog when the CLI is gone
+		UserData:                          aws.String(base64.StdEncoding.EncodeToString([]byte("#!/bin/bash\n" + c.watchdogScript()))),
+		InstanceInitiatedShutdownBehavior: types.ShutdownBehaviorTerminate,
 		TagSpecifications: []types.TagSpecification{
 			{
 				ResourceType: types.ResourceTypeInstance,
-				Tags:         []types.Tag{{Key: aws.String(constants.BenchInstanceLabelKey), Value: aws.String(AMI_INSTANCE_TEMPLATE_TAG)}},
+				Tags:         append(managedTags(), types.Tag{Key: aws.String(constants.BenchInstanceLabelKey), Value: aws.String(AMI_INSTANCE_TEMPLATE_TAG)}),
 			},
 		},
 		BlockDeviceMappings: []types.BlockDeviceMapping{
 			{
 				DeviceName: aws.String("/dev/sda1"),
 				Ebs: &types.EbsBlockDevice{
-					VolumeSize: aws.Int32(200),
+					VolumeSize: aws.Int32(rootVolumeSize),
 				},
 			},
 		},
 	})
-
-	defer func() {
-		logger.Debug().Str("instanceId", *instance.Instances[0].InstanceId).Msg("Terminating the instance")
-		c.svc.TerminateInstances(context.TODO(), &ec2.TerminateInstancesInput{
-			InstanceIds: []string{*instance.Instances[0].InstanceId},
-		})
-		logger.Debug().Str("instanceId", *instance.Instances[0].InstanceId).Msg("Instance terminated")
-	}()
-
 	if err != nil {
-		return err
+		return "", c.capacityError(fmt.Errorf("cannot create the builder instance: %w", err), instanceType)
 	}
 
-	logger.Debug().Str("keyMaterial", *keyPair.KeyMaterial).Msg("Creating the SSH client")
-
-	waiter := ec2.NewInstanceStatusOkWaiter(c.svc)
+	instanceID := aws.ToString(instance.Instances[0].InstanceId)
+	cleanups = append(cleanups, func() {
+		logger.Info().Str("instanceId", instanceID).Msg("Terminating the builder instance")
+		if err := c.deleteInstance(instanceID, false); err != nil {
+			logger.Error().Err(err).Str("instanceId", instanceID).Msg("Cannot terminate the builder instance, bench reap terminates it")
+		}
+	})
 
-	logger.Info().Msg("Waiting for the instance to be running")
-	err = waiter.Wait(context.TODO(), &ec2.DescribeInstanceStatusInput{
-		InstanceIds: []string{*instance.Instances[0].InstanceId},
+	logger.Info().Str("instanceId", instanceID).Msg("Waiting for the builder instance to be running")
+	err = ec2.NewInstanceStatusOkWaiter(c.svc).Wait(ctx, &ec2.DescribeInstanceStatusInput{
+		InstanceIds: []string{instanceID},
 	}, 10*time.Minute)
-
 	if err != nil {
-		return err
+		return "", fmt.Errorf("the builder instance %s is not running: %w", instanceID, err)
 	}
 
-	logger.Info().Msg("Instance is running")
-
-	// describe the instance to get the public ip address
-	describeInstance, err := c.svc.DescribeInstances(context.TODO(), &ec2.DescribeInstancesInput{
-		InstanceIds: []string{*instance.Instances[0].InstanceId},
+	describeInstance, err := c.svc.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
 	})
-
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	logger.Debug().Str("publicIp", *describeInstance.Reservations[0].Instances[0].PublicIpAddress).Str("keyFile", keyFile.Name()).Msg("Creating the SSH client")
+	publicIP := aws.ToString(describeInstance.Reservations[0].Instances[0].PublicIpAddress)
+	if publicIP == "" {
+		return "", fmt.Errorf("the builder instance %s has no public IP, the builder connects to it over SSH", instanceID)
+	}
 
-	sshClient := ssh.NewSSHClient(keyFile.Name(), *describeInstance.Reservations[0].Instances[0].PublicIpAddress, "ubuntu")
+	logger.Debug().Str("publicIp", publicIP).Str("keyFile", keyPath).Msg("Creating the SSH client")
 
-	err = c.runSetup(sshClient, installType)
+	// the builder is only used once, its host keys are pinned in a temp file
+	knownHostsPath := keyPath + ".known_hosts"
+	defer os.Remove(knownHostsPath)
 
-	if err != nil {
-		return err
+	if err := c.pinHostKeys(ctx, instanceID, publicIP, knownHostsPath, 5*time.Minute); err != nil {
+		return "", err
 	}
 
-	logger.Info().Msg("Setup complete, creating the AMI")
+	sshClient := ssh.NewSSHClient(keyPath, publicIP, baseImageUser, knownHostsPath)
+	defer sshClient.Close()
 
-	amiName := fmt.Sprintf("benchmark-ami-%s-%s", installType, time.Now().Format("2006-01-02-15-04-05"))
+	if err := sshClient.WaitReady(ctx, 5*time.Minute); err != nil {
+		return "", err
+	}
 
-	ami, err := c.svc.CreateImage(context.TODO(), &ec2.CreateImageInput{
-		InstanceId:  instance.Instances[0].InstanceId,
-		Name:        aws.String(amiName),
-		Description: aws.String(fmt.Sprintf("AMI for the benchmark-cli %s instance", installType)),
-	})
+	if err := imagebuilder.NewBuilder(sshClient, steps).Build(ctx); err != nil {
+		return "", err
+	}
 
+	logger.Info().Msg("Setup complete, creating the AMI")
+
+	imageID, err := c.createImage(ctx, instanceID, options.Role, commit)
+	if imageID != "" && err != nil {
+		cleanups = append(cleanups, func() {
+			logger.Info().Str("amiId", imageID).Msg("Deleting the image of the failed build")
+			if err := c.deleteImageByID(imageID); err != nil {
+				logger.Error().Err(err).Str("amiId", imageID).Msg("Cannot delete the image, bench reap deletes it")
+			}
+		})
+	}
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	imageAvailableWaiter := ec2.NewImageAvailableWaiter(c.svc)
+	logger.Info().Str("amiId", imageID).Str("commit", commit).Msg("AMI created")
 
-	err = imageAvailableWaiter.Wait(context.TODO(), &ec2.DescribeImagesInput{
-		ImageIds: []string{*ami.ImageId},
-	}, 30*time.Minute)
+	return imageID, nil
+}
 
+// builderNetwork returns the subnet and the security group of the builder,
+// the subnet of aws.network and its security group when set. Otherwise a
+// security group opening SSH to the operator is created for the build, the
+// returned cleanup deletes it once the builder is terminated.
+func (c *AWSClient) builderNetwork() (string, string, func(), error) {
+	networkConfig := c.networkConfig()
+
+	vpcID, subnetID, err := c.resolveSubnet(networkConfig)
 	if err != nil {
-		return err
+		return "", "", nil, err
 	}
 
-	logger.Info().Str("amiId", *ami.ImageId).Msg("AMI created")
+	if networkConfig.SecurityGroupID != "" {
+		return subnetID, networkConfig.SecurityGroupID, func() {}, nil
+	}
 
-	return nil
-}
+	allowedCIDR := networkConfig.AllowedCIDR
+	if allowedCIDR == "" {
+		allowedCIDR, err = operatorCIDR()
+		if err != nil {
+			return "", "", nil, fmt.Errorf("cannot find the public IP of this machine, set aws.network.allowed_cidr: %w", err)
+		}
+	}
 
-func (c *AWSClient) runSetup(sshClient *ssh.SSHClient, installType string) error {
-	// clone the benchmark-cli repo
-	logger.Info().Msg("Installing the benchmark-cli repo")
-	err := sshClient.Run("git clone https://github.com/heka-ai/sia-benchmark.git /home/ubuntu/benchmark-cli")
+	name := builderSecurityGroupPrefix + uuid.New().String()
+	input := &ec2.CreateSecurityGroupInput{
+		GroupName:   aws.String(name),
+		Description: aws.String("Builder of the benchmark images"),
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeSecurityGroup,
+				Tags:         append(managedTags(), types.Tag{Key: aws.String("Name"), Value: aws.String(name)}),
+			},
+		},
+	}
+	if vpcID != "" {
+		input.VpcId = aws.String(vpcID)
+	}
 
+	created, err := c.svc.CreateSecurityGroup(context.TODO(), input)
 	if err != nil {
-		return err
+		return "", "", nil, fmt.Errorf("cannot create the security group of the builder: %w", err)
 	}
 
-	err = sshClient.Run("mv /home/ubuntu/benchmark-cli/instance-builder/aws/ec2 /home/ubuntu/")
+	securityGroupID := aws.ToString(created.GroupId)
+	deleteSecurityGroup := func() {
+		err := c.waitForInstancesTermination("the security group "+securityGroupID, types.Filter{
+			Name:   aws.String("instance.group-id"),
+			Values: []string{securityGroupID},
+		})
+		if err == nil {
+			logger.Debug().Str("security-group", securityGroupID).Msg("Deleting the security group of the builder")
+			_, err = c.svc.DeleteSecurityGroup(context.Background(), &ec2.DeleteSecurityGroupInput{
+				GroupId: aws.String(securityGroupID),
+			})
+		}
+		if err != nil {
+			logger.Error().Err(err).Str("security-group", securityGroupID).Msg("Cannot delete the security group of the builder, bench reap deletes it")
+		}
+	}
 
+	_, err = c.svc.AuthorizeSecurityGroupIngress(context.TODO(), &ec2.AuthorizeSecurityGroupIngressInput{
+		GroupId: aws.String(securityGroupID),
+		IpPermissions: []types.IpPermission{
+			{
+				IpProtocol: aws.String("tcp"),
+				FromPort:   aws.Int32(sshPort),
+				ToPort:     aws.Int32(sshPort),
+				IpRanges: []types.IpRange{
+					{
+						CidrIp:      aws.String(allowedCIDR),
+						Description: aws.String("SSH, from the operator"),
+					},
+				},
+			},
+		},
+	})
 	if err != nil {
-		return err
+		deleteSecurityGroup()
+		return "", "", nil, fmt.Errorf("cannot open SSH in the security group of the builder: %w", err)
 	}
 
-	sshClient.Run("ls /home/ubuntu")
+	logger.Info().Str("security-group", securityGroupID).Str("allowed-cidr", allowedCIDR).Msg("Security group of the builder created")
 
-	logger.Info().Msg("Installing the control API")
-	err = sshClient.Run("sudo bash /home/ubuntu/ec2/api/install.sh")
+	return subnetID, securityGroupID, deleteSecurityGroup, nil
+}
 
+// createBuilderKeyPair creates the key pair the builder connects with and
+// writes its private key to a temp file only readable by the user
+func (c *AWSClient) createBuilderKeyPair() (string, string, error) {
+	keyPairName := fmt.Sprintf("%s%s", keyPairPrefix, uuid.New().String())
+	logger.Debug().Str("keyName", keyPairName).Msg("Generating a key pair")
+
+	keyPair, err := c.svc.CreateKeyPair(context.TODO(), &ec2.CreateKeyPairInput{
+		KeyName: aws.String(keyPairName),
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeKeyPair,
+				Tags:         managedTags(),
+			},
+		},
+	})
 	if err != nil {
-		return err
+		return "", "", err
+	}
+
+	keyFile, err := os.CreateTemp("", "tmp-key-ssh-benchmark-*.pem")
+	if err == nil {
+		_, err = keyFile.WriteString(aws.ToString(keyPair.KeyMaterial))
+		keyFile.Close()
 	}
 
-	if installType == "llm" {
-		logger.Info().Msg("Installing the LLM dependencies")
-		err = sshClient.Run("sudo bash /home/ubuntu/ec2/gpu/install.sh")
-	} else {
-		logger.Info().Msg("Installing the CPU dependencies")
-		err = sshClient.Run("bash /home/ubuntu/ec2/cpu/install.sh")
+	if err != nil || keyPair.KeyMaterial == nil {
+		c.svc.DeleteKeyPair(context.TODO(), &ec2.DeleteKeyPairInput{KeyName: aws.String(keyPairName)})
+		if keyFile != nil {
+			os.Remove(keyFile.Name())
+		}
+
+		return "", "", errors.Join(errors.New("failed to create key pair"), err)
 	}
 
-	return err
+	logger.Debug().Str("keyName", keyPairName).Msg("Key pair generated")
+
+	return keyPairName, keyFile.Name(), nil
 }
 
-func (c *AWSClient) CreateBenchInstance() error {
-	logger.Info().Msg("Starting the creation of the CPU instance image")
+// createImage creates the image of the builder and waits for it to be
+// available. The image id is returned with the error when the image was
+// created but is not available.
+func (c *AWSClient) createImage(ctx context.Context, instanceID string, role string, commit string) (string, error) {
+	amiName := fmt.Sprintf("%s%s-%s", imageNamePrefix, role, time.Now().Format("2006-01-02-15-04-05"))
+
+	tags := append(managedTags(),
+		types.Tag{Key: aws.String(constants.GitCommitTag), Value: aws.String(commit)},
+		types.Tag{Key: aws.String(constants.ImageRoleTag), Value: aws.String(role)},
+		types.Tag{Key: aws.String("Name"), Value: aws.String(amiName)},
+	)
+
+	// the image is tagged as pending until it is available, bench reap
+	// deletes the images of the failed builds
+	buildTags := append(tags, types.Tag{
+		Key:   aws.String(constants.ImageBuildTag),
+		Value: aws.String(constants.ImageBuildPendingValue),
+	})
 
-	err := c.CreateTemplateInstance("cpu")
+	ami, err := c.svc.CreateImage(ctx, &ec2.CreateImageInput{
+		InstanceId:  aws.String(instanceID),
+		Name:        aws.String(amiName),
+		Description: aws.String(fmt.Sprintf("AMI for the benchmark-cli %s instance, commit %s", role, commit)),
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeImage,
+				Tags:         buildTags,
+			},
+			{
+				ResourceType: types.ResourceTypeSnapshot,
+				Tags:         buildTags,
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot create the image: %w", err)
+	}
 
-	return err
+	imageID := aws.ToString(ami.ImageId)
+	logger.Info().Str("amiId", imageID).Str("name", amiName).Msg("Waiting for the image to be available")
+
+	err = ec2.NewImageAvailableWaiter(c.svc).Wait(ctx, &ec2.DescribeImagesInput{
+		ImageIds: []string{imageID},
+	}, 30*time.Minute)
+	if err != nil {
+		return imageID, fmt.Errorf("the image %s is not available: %w", imageID, err)
+	}
+
+	_, err = c.svc.DeleteTags(ctx, &ec2.DeleteTagsInput{
+		Resources: []string{imageID},
+		Tags:      []types.Tag{{Key: aws.String(constants.ImageBuildTag)}},
+	})
+	if err != nil {
+		return imageID, fmt.Errorf("cannot mark the image %s as built: %w", imageID, err)
+	}
+
+	return imageID, nil
 }
 
-func (c *AWSClient) CreateLLMInstance() error {
-	logger.Info().Msg("Starting the creation of the LLM instance image")
+// deleteImageByID deletes the image and its snapshots, see deleteImage
+func (c *AWSClient) deleteImageByID(imageID string) error {
+	output, err := c.svc.DescribeImages(context.Background(), &ec2.DescribeImagesInput{
+		ImageIds: []string{imageID},
+	})
+	if err != nil {
+		return err
+	}
 
-	err := c.CreateTemplateInstance("llm")
+	if len(output.Images) == 0 {
+		return nil
+	}
 
-	return err
+	return c.deleteImage(output.Images[0])
 }
@@ -1,41 +1,83 @@
 package aws
 
 import (
-	"errors"
+	"fmt"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/heka-ai/benchmark-cli/internal/cloud"
 	"github.com/heka-ai/benchmark-cli/internal/constants"
 )
 
 func (c *AWSClient) GetLLMInstanceIP() (string, error) {
-	instances, err := c.GetBenchmarkInstances()
+	addresses, err := c.GetLLMInstanceAddresses()
 	if err != nil {
 		return "", err
 	}
 
-	for _, instance := range instances {
-		for _, tag := range instance.Tags {
-			if *tag.Key == constants.BenchInstanceLabelKey && *tag.Value == constants.LLMInstanceLabelValue {
-				return *instance.PublicIpAddress, nil
-			}
-		}
+	return addresses.Public, nil
+}
+
+func (c *AWSClient) GetBenchInstanceIP() (string, error) {
+	addresses, err := c.GetBenchInstanceAddresses()
+	if err != nil {
+		return "", err
 	}
 
-	return "", errors.New("no LLM instance found")
+	return addresses.Public, nil
 }
 
-func (c *AWSClient) GetBenchInstanceIP() (string, error) {
+func (c *AWSClient) GetLLMInstanceAddresses() (*cloud.Addresses, error) {
+	return c.instanceAddresses(constants.LLMInstanceLabelValue, "LLM")
+}
+
+func (c *AWSClient) GetBenchInstanceAddresses() (*cloud.Addresses, error) {
+	return c.instanceAddresses(constants.BenchInstanceLabelValue, "CPU")
+}
+
+// instanceAddresses returns the addresses of the running instance of the
+// benchmark having the machine type label
+func (c *AWSClient) instanceAddresses(labelValue string, name string) (*cloud.Addresses, error) {
+	instance, err := c.runningInstance(labelValue, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cloud.Addresses{
+		Public:  aws.ToString(instance.PublicIpAddress),
+		Private: aws.ToString(instance.PrivateIpAddress),
+	}, nil
+}
+
+// runningInstance returns the running instance of the benchmark having the
+// machine type label, with its public address
+func (c *AWSClient) runningInstance(labelValue string, name string) (*types.Instance, error) {
 	instances, err := c.GetBenchmarkInstances()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	for _, instance := range instances {
+	instance := findInstance(instances, labelValue)
+	if instance == nil {
+		return nil, fmt.Errorf("no %s instance found", name)
+	}
+
+	if instance.PublicIpAddress == nil {
+		return nil, fmt.Errorf("the %s instance has no public IP address yet", name)
+	}
+
+	return instance, nil
+}
+
+// findInstance returns the instance having the machine type label
+func findInstance(instances []types.Instance, labelValue string) *types.Instance {
+	for i, instance := range instances {
 		for _, tag := range instance.Tags {
-			if *tag.Key == constants.BenchInstanceLabelKey && *tag.Value == constants.BenchInstanceLabelValue {
-				return *instance.PublicIpAddress, nil
+			if aws.ToString(tag.Key) == constants.BenchInstanceLabelKey && aws.ToString(tag.Value) == labelValue {
+				return &instances[i]
 			}
 		}
 	}
 
-	return "", errors.New("no CPU instance found")
+	return nil
 }
@@ -0,0 +1,218 @@
+package aws
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/heka-ai/benchmark-cli/internal/cloud"
+	"github.com/heka-ai/benchmark-cli/internal/constants"
+	"github.com/heka-ai/benchmark-cli/internal/imagebuilder"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	privateKeyFile = "id_ed25519"
+	knownHostsFile = "known_hosts"
+)
+
+func (c *AWSClient) keyPairName() string {
+	return keyPairPrefix + c.config.BenchID
+}
+
+// sshDir holds the private key of the benchmark and the host keys of its
+// instances, it is deleted by destroy
+func (c *AWSClient) sshDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, "benchmark-cli", c.config.BenchID), nil
+}
+
+// getOrCreateKeyPair returns the key pair the instances of the benchmark are
+// launched with. The key is generated on this machine and only its public
+// part is sent to AWS, a key pair without its private key is replaced.
+func (c *AWSClient) getOrCreateKeyPair() (string, error) {
+	dir, err := c.sshDir()
+	if err != nil {
+		return "", err
+	}
+
+	keyPath := filepath.Join(dir, privateKeyFile)
+	name := c.keyPairName()
+
+	exists, err := c.keyPairExists(name)
+	if err != nil {
+		return "", err
+	}
+
+	signer, err := readPrivateKey(keyPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("cannot read the private key %s: %w", keyPath, err)
+	}
+
+	if signer != nil && exists {
+		return name, nil
+	}
+
+	if exists {
+		logger.Warn().Str("keyName", name).Str("keyFile", keyPath).Msg("The private key of the key pair is missing, replacing the key pair")
+
+		if _, err := c.svc.DeleteKeyPair(context.TODO(), &ec2.DeleteKeyPairInput{KeyName: aws.String(name)}); err != nil {
+			return "", fmt.Errorf("cannot delete the key pair %s: %w", name, err)
+		}
+	}
+
+	if signer == nil {
+		signer, err = generatePrivateKey(keyPath)
+		if err != nil {
+			return "", fmt.Errorf("cannot generate the private key: %w", err)
+		}
+	}
+
+	_, err = c.svc.ImportKeyPair(context.TODO(), &ec2.ImportKeyPairInput{
+		KeyName:           aws.String(name),
+		PublicKeyMaterial: ssh.MarshalAuthorizedKey(signer.PublicKey()),
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeKeyPair,
+				Tags:         c.defaultTags(),
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot import the key pair %s: %w", name, err)
+	}
+
+	logger.Info().Str("keyName", name).Str("keyFile", keyPath).Msg("Key pair created")
+
+	return name, nil
+}
+
+func (c *AWSClient) keyPairExists(name string) (bool, error) {
+	_, err := c.svc.DescribeKeyPairs(context.TODO(), &ec2.DescribeKeyPairsInput{
+		KeyNames: []string{name},
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidKeyPair.NotFound" {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("cannot find the key pair %s: %w", name, err)
+	}
+
+	return true, nil
+}
+
+func readPrivateKey(keyPath string) (ssh.Signer, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return ssh.ParsePrivateKey(data)
+}
+
+// generatePrivateKey writes a new ed25519 key only readable by the user
+func generatePrivateKey(keyPath string) (ssh.Signer, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := ssh.MarshalPrivateKey(privateKey, "benchmark-cli")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(block), 0600); err != nil {
+		return nil, err
+	}
+
+	return ssh.NewSignerFromKey(privateKey)
+}
+
+// deleteKeyPair deletes the key pair of the benchmark and its local files
+func (c *AWSClient) deleteKeyPair() error {
+	name := c.keyPairName()
+
+	exists, err := c.keyPairExists(name)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		if _, err := c.svc.DeleteKeyPair(context.TODO(), &ec2.DeleteKeyPairInput{KeyName: aws.String(name)}); err != nil {
+			return fmt.Errorf("cannot delete the key pair %s: %w", name, err)
+		}
+
+		logger.Info().Str("keyName", name).Msg("Key pair deleted")
+	}
+
+	dir, err := c.sshDir()
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(dir)
+}
+
+// SSHAccess returns how to connect to the llm or bench instance with the key
+// pair of the benchmark
+func (c *AWSClient) SSHAccess(role string) (*cloud.SSHAccess, error) {
+	var instance *types.Instance
+	var err error
+
+	switch role {
+	case imagebuilder.RoleLLM:
+		instance, err = c.runningInstance(constants.LLMInstanceLabelValue, "LLM")
+	case imagebuilder.RoleBench:
+		instance, err = c.runningInstance(constants.BenchInstanceLabelValue, "CPU")
+	default:
+		return nil, fmt.Errorf("unknown instance %q, must be %s or %s", role, imagebuilder.RoleLLM, imagebuilder.RoleBench)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := c.sshDir()
+	if err != nil {
+		return nil, err
+	}
+
+	keyPath := filepath.Join(dir, privateKeyFile)
+	if _, err := os.Stat(keyPath); err != nil {
+		return nil, fmt.Errorf("cannot find the private key of the benchmark, the instances were created on another machine or before the CLI registered a key pair: %w", err)
+	}
+
+	host := aws.ToString(instance.PublicIpAddress)
+	knownHostsPath := filepath.Join(dir, knownHostsFile)
+
+	// create pins the host keys, unless it could not read them in time
+	if err := c.ensureHostKeys(context.TODO(), instance, knownHostsPath); err != nil {
+		return nil, err
+	}
+
+	return &cloud.SSHAccess{
+		Host:           host,
+		User:           baseImageUser,
+		KeyPath:        keyPath,
+		KnownHostsPath: knownHostsPath,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// MarketSpot launches the instances on the spot market
+const MarketSpot = "spot"
+
+func (c *AWSClient) spotMarket() bool {
+	return c.config.AWSConfig.Market == MarketSpot
+}
+
+// marketOptions requests a spot instance when the market is spot, nil keeps
+// the on-demand market. The instance is terminated on interruption, the
+// benchmark cannot resume on a stopped instance.
+func (c *AWSClient) marketOptions(maxPrice float64) *types.InstanceMarketOptionsRequest {
+	if !c.spotMarket() {
+		return nil
+	}
+
+	spotOptions := &types.SpotMarketOptions{
+		SpotInstanceType:             types.SpotInstanceTypeOneTime,
+		InstanceInterruptionBehavior: types.InstanceInterruptionBehaviorTerminate,
+	}
+
+	// AWS caps the price at the on-demand price without a max price
+	if maxPrice > 0 {
+		spotOptions.MaxPrice = aws.String(strconv.FormatFloat(maxPrice, 'f', -1, 64))
+	}
+
+	return &types.InstanceMarketOptionsRequest{
+		MarketType:  types.MarketTypeSpot,
+		SpotOptions: spotOptions,
+	}
+}
+
+// spotPrice returns the current hourly spot price of the instance type, the
+// highest of the availability zones of the region as the zone of the
+// instance is only known once it is created
+func (c *AWSClient) spotPrice(instanceType string) (float64, error) {
+	output, err := c.svc.DescribeSpotPriceHistory(context.TODO(), &ec2.DescribeSpotPriceHistoryInput{
+		InstanceTypes:       []types.InstanceType{types.InstanceType(instanceType)},
+		ProductDescriptions: []string{"Linux/UNIX"},
+		// only the current price of each zone
+		StartTime: aws.Time(time.Now()),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("cannot get the spot price of %s: %w", instanceType, err)
+	}
+
+	highest := 0.0
+	for _, history := range output.SpotPriceHistory {
+		price, err := strconv.ParseFloat(aws.ToString(history.SpotPrice), 64)
+		if err == nil {
+			highest = max(highest, price)
+		}
+	}
+
+	if highest == 0 {
+		return 0, fmt.Errorf("no spot price for %s in %s", instanceType, c.config.AWSConfig.Region)
+	}
+
+	return highest, nil
+}
@@ -0,0 +1,416 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/heka-ai/benchmark-cli/internal/constants"
+	"github.com/heka-ai/benchmark-cli/pkg/config"
+)
+
+const (
+	// the control API of the instances, reached by the CLI
+	apiPort = 8001
+	// the inference engine, reached by the bench instance
+	enginePort = 8000
+	// bench ssh and bench cp, reached by the CLI
+	sshPort = 22
+
+	// checkIPURL returns the public IP of the caller
+	checkIPURL = "https://checkip.amazonaws.com"
+
+	// the security and placement groups can only be deleted once their
+	// instances are terminated
+	terminateTimeout = 10 * time.Minute
+)
+
+// network is where the instances of the benchmark are created
+type network struct {
+	// empty for the default subnet of the default VPC
+	subnetID        string
+	securityGroupID string
+	// empty when the instances are not in a placement group
+	placementGroup string
+}
+
+func (c *AWSClient) networkConfig() *config.AWSNetworkConfig {
+	if c.config.AWSConfig.Network == nil {
+		return &config.AWSNetworkConfig{}
+	}
+
+	return c.config.AWSConfig.Network
+}
+
+// getNetwork resolves the subnet of the instances, their security group and
+// placement group, the groups of the benchmark are created the first time
+func (c *AWSClient) getNetwork() (*network, error) {
+	if c.network != nil {
+		return c.network, nil
+	}
+
+	networkConfig := c.networkConfig()
+
+	vpcID, subnetID, err := c.resolveSubnet(networkConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	securityGroupID := networkConfig.SecurityGroupID
+	if securityGroupID == "" {
+		securityGroupID, err = c.getOrCreateSecurityGroup(vpcID, networkConfig.AllowedCIDR)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	placementGroup := ""
+	if c.clusterPlacement() {
+		placementGroup, err = c.getOrCreatePlacementGroup()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.network = &network{subnetID: subnetID, securityGroupID: securityGroupID, placementGroup: placementGroup}
+
+	return c.network, nil
+}
+
+// resolveSubnet returns the VPC and the subnet of the instances, both are
+// empty for the default VPC
+func (c *AWSClient) resolveSubnet(networkConfig *config.AWSNetworkConfig) (string, string, error) {
+	if networkConfig.SubnetID != "" {
+		subnets, err := c.svc.DescribeSubnets(context.TODO(), &ec2.DescribeSubnetsInput{
+			SubnetIds: []string{networkConfig.SubnetID},
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("cannot find the subnet %s: %w", networkConfig.SubnetID, err)
+		}
+
+		if len(subnets.Subnets) == 0 {
+			return "", "", fmt.Errorf("cannot find the subnet %s", networkConfig.SubnetID)
+		}
+
+		vpcID := aws.ToString(subnets.Subnets[0].VpcId)
+		if networkConfig.VPCID != "" && networkConfig.VPCID != vpcID {
+			return "", "", fmt.Errorf("the subnet %s is in the VPC %s, not in %s", networkConfig.SubnetID, vpcID, networkConfig.VPCID)
+		}
+
+		return vpcID, networkConfig.SubnetID, nil
+	}
+
+	if networkConfig.VPCID == "" {
+		return "", "", nil
+	}
+
+	subnets, err := c.svc.DescribeSubnets(context.TODO(), &ec2.DescribeSubnetsInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("vpc-id"),
+				Values: []string{networkConfig.VPCID},
+			},
+			{
+				Name:   aws.String("state"),
+				Values: []string{"available"},
+			},
+		},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("cannot list the subnets of the VPC %s: %w", networkConfig.VPCID, err)
+	}
+
+	if len(subnets.Subnets) == 0 {
+		return "", "", fmt.Errorf("the VPC %s has no available subnet", networkConfig.VPCID)
+	}
+
+	// the same subnet is picked on each run
+	sort.Slice(subnets.Subnets, func(i, j int) bool {
+		return aws.ToString(subnets.Subnets[i].SubnetId) < aws.ToString(subnets.Subnets[j].SubnetId)
+	})
+
+	subnetID := aws.ToString(subnets.Subnets[0].SubnetId)
+	logger.Info().Str("vpc", networkConfig.VPCID).Str("subnet", subnetID).Msg("No subnet in the config, using a subnet of the VPC")
+
+	return networkConfig.VPCID, subnetID, nil
+}
+
+func (c *AWSClient) securityGroupName() string {
+	return fmt.Sprintf("benchmark-%s", c.config.BenchID)
+}
+
+// getOrCreateSecurityGroup returns the security group of the benchmark, it
+// opens the API and SSH to the operator and the engine to the other instance
+func (c *AWSClient) getOrCreateSecurityGroup(vpcID string, allowedCIDR string) (string, error) {
+	securityGroups, err := c.benchmarkSecurityGroups()
+	if err != nil {
+		return "", err
+	}
+
+	if len(securityGroups) > 0 {
+		return aws.ToString(securityGroups[0].GroupId), nil
+	}
+
+	if allowedCIDR == "" {
+		allowedCIDR, err = operatorCIDR()
+		if err != nil {
+			return "", fmt.Errorf("cannot find the public IP of this machine, set aws.network.allowed_cidr: %w", err)
+		}
+	}
+
+	input := &ec2.CreateSecurityGroupInput{
+		GroupName:   aws.String(c.securityGroupName()),
+		Description: aws.String(fmt.Sprintf("Instances of the benchmark %s", c.config.BenchID)),
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeSecurityGroup,
+				Tags:         c.defaultTags(),
+			},
+		},
+	}
+	if vpcID != "" {
+		input.VpcId = aws.String(vpcID)
+	}
+
+	created, err := c.svc.CreateSecurityGroup(context.TODO(), input)
+	if err != nil {
+		return "", fmt.Errorf("cannot create the security group: %w", err)
+	}
+
+	securityGroupID := aws.ToString(created.GroupId)
+
+	_, err = c.svc.AuthorizeSecurityGroupIngress(context.TODO(), &ec2.AuthorizeSecurityGroupIngressInput{
+		GroupId: aws.String(securityGroupID),
+		IpPermissions: []types.IpPermission{
+			{
+				IpProtocol: aws.String("tcp"),
+				FromPort:   aws.Int32(apiPort),
+				ToPort:     aws.Int32(apiPort),
+				IpRanges: []types.IpRange{
+					{
+						CidrIp:      aws.String(allowedCIDR),
+						Description: aws.String("Control API, from the operator"),
+					},
+				},
+			},
+			{
+				IpProtocol: aws.String("tcp"),
+				FromPort:   aws.Int32(sshPort),
+				ToPort:     aws.Int32(sshPort),
+				IpRanges: []types.IpRange{
+					{
+						CidrIp:      aws.String(allowedCIDR),
+						Description: aws.String("SSH, from the operator"),
+					},
+				},
+			},
+			{
+				IpProtocol: aws.String("tcp"),
+				FromPort:   aws.Int32(enginePort),
+				ToPort:     aws.Int32(enginePort),
+				UserIdGroupPairs: []types.UserIdGroupPair{
+					{
+						GroupId:     aws.String(securityGroupID),
+						Description: aws.String("Inference engine, from the bench instance"),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		// an empty group would leave the instances unreachable
+		if _, deleteErr := c.svc.DeleteSecurityGroup(context.TODO(), &ec2.DeleteSecurityGroupInput{GroupId: aws.String(securityGroupID)}); deleteErr != nil {
+			logger.Error().Err(deleteErr).Str("security-group", securityGroupID).Msg("Cannot delete the security group")
+		}
+
+		return "", fmt.Errorf("cannot open the ports of the security group: %w", err)
+	}
+
+	logger.Info().Str("security-group", securityGroupID).Str("allowed-cidr", allowedCIDR).Msg("Security group created")
+
+	return securityGroupID, nil
+}
+
+// openEngineToBench opens the engine to the public IP of the bench instance,
+// the group reference only matches the private addresses and the load goes
+// through the public address when the LLM instance has no private one
+func (c *AWSClient) openEngineToBench() error {
+	// the CLI does not change an existing security group
+	if c.networkConfig().SecurityGroupID != "" {
+		return nil
+	}
+
+	securityGroups, err := c.benchmarkSecurityGroups()
+	if err != nil {
+		return err
+	}
+	if len(securityGroups) == 0 {
+		return errors.New("the security group of the benchmark is missing")
+	}
+
+	benchAddresses, err := c.GetBenchInstanceAddresses()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.svc.AuthorizeSecurityGroupIngress(context.TODO(), &ec2.AuthorizeSecurityGroupIngressInput{
+		GroupId: securityGroups[0].GroupId,
+		IpPermissions: []types.IpPermission{
+			{
+				IpProtocol: aws.String("tcp"),
+				FromPort:   aws.Int32(enginePort),
+				ToPort:     aws.Int32(enginePort),
+				IpRanges: []types.IpRange{
+					{
+						CidrIp:      aws.String(benchAddresses.Public + "/32"),
+						Description: aws.String("Inference engine, from the public IP of the bench instance"),
+					},
+				},
+			},
+		},
+	})
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidPermission.Duplicate" {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot open the engine to the bench instance: %w", err)
+	}
+
+	return nil
+}
+
+// benchmarkSecurityGroups returns the security groups created for the bench id
+func (c *AWSClient) benchmarkSecurityGroups() ([]types.SecurityGroup, error) {
+	output, err := c.svc.DescribeSecurityGroups(context.TODO(), &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", constants.BenchIDTag)),
+				Values: []string{c.config.BenchID},
+			},
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", constants.ManagedByTag)),
+				Values: []string{constants.ManagedByValue},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the security groups: %w", err)
+	}
+
+	return output.SecurityGroups, nil
+}
+
+// deleteSecurityGroups deletes the security groups created for the bench id,
+// once the instances using them are terminated
+func (c *AWSClient) deleteSecurityGroups() error {
+	securityGroups, err := c.benchmarkSecurityGroups()
+	if err != nil {
+		return err
+	}
+
+	for _, securityGroup := range securityGroups {
+		securityGroupID := aws.ToString(securityGroup.GroupId)
+
+		err := c.waitForInstancesTermination("the security group "+securityGroupID, types.Filter{
+			Name:   aws.String("instance.group-id"),
+			Values: []string{securityGroupID},
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = c.svc.DeleteSecurityGroup(context.TODO(), &ec2.DeleteSecurityGroupInput{
+			GroupId: aws.String(securityGroupID),
+		})
+		if err != nil {
+			var apiErr smithy.APIError
+			if errors.As(err, &apiErr) && apiErr.ErrorCode() == "DependencyViolation" {
+				return fmt.Errorf("the security group %s is still used, run destroy again once its resources are deleted: %w", securityGroupID, err)
+			}
+
+			return fmt.Errorf("cannot delete the security group %s: %w", securityGroupID, err)
+		}
+
+		logger.Info().Str("security-group", securityGroupID).Msg("Security group deleted")
+	}
+
+	return nil
+}
+
+// waitForInstancesTermination waits for the instances matching the filter
+// to be terminated, the resource they use can then be deleted
+func (c *AWSClient) waitForInstancesTermination(resource string, filter types.Filter) error {
+	output, err := c.svc.DescribeInstances(context.TODO(), &ec2.DescribeInstancesInput{
+		Filters: []types.Filter{
+			filter,
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: liveStates,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	instanceIDs := []string{}
+	for _, reservation := range output.Reservations {
+		for _, instance := range reservation.Instances {
+			instanceIDs = append(instanceIDs, aws.ToString(instance.InstanceId))
+		}
+	}
+
+	if len(instanceIDs) == 0 {
+		return nil
+	}
+
+	logger.Info().Strs("instances", instanceIDs).Msg("Waiting for the instances to be terminated")
+
+	waiter := ec2.NewInstanceTerminatedWaiter(c.svc)
+	err = waiter.Wait(context.TODO(), &ec2.DescribeInstancesInput{InstanceIds: instanceIDs}, terminateTimeout)
+	if err != nil {
+		return fmt.Errorf("the instances of %s are not terminated: %w", resource, err)
+	}
+
+	return nil
+}
+
+// operatorCIDR returns the public IP of this machine as a /32
+func operatorCIDR() (string, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Get(checkIPURL)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", checkIPURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return "", err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil || ip.To4() == nil {
+		return "", fmt.Errorf("%s returned an invalid IPv4 address %q", checkIPURL, strings.TrimSpace(string(body)))
+	}
+
+	return ip.String() + "/32", nil
+}
@@ -0,0 +1,159 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/heka-ai/benchmark-cli/internal/constants"
+)
+
+// PlacementCluster packs the instances of the benchmark in a placement group
+const PlacementCluster = "cluster"
+
+// the burstable families cannot be launched in a cluster placement group
+var burstableFamilies = []string{"t2", "t3", "t3a", "t4g"}
+
+func (c *AWSClient) clusterPlacement() bool {
+	return c.config.AWSConfig.Placement == PlacementCluster
+}
+
+func (c *AWSClient) placementGroupName() string {
+	return fmt.Sprintf("benchmark-%s", c.config.BenchID)
+}
+
+// checkPlacement reports the instance types that cannot join the cluster
+// placement group, before any instance is created
+func (c *AWSClient) checkPlacement() error {
+	if !c.clusterPlacement() {
+		return nil
+	}
+
+	for _, instanceType := range []string{c.config.AWSConfig.GPUInstanceType, c.config.AWSConfig.CPUInstanceType} {
+		family, _, _ := strings.Cut(instanceType, ".")
+		for _, burstable := range burstableFamilies {
+			if family == burstable {
+				return fmt.Errorf("the burstable instance type %s cannot be launched in a cluster placement group, use another type such as c5.large or remove aws.placement", instanceType)
+			}
+		}
+	}
+
+	return nil
+}
+
+// getOrCreatePlacementGroup returns the name of the placement group of the
+// benchmark, it is created the first time
+func (c *AWSClient) getOrCreatePlacementGroup() (string, error) {
+	placementGroups, err := c.benchmarkPlacementGroups()
+	if err != nil {
+		return "", err
+	}
+
+	if len(placementGroups) > 0 {
+		return aws.ToString(placementGroups[0].GroupName), nil
+	}
+
+	_, err = c.svc.CreatePlacementGroup(context.TODO(), &ec2.CreatePlacementGroupInput{
+		GroupName: aws.String(c.placementGroupName()),
+		Strategy:  types.PlacementStrategyCluster,
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypePlacementGroup,
+				Tags:         c.defaultTags(),
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot create the placement group: %w", err)
+	}
+
+	logger.Info().Str("placement-group", c.placementGroupName()).Msg("Placement group created")
+
+	return c.placementGroupName(), nil
+}
+
+// benchmarkPlacementGroups returns the placement groups created for the bench id
+func (c *AWSClient) benchmarkPlacementGroups() ([]types.PlacementGroup, error) {
+	output, err := c.svc.DescribePlacementGroups(context.TODO(), &ec2.DescribePlacementGroupsInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", constants.BenchIDTag)),
+				Values: []string{c.config.BenchID},
+			},
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", constants.ManagedByTag)),
+				Values: []string{constants.ManagedByValue},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the placement groups: %w", err)
+	}
+
+	return output.PlacementGroups, nil
+}
+
+// deletePlacementGroups deletes the placement groups created for the bench
+// id, once their instances are terminated
+func (c *AWSClient) deletePlacementGroups() error {
+	placementGroups, err := c.benchmarkPlacementGroups()
+	if err != nil {
+		return err
+	}
+
+	for _, placementGroup := range placementGroups {
+		name := aws.ToString(placementGroup.GroupName)
+
+		err := c.waitForInstancesTermination("the placement group "+name, types.Filter{
+			Name:   aws.String("placement-group-name"),
+			Values: []string{name},
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = c.svc.DeletePlacementGroup(context.TODO(), &ec2.DeletePlacementGroupInput{
+			GroupName: aws.String(name),
+		})
+		if err != nil {
+			return fmt.Errorf("cannot delete the placement group %s: %w", name, err)
+		}
+
+		logger.Info().Str("placement-group", name).Msg("Placement group deleted")
+	}
+
+	return nil
+}
+
+// capacityError explains the errors of the instances AWS has no capacity
+// for, or no spot instance at the max price
+func (c *AWSClient) capacityError(err error, instanceType string) error {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	switch apiErr.ErrorCode() {
+	case "InsufficientInstanceCapacity", "InsufficientCapacity":
+		if c.clusterPlacement() {
+			return fmt.Errorf("AWS has no capacity for %s in the placement group %s, its instances must share an availability zone: retry later, set another aws.network.subnet_id or remove aws.placement: %w", instanceType, c.placementGroupName(), err)
+		}
+
+		if c.spotMarket() {
+			return fmt.Errorf("AWS has no spot capacity for %s in this availability zone, retry later, set another aws.network.subnet_id or remove aws.market: %w", instanceType, err)
+		}
+
+		return fmt.Errorf("AWS has no capacity for %s in this availability zone, retry later or set another aws.network.subnet_id: %w", instanceType, err)
+	case "SpotMaxPriceTooLow":
+		return fmt.Errorf("the spot price of %s is above the max price, raise aws.gpu_max_price or aws.cpu_max_price, or set it to 0 to pay up to the on-demand price: %w", instanceType, err)
+	case "MaxSpotInstanceCountExceeded":
+		return fmt.Errorf("the spot instances quota of the account is reached for %s, request a higher quota or remove aws.market: %w", instanceType, err)
+	}
+
+	return err
+}
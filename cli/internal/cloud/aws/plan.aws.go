@@ -0,0 +1,319 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/smithy-go"
+	"github.com/heka-ai/benchmark-cli/internal/cloud"
+	"github.com/heka-ai/benchmark-cli/internal/pricing"
+)
+
+// MarketOnDemand is the market of the instances when aws.market is not spot
+const MarketOnDemand = "on-demand"
+
+// plannedInstance is an instance Create launches
+type plannedInstance struct {
+	role         string
+	instanceType string
+	ami          string
+	maxPrice     float64
+}
+
+// Plan lists the resources Create would create or reuse and the cost of
+// the instances. It only reads the account, its quotas and runs dry runs, the problems
+// it finds are returned in the plan rather than as errors.
+func (c *AWSClient) Plan() (*cloud.Plan, error) {
+	if !c.wasInit {
+		return nil, errors.New("client not initialized")
+	}
+
+	plan := &cloud.Plan{}
+
+	if err := c.resolveImages(); err != nil {
+		plan.Problems = append(plan.Problems, err)
+	}
+
+	if err := c.checkPlacement(); err != nil {
+		plan.Problems = append(plan.Problems, err)
+	}
+
+	existing, err := c.benchmarkInstances(liveStates...)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		plan.Problems = append(plan.Problems, fmt.Errorf("the benchmark %s already has %d instances, bench create would launch more, run bench destroy first", c.config.BenchID, len(existing)))
+	}
+
+	c.planIAM(plan)
+	c.planNetwork(plan)
+	c.planKeyPair(plan)
+
+	for _, instance := range []plannedInstance{
+		{role: "llm", instanceType: c.config.AWSConfig.GPUInstanceType, ami: c.config.AWSConfig.GPU_AMI, maxPrice: c.config.AWSConfig.GPUMaxPrice},
+		{role: "bench", instanceType: c.config.AWSConfig.CPUInstanceType, ami: c.config.AWSConfig.CPU_AMI, maxPrice: c.config.AWSConfig.CPUMaxPrice},
+	} {
+		c.planInstance(plan, instance)
+	}
+
+	c.planQuotas(plan)
+
+	return plan, nil
+}
+
+func isNoSuchEntity(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchEntity"
+}
+
+// planIAM checks the role of the instances, shared by all the benchmarks
+func (c *AWSClient) planIAM(plan *cloud.Plan) {
+	_, err := c.iam.GetRole(context.TODO(), &iam.GetRoleInput{RoleName: aws.String(roleName)})
+	switch {
+	case err == nil:
+		plan.Resources = append(plan.Resources, cloud.PlannedResource{Kind: "iam-role", Name: roleName, Action: cloud.ActionReuse})
+	case isNoSuchEntity(err):
+		plan.Resources = append(plan.Resources, cloud.PlannedResource{Kind: "iam-role", Name: roleName, Action: cloud.ActionCreate, Details: "AmazonSSMManagedInstanceCore"})
+	default:
+		plan.Problems = append(plan.Problems, fmt.Errorf("cannot read the IAM role %s: %w", roleName, err))
+	}
+
+	_, err = c.iam.GetInstanceProfile(context.TODO(), &iam.GetInstanceProfileInput{InstanceProfileName: aws.String(instanceProfileName)})
+	switch {
+	case err == nil:
+		plan.Resources = append(plan.Resources, cloud.PlannedResource{Kind: "instance-profile", Name: instanceProfileName, Action: cloud.ActionReuse})
+	case isNoSuchEntity(err):
+		plan.Resources = append(plan.Resources, cloud.PlannedResource{Kind: "instance-profile", Name: instanceProfileName, Action: cloud.ActionCreate, Details: "role " + roleName})
+	default:
+		plan.Problems = append(plan.Problems, fmt.Errorf("cannot read the instance profile %s: %w", instanceProfileName, err))
+	}
+}
+
+// planNetwork resolves the network like getNetwork, without creating the
+// groups
+func (c *AWSClient) planNetwork(plan *cloud.Plan) {
+	networkConfig := c.networkConfig()
+
+	vpcID, subnetID, err := c.resolveSubnet(networkConfig)
+	if err != nil {
+		plan.Problems = append(plan.Problems, err)
+		return
+	}
+
+	if subnetID == "" {
+		plan.Resources = append(plan.Resources, cloud.PlannedResource{Kind: "subnet", Name: "default", Action: cloud.ActionReuse, Details: "a default subnet of the default VPC"})
+	} else {
+		plan.Resources = append(plan.Resources, cloud.PlannedResource{Kind: "subnet", Name: subnetID, Action: cloud.ActionReuse, Details: "VPC " + vpcID})
+	}
+
+	switch {
+	case networkConfig.SecurityGroupID != "":
+		_, err := c.svc.DescribeSecurityGroups(context.TODO(), &ec2.DescribeSecurityGroupsInput{
+			GroupIds: []string{networkConfig.SecurityGroupID},
+		})
+		if err != nil {
+			plan.Problems = append(plan.Problems, fmt.Errorf("cannot find the security group %s: %w", networkConfig.SecurityGroupID, err))
+		} else {
+			plan.Resources = append(plan.Resources, cloud.PlannedResource{Kind: "security-group", Name: networkConfig.SecurityGroupID, Action: cloud.ActionReuse})
+		}
+	default:
+		securityGroups, err := c.benchmarkSecurityGroups()
+		if err != nil {
+			plan.Problems = append(plan.Problems, err)
+			break
+		}
+
+		if len(securityGroups) > 0 {
+			plan.Resources = append(plan.Resources, cloud.PlannedResource{Kind: "security-group", Name: aws.ToString(securityGroups[0].GroupId), Action: cloud.ActionReuse})
+			break
+		}
+
+		allowedCIDR := networkConfig.AllowedCIDR
+		if allowedCIDR == "" {
+			allowedCIDR, err = operatorCIDR()
+			if err != nil {
+				plan.Problems = append(plan.Problems, fmt.Errorf("cannot find the public IP of this machine, set aws.network.allowed_cidr: %w", err))
+				break
+			}
+		}
+
+		plan.Resources = append(plan.Resources, cloud.PlannedResource{
+			Kind:    "security-group",
+			Name:    c.securityGroupName(),
+			Action:  cloud.ActionCreate,
+			Details: fmt.Sprintf("ports %d and %d from %s, port %d between the instances", apiPort, sshPort, allowedCIDR, enginePort),
+		})
+	}
+
+	if c.clusterPlacement() {
+		placementGroups, err := c.benchmarkPlacementGroups()
+		if err != nil {
+			plan.Problems = append(plan.Problems, err)
+			return
+		}
+
+		if len(placementGroups) > 0 {
+			plan.Resources = append(plan.Resources, cloud.PlannedResource{Kind: "placement-group", Name: aws.ToString(placementGroups[0].GroupName), Action: cloud.ActionReuse})
+		} else {
+			plan.Resources = append(plan.Resources, cloud.PlannedResource{Kind: "placement-group", Name: c.placementGroupName(), Action: cloud.ActionCreate, Details: PlacementCluster})
+		}
+	}
+}
+
+// planKeyPair checks the key pair of the benchmark, it is replaced when its
+// private key is not on this machine
+func (c *AWSClient) planKeyPair(plan *cloud.Plan) {
+	name := c.keyPairName()
+
+	exists, err := c.keyPairExists(name)
+	if err != nil {
+		plan.Problems = append(plan.Problems, err)
+		return
+	}
+
+	dir, err := c.sshDir()
+	if err != nil {
+		plan.Problems = append(plan.Problems, err)
+		return
+	}
+
+	keyPath := filepath.Join(dir, privateKeyFile)
+	if _, err := os.Stat(keyPath); exists && err == nil {
+		plan.Resources = append(plan.Resources, cloud.PlannedResource{Kind: "key-pair", Name: name, Action: cloud.ActionReuse, Details: keyPath})
+	} else {
+		plan.Resources = append(plan.Resources, cloud.PlannedResource{Kind: "key-pair", Name: name, Action: cloud.ActionCreate, Details: "ed25519, private key in " + keyPath})
+	}
+}
+
+// planInstance checks the image and the instance type, runs a dry run of
+// the launch and estimates the hourly cost of the instance
+func (c *AWSClient) planInstance(plan *cloud.Plan, instance plannedInstance) {
+	market := MarketOnDemand
+	if c.spotMarket() {
+		market = MarketSpot
+	}
+
+	planned := cloud.PlannedInstance{Role: instance.role, InstanceType: instance.instanceType, Market: market}
+
+	// the root volume takes the type of the root device of the image
+	volumeType := "gp2"
+	image, err := c.describeImage(instance.ami)
+	if err != nil {
+		plan.Problems = append(plan.Problems, err)
+	} else {
+		plan.Resources = append(plan.Resources, cloud.PlannedResource{Kind: "image", Name: instance.ami, Action: cloud.ActionReuse, Details: aws.ToString(image.Name)})
+
+		for _, mapping := range image.BlockDeviceMappings {
+			if aws.ToString(mapping.DeviceName) == aws.ToString(image.RootDeviceName) && mapping.Ebs != nil && mapping.Ebs.VolumeType != "" {
+				volumeType = string(mapping.Ebs.VolumeType)
+			}
+		}
+	}
+
+	instanceTypes, err := c.svc.DescribeInstanceTypes(context.TODO(), &ec2.DescribeInstanceTypesInput{
+		InstanceTypes: []types.InstanceType{types.InstanceType(instance.instanceType)},
+	})
+	if err != nil {
+		plan.Problems = append(plan.Problems, fmt.Errorf("the instance type %s is not offered in %s: %w", instance.instanceType, c.config.AWSConfig.Region, err))
+	} else if len(instanceTypes.InstanceTypes) == 0 {
+		plan.Problems = append(plan.Problems, fmt.Errorf("the instance type %s is not offered in %s", instance.instanceType, c.config.AWSConfig.Region))
+	} else if vcpus := instanceTypes.InstanceTypes[0].VCpuInfo; vcpus != nil {
+		planned.VCPUs = int(aws.ToInt32(vcpus.DefaultVCpus))
+	}
+
+	if err := c.dryRunInstance(instance); err != nil {
+		plan.Problems = append(plan.Problems, err)
+	}
+
+	details := fmt.Sprintf("%s, %s", instance.instanceType, market)
+	if planned.VCPUs > 0 {
+		details += fmt.Sprintf(", %d vCPUs", planned.VCPUs)
+	}
+	plan.Resources = append(plan.Resources,
+		cloud.PlannedResource{Kind: "instance", Name: fmt.Sprintf("benchmark-%s (%s)", c.config.BenchID, instance.role), Action: cloud.ActionCreate, Details: details},
+		cloud.PlannedResource{Kind: "volume", Name: fmt.Sprintf("root of the %s instance", instance.role), Action: cloud.ActionCreate, Details: fmt.Sprintf("%d GB %s, deleted with the instance", rootVolumeSize, volumeType)},
+	)
+
+	hourly, instanceKnown := c.hourlyPrice(plan, instance)
+	volumeHourly, volumeKnown := pricing.VolumeCost(volumeType, rootVolumeSize, time.Hour)
+
+	planned.HourlyUSD = hourly
+	planned.VolumeHourlyUSD = volumeHourly
+	planned.PriceKnown = instanceKnown && volumeKnown
+
+	plan.Instances = append(plan.Instances, planned)
+}
+
+func (c *AWSClient) describeImage(ami string) (*types.Image, error) {
+	output, err := c.svc.DescribeImages(context.TODO(), &ec2.DescribeImagesInput{
+		ImageIds: []string{ami},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot find the image %s in %s: %w", ami, c.config.AWSConfig.Region, err)
+	}
+
+	if len(output.Images) == 0 {
+		return nil, fmt.Errorf("cannot find the image %s in %s", ami, c.config.AWSConfig.Region)
+	}
+
+	return &output.Images[0], nil
+}
+
+// hourlyPrice returns the price of the instance on its market, the spot
+// price is the current one and is checked against the max price
+func (c *AWSClient) hourlyPrice(plan *cloud.Plan, instance plannedInstance) (float64, bool) {
+	if !c.spotMarket() {
+		price, ok := pricing.GetInstance(instance.instanceType)
+		return price.HourlyUSD, ok
+	}
+
+	price, err := c.spotPrice(instance.instanceType)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Cannot estimate the cost of the spot instance")
+		return 0, false
+	}
+
+	if instance.maxPrice > 0 && price > instance.maxPrice {
+		plan.Problems = append(plan.Problems, fmt.Errorf("the spot price of %s is %.4f USD, above the max price %.4f USD of the %s instance", instance.instanceType, price, instance.maxPrice, instance.role))
+	}
+
+	return price, true
+}
+
+// dryRunInstance launches the instance with DryRun, AWS checks the
+// permissions, the image and the instance type, not the quotas of the
+// account, see planQuotas. The network and the instance profile are left
+// out, they may not exist yet.
+func (c *AWSClient) dryRunInstance(instance plannedInstance) error {
+	_, err := c.svc.RunInstances(context.TODO(), &ec2.RunInstancesInput{
+		InstanceType:          types.InstanceType(instance.instanceType),
+		ImageId:               aws.String(instance.ami),
+		MinCount:              aws.Int32(1),
+		MaxCount:              aws.Int32(1),
+		DryRun:                aws.Bool(true),
+		InstanceMarketOptions: c.marketOptions(instance.maxPrice),
+	})
+	if err == nil || isDryRunError(err) {
+		return nil
+	}
+
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return fmt.Errorf("cannot run the dry run of the %s instance: %w", instance.role, err)
+	}
+
+	if apiErr.ErrorCode() == "UnauthorizedOperation" {
+		return fmt.Errorf("the credentials cannot launch the %s instance, see bench creds: %w", instance.role, err)
+	}
+
+	return c.capacityError(fmt.Errorf("the dry run of the %s instance %s failed: %w", instance.role, instance.instanceType, err), instance.instanceType)
+}
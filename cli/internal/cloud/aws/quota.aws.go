@@ -0,0 +1,154 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	quotaTypes "github.com/aws/aws-sdk-go-v2/service/servicequotas/types"
+	"github.com/heka-ai/benchmark-cli/internal/cloud"
+)
+
+// vcpuQuota is the Service Quotas entry limiting the vCPUs of a family of
+// instance types on a market
+type vcpuQuota struct {
+	code string
+	name string
+}
+
+// the codes of the EC2 vCPU quotas by kind, on-demand then spot
+var vcpuQuotaCodes = map[string][2]string{
+	"Standard (A, C, D, H, I, M, R, T, Z)": {"L-1216C47A", "L-34B43A08"},
+	"G and VT":                             {"L-DB2E81BA", "L-3819A6DF"},
+	"P":                                    {"L-417A185B", "L-7212CCBC"},
+	"Inf":                                  {"L-1945791B", "L-B5D1601B"},
+}
+
+// vcpuQuota returns the quota limiting the vCPUs of the family of the
+// instance type on the market of the config
+func (c *AWSClient) vcpuQuota(instanceType string) vcpuQuota {
+	family, _, _ := strings.Cut(instanceType, ".")
+
+	kind := "Standard (A, C, D, H, I, M, R, T, Z)"
+	switch {
+	case strings.HasPrefix(family, "g"), strings.HasPrefix(family, "vt"):
+		kind = "G and VT"
+	case strings.HasPrefix(family, "p"):
+		kind = "P"
+	case strings.HasPrefix(family, "inf"):
+		kind = "Inf"
+	}
+
+	if c.spotMarket() {
+		return vcpuQuota{code: vcpuQuotaCodes[kind][1], name: fmt.Sprintf("All %s Spot Instance Requests", kind)}
+	}
+
+	return vcpuQuota{code: vcpuQuotaCodes[kind][0], name: fmt.Sprintf("Running On-Demand %s instances", kind)}
+}
+
+// planQuotas checks that the vCPU quotas of the account leave room for the
+// planned instances, the instances sharing a quota add up. A launch with
+// DryRun only checks the permissions, it never reports a quota.
+func (c *AWSClient) planQuotas(plan *cloud.Plan) {
+	needed := map[vcpuQuota]int{}
+	quotas := []vcpuQuota{}
+
+	for _, instance := range plan.Instances {
+		// the instance type could not be described, it is already reported
+		if instance.VCPUs == 0 {
+			continue
+		}
+
+		quota := c.vcpuQuota(instance.InstanceType)
+		if _, ok := needed[quota]; !ok {
+			quotas = append(quotas, quota)
+		}
+		needed[quota] += instance.VCPUs
+	}
+
+	for _, quota := range quotas {
+		limit, err := c.quotaValue(quota.code)
+		if err != nil {
+			plan.Problems = append(plan.Problems, fmt.Errorf("cannot read the %q quota (%s), see bench creds: %w", quota.name, quota.code, err))
+			continue
+		}
+
+		used, err := c.usedVCPUs(quota)
+		if err != nil {
+			plan.Problems = append(plan.Problems, fmt.Errorf("cannot count the vCPUs used in the %q quota: %w", quota.name, err))
+			continue
+		}
+
+		if used+needed[quota] > limit {
+			plan.Problems = append(plan.Problems, fmt.Errorf("the instances need %d vCPUs of the %q quota (%s) but %d of its %d vCPUs are used, request an increase of the quota", needed[quota], quota.name, quota.code, used, limit))
+		}
+	}
+}
+
+// quotaValue returns the value of the EC2 quota in the region, the default
+// value of AWS when the account has none
+func (c *AWSClient) quotaValue(code string) (int, error) {
+	output, err := c.quotas.GetServiceQuota(context.TODO(), &servicequotas.GetServiceQuotaInput{
+		ServiceCode: aws.String("ec2"),
+		QuotaCode:   aws.String(code),
+	})
+
+	var noSuchResource *quotaTypes.NoSuchResourceException
+	if errors.As(err, &noSuchResource) {
+		defaultOutput, defaultErr := c.quotas.GetAWSDefaultServiceQuota(context.TODO(), &servicequotas.GetAWSDefaultServiceQuotaInput{
+			ServiceCode: aws.String("ec2"),
+			QuotaCode:   aws.String(code),
+		})
+		if defaultErr != nil {
+			return 0, defaultErr
+		}
+
+		return int(aws.ToFloat64(defaultOutput.Quota.Value)), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return int(aws.ToFloat64(output.Quota.Value)), nil
+}
+
+// usedVCPUs sums the vCPUs of the pending and running instances of the
+// account counted by the quota, the spot instances count in the spot quotas
+func (c *AWSClient) usedVCPUs(quota vcpuQuota) (int, error) {
+	paginator := ec2.NewDescribeInstancesPaginator(c.svc, &ec2.DescribeInstancesInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: []string{"pending", "running"},
+			},
+		},
+	})
+
+	used := 0
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return 0, err
+		}
+
+		for _, reservation := range output.Reservations {
+			for _, instance := range reservation.Instances {
+				spot := instance.InstanceLifecycle == types.InstanceLifecycleTypeSpot
+				if spot != c.spotMarket() || c.vcpuQuota(string(instance.InstanceType)) != quota {
+					continue
+				}
+
+				if instance.CpuOptions != nil {
+					used += int(aws.ToInt32(instance.CpuOptions.CoreCount) * aws.ToInt32(instance.CpuOptions.ThreadsPerCore))
+				}
+			}
+		}
+	}
+
+	return used, nil
+}
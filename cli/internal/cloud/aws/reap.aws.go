@@ -0,0 +1,343 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/heka-ai/benchmark-cli/internal/cloud"
+	"github.com/heka-ai/benchmark-cli/internal/constants"
+)
+
+// the key pairs of the image builder, created before they were tagged
+const keyPairPrefix = "benchmark-key-pair-"
+
+// Reap deletes the resources tagged managed-by=benchmark-cli older than
+// olderThan in the region of the config, whatever their bench id and state.
+// The instances are terminated first, the groups they use can only be
+// deleted once they are gone. A resource that cannot be deleted does not
+// stop the others, its error is set on it.
+func (c *AWSClient) Reap(olderThan time.Duration, dryRun bool) ([]cloud.Resource, error) {
+	cutoff := time.Now().Add(-olderThan)
+
+	instances, err := c.reapInstances(cutoff, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPairs, err := c.reapKeyPairs(cutoff, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := c.reapImages(cutoff, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	securityGroups, err := c.reapSecurityGroups(cutoff, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	placementGroups, err := c.reapPlacementGroups(cutoff, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := append(instances, keyPairs...)
+	resources = append(resources, images...)
+	resources = append(resources, securityGroups...)
+	resources = append(resources, placementGroups...)
+
+	return resources, nil
+}
+
+func managedByFilter() types.Filter {
+	return types.Filter{
+		Name:   aws.String(fmt.Sprintf("tag:%s", constants.ManagedByTag)),
+		Values: []string{constants.ManagedByValue},
+	}
+}
+
+func tagValue(tags []types.Tag, key string) string {
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == key {
+			return aws.ToString(tag.Value)
+		}
+	}
+
+	return ""
+}
+
+// createdAt reads the created-at tag, the resources created before the tag
+// existed are considered as old
+func createdAt(tags []types.Tag) time.Time {
+	created, err := time.Parse(time.RFC3339, tagValue(tags, constants.CreatedAtTag))
+	if err != nil {
+		return time.Time{}
+	}
+
+	return created
+}
+
+func (c *AWSClient) reapInstances(cutoff time.Time, dryRun bool) ([]cloud.Resource, error) {
+	resources := []cloud.Resource{}
+	instanceIDs := []string{}
+	seen := map[string]bool{}
+
+	// the image builders created before the managed-by tag only have the
+	// template tag
+	for _, filter := range []types.Filter{
+		managedByFilter(),
+		{
+			Name:   aws.String(fmt.Sprintf("tag:%s", constants.BenchInstanceLabelKey)),
+			Values: []string{AMI_INSTANCE_TEMPLATE_TAG},
+		},
+	} {
+		paginator := ec2.NewDescribeInstancesPaginator(c.svc, &ec2.DescribeInstancesInput{
+			Filters: []types.Filter{
+				filter,
+				{
+					Name:   aws.String("instance-state-name"),
+					Values: liveStates,
+				},
+			},
+		})
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(context.TODO())
+			if err != nil {
+				return nil, fmt.Errorf("cannot list the instances: %w", err)
+			}
+
+			for _, reservation := range output.Reservations {
+				for _, instance := range reservation.Instances {
+					instanceID := aws.ToString(instance.InstanceId)
+					launchTime := aws.ToTime(instance.LaunchTime)
+					if seen[instanceID] || launchTime.After(cutoff) {
+						continue
+					}
+					seen[instanceID] = true
+
+					resources = append(resources, cloud.Resource{
+						Kind:      "instance",
+						ID:        instanceID,
+						BenchID:   tagValue(instance.Tags, constants.BenchIDTag),
+						CreatedAt: launchTime,
+					})
+					instanceIDs = append(instanceIDs, instanceID)
+				}
+			}
+		}
+	}
+
+	if dryRun || len(instanceIDs) == 0 {
+		return resources, nil
+	}
+
+	_, err := c.svc.TerminateInstances(context.TODO(), &ec2.TerminateInstancesInput{
+		InstanceIds: instanceIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot terminate the instances: %w", err)
+	}
+
+	logger.Info().Strs("instances", instanceIDs).Msg("Instances terminated")
+
+	// the security and placement groups are still used until then
+	err = c.waitForInstancesTermination("the reaped instances", types.Filter{
+		Name:   aws.String("instance-id"),
+		Values: instanceIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resources, nil
+}
+
+func (c *AWSClient) reapKeyPairs(cutoff time.Time, dryRun bool) ([]cloud.Resource, error) {
+	keyPairs := map[string]types.KeyPairInfo{}
+
+	for _, filter := range []types.Filter{
+		managedByFilter(),
+		{
+			Name:   aws.String("key-name"),
+			Values: []string{keyPairPrefix + "*"},
+		},
+	} {
+		output, err := c.svc.DescribeKeyPairs(context.TODO(), &ec2.DescribeKeyPairsInput{
+			Filters: []types.Filter{filter},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the key pairs: %w", err)
+		}
+
+		for _, keyPair := range output.KeyPairs {
+			keyPairs[aws.ToString(keyPair.KeyPairId)] = keyPair
+		}
+	}
+
+	resources := []cloud.Resource{}
+	for _, keyPair := range keyPairs {
+		created := aws.ToTime(keyPair.CreateTime)
+		if created.After(cutoff) {
+			continue
+		}
+
+		resource := cloud.Resource{
+			Kind:      "key-pair",
+			ID:        aws.ToString(keyPair.KeyName),
+			BenchID:   tagValue(keyPair.Tags, constants.BenchIDTag),
+			CreatedAt: created,
+		}
+
+		if !dryRun {
+			_, resource.Err = c.svc.DeleteKeyPair(context.TODO(), &ec2.DeleteKeyPairInput{
+				KeyPairId: keyPair.KeyPairId,
+			})
+		}
+
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
+
+// reapImages deletes the images left by the failed builds and their
+// snapshots, the images that were built are kept
+func (c *AWSClient) reapImages(cutoff time.Time, dryRun bool) ([]cloud.Resource, error) {
+	output, err := c.svc.DescribeImages(context.TODO(), &ec2.DescribeImagesInput{
+		Owners: []string{"self"},
+		Filters: []types.Filter{
+			managedByFilter(),
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", constants.ImageBuildTag)),
+				Values: []string{constants.ImageBuildPendingValue},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the images: %w", err)
+	}
+
+	resources := []cloud.Resource{}
+	for _, image := range output.Images {
+		created, err := time.Parse(time.RFC3339, aws.ToString(image.CreationDate))
+		if err == nil && created.After(cutoff) {
+			continue
+		}
+
+		resource := cloud.Resource{
+			Kind:      "image",
+			ID:        aws.ToString(image.ImageId),
+			CreatedAt: created,
+		}
+
+		if !dryRun {
+			resource.Err = c.deleteImage(image)
+		}
+
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
+
+// deleteImage deregisters the image then deletes its snapshots, they are
+// not deleted with it
+func (c *AWSClient) deleteImage(image types.Image) error {
+	_, err := c.svc.DeregisterImage(context.TODO(), &ec2.DeregisterImageInput{
+		ImageId: image.ImageId,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, mapping := range image.BlockDeviceMappings {
+		if mapping.Ebs == nil || mapping.Ebs.SnapshotId == nil {
+			continue
+		}
+
+		_, err := c.svc.DeleteSnapshot(context.TODO(), &ec2.DeleteSnapshotInput{
+			SnapshotId: mapping.Ebs.SnapshotId,
+		})
+		if err != nil {
+			return fmt.Errorf("the image is deregistered but its snapshot %s is left: %w", aws.ToString(mapping.Ebs.SnapshotId), err)
+		}
+	}
+
+	return nil
+}
+
+func (c *AWSClient) reapSecurityGroups(cutoff time.Time, dryRun bool) ([]cloud.Resource, error) {
+	output, err := c.svc.DescribeSecurityGroups(context.TODO(), &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{managedByFilter()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the security groups: %w", err)
+	}
+
+	resources := []cloud.Resource{}
+	for _, securityGroup := range output.SecurityGroups {
+		created := createdAt(securityGroup.Tags)
+		if created.After(cutoff) {
+			continue
+		}
+
+		resource := cloud.Resource{
+			Kind:      "security-group",
+			ID:        aws.ToString(securityGroup.GroupId),
+			BenchID:   tagValue(securityGroup.Tags, constants.BenchIDTag),
+			CreatedAt: created,
+		}
+
+		// a group still used by a recent instance cannot be deleted
+		if !dryRun {
+			_, resource.Err = c.svc.DeleteSecurityGroup(context.TODO(), &ec2.DeleteSecurityGroupInput{
+				GroupId: securityGroup.GroupId,
+			})
+		}
+
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
+
+func (c *AWSClient) reapPlacementGroups(cutoff time.Time, dryRun bool) ([]cloud.Resource, error) {
+	output, err := c.svc.DescribePlacementGroups(context.TODO(), &ec2.DescribePlacementGroupsInput{
+		Filters: []types.Filter{managedByFilter()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the placement groups: %w", err)
+	}
+
+	resources := []cloud.Resource{}
+	for _, placementGroup := range output.PlacementGroups {
+		created := createdAt(placementGroup.Tags)
+		if created.After(cutoff) {
+			continue
+		}
+
+		resource := cloud.Resource{
+			Kind:      "placement-group",
+			ID:        aws.ToString(placementGroup.GroupName),
+			BenchID:   tagValue(placementGroup.Tags, constants.BenchIDTag),
+			CreatedAt: created,
+		}
+
+		if !dryRun {
+			_, resource.Err = c.svc.DeletePlacementGroup(context.TODO(), &ec2.DeletePlacementGroupInput{
+				GroupName: placementGroup.GroupName,
+			})
+		}
+
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
@@ -0,0 +1,67 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsConfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// SecretsManagerScheme is the scheme of the AWS Secrets Manager references,
+// ${aws-sm:<secret id>} or ${aws-sm:<secret id>#<json key>}
+const SecretsManagerScheme = "aws-sm"
+
+// SecretsManagerBackend resolves the references from AWS Secrets Manager.
+// The secrets are read before the config is decoded, so the credentials and
+// the region come from the environment (AWS_PROFILE, AWS_REGION...) and not from [aws].
+type SecretsManagerBackend struct {
+	once sync.Once
+	svc  *secretsmanager.Client
+	err  error
+}
+
+func (b *SecretsManagerBackend) Resolve(ref string) (string, error) {
+	b.once.Do(func() {
+		conf, err := awsConfig.LoadDefaultConfig(context.TODO())
+		if err != nil {
+			b.err = err
+			return
+		}
+		b.svc = secretsmanager.NewFromConfig(conf)
+	})
+
+	if b.err != nil {
+		return "", b.err
+	}
+
+	id, key, hasKey := strings.Cut(ref, "#")
+
+	out, err := b.svc.GetSecretValue(context.TODO(), &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(id),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	secret := aws.ToString(out.SecretString)
+	if !hasKey {
+		return secret, nil
+	}
+
+	values := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object: %v", id, err)
+	}
+
+	value, ok := values[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", id, key)
+	}
+
+	return fmt.Sprint(value), nil
+}
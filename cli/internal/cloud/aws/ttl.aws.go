@@ -0,0 +1,26 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+)
+
+// the instances of a benchmark are terminated after this many hours when
+// aws.ttl_hours is not set
+const defaultTTLHours = 12
+
+func (c *AWSClient) ttl() time.Duration {
+	hours := c.config.AWSConfig.TTLHours
+	if hours == 0 {
+		hours = defaultTTLHours
+	}
+
+	return time.Duration(hours) * time.Hour
+}
+
+// watchdogScript powers the instance off once the TTL is over, the
+// instances are launched with a terminate shutdown behavior so they are
+// terminated rather than stopped, even when the CLI crashed
+func (c *AWSClient) watchdogScript() string {
+	return fmt.Sprintf("shutdown -h +%d\n", int(c.ttl().Minutes()))
+}
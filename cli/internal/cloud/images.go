@@ -0,0 +1,27 @@
+package cloud
+
+// PublishOptions tells PublishImages which images to copy and who can
+// launch the copies
+type PublishOptions struct {
+	// the images of the region of the config, the latest image of each role
+	// when empty
+	ImageIDs []string
+	Regions  []string
+	// anyone can launch the images
+	Public bool
+	// the accounts that can launch the images
+	AccountIDs []string
+}
+
+// PublishedImage is an image copied to a region by PublishImages
+type PublishedImage struct {
+	// llm or bench
+	Role    string
+	Region  string
+	ImageID string
+	Name    string
+	// false when the region already had the image
+	Copied bool
+	// set when the image could not be copied or shared
+	Err error
+}
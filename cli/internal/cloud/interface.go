@@ -1,6 +1,10 @@
 package cloud
 
 import (
+	"context"
+	"time"
+
+	"github.com/heka-ai/benchmark-cli/internal/imagebuilder"
 	"github.com/heka-ai/benchmark-cli/pkg/config"
 )
 
@@ -8,17 +12,20 @@ import (
 type Cloud interface {
 	NewClient(config *config.Config) Cloud
 
-	// Validate the cloud credentials
-	ValidateCredentials() error
+	// Check that the credentials allow every action the pipeline calls, the
+	// error is only set when the checks cannot run
+	ValidateCredentials() ([]PermissionCheck, error)
 
 	// Create the two instances needed for the benchmark
 	Create() error
 
-	// Create the LLM instance
-	CreateLLMInstance() error
+	// Build the image of the llm or bench instances from the install
+	// scripts, returns the id of the image
+	BuildImage(ctx context.Context, options imagebuilder.Options) (string, error)
 
-	// Create the CPU instance
-	CreateBenchInstance() error
+	// Copy the images to the regions and share the copies, an image that
+	// fails does not stop the others
+	PublishImages(ctx context.Context, options PublishOptions) ([]PublishedImage, error)
 
 	// Destroy the instances
 	Destroy() error
@@ -28,4 +35,70 @@ type Cloud interface {
 
 	// Get the IP address of the CPU instance
 	GetBenchInstanceIP() (string, error)
+
+	// Get the public and private addresses of the LLM instance
+	GetLLMInstanceAddresses() (*Addresses, error)
+
+	// Get the public and private addresses of the CPU instance
+	GetBenchInstanceAddresses() (*Addresses, error)
+
+	// Get how to connect over SSH to the llm or bench instance
+	SSHAccess(role string) (*SSHAccess, error)
+
+	// List what Create would create or reuse and its cost, and the problems
+	// that would make it fail, without creating anything
+	Plan() (*Plan, error)
+
+	// Delete the resources created by the CLI older than olderThan, for all
+	// the benchmarks, they are only listed on a dry run
+	Reap(olderThan time.Duration, dryRun bool) ([]Resource, error)
+}
+
+// Resource is a resource found by Reap
+type Resource struct {
+	// instance, key-pair, image, security-group or placement-group
+	Kind string
+	ID   string
+	// empty for the resources shared by the benchmarks, e.g. the images
+	BenchID   string
+	CreatedAt time.Time
+	// set when the resource could not be deleted
+	Err error
+}
+
+// How the bench instance reaches the LLM instance, recorded in the results
+const (
+	AddressModePrivate = "private"
+	AddressModePublic  = "public"
+)
+
+// Addresses of an instance, the CLI controls the instances through their
+// public address while the load goes through the private network
+type Addresses struct {
+	Public string
+	// empty when the provider gives no private address
+	Private string
+}
+
+// LoadAddress returns the address the benchmark load is sent to and its
+// mode, the private address keeps the traffic off the internet
+func (a *Addresses) LoadAddress() (string, string) {
+	if a.Private != "" {
+		return a.Private, AddressModePrivate
+	}
+
+	return a.Public, AddressModePublic
+}
+
+// PermissionCheck is the result of the check of an action of the provider
+type PermissionCheck struct {
+	Action   string
+	Resource string
+	// the commands calling the action, e.g. create, destroy
+	Steps []string
+	// the action is only called by the optional commands, e.g. bench reap
+	Optional bool
+	Allowed  bool
+	// why the action is denied, or how it was checked
+	Details string
 }
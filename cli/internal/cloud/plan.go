@@ -0,0 +1,59 @@
+package cloud
+
+// What Create would do with a resource
+const (
+	ActionCreate = "create"
+	ActionReuse  = "reuse"
+)
+
+// Plan lists what Create would create or reuse, nothing is created to
+// build it
+type Plan struct {
+	Resources []PlannedResource
+	Instances []PlannedInstance
+	// the problems that would make Create fail, e.g. a missing image or a
+	// vCPU quota too low
+	Problems []error
+}
+
+// PlannedResource is a resource Create would create or reuse
+type PlannedResource struct {
+	// instance, volume, image, iam-role, security-group...
+	Kind   string
+	Name   string
+	Action string
+	// a short description, e.g. the size of a volume
+	Details string
+}
+
+// PlannedInstance is an instance Create would launch and its cost
+type PlannedInstance struct {
+	// llm or bench
+	Role         string
+	InstanceType string
+	// on-demand or spot
+	Market string
+	VCPUs  int
+	// USD per hour of the instance and of its root volume
+	HourlyUSD       float64
+	VolumeHourlyUSD float64
+	// false when the price of the instance type or volume is not known
+	PriceKnown bool
+}
+
+// HourlyUSD returns the cost per hour of the instances whose price is
+// known, and whether all of them are known
+func (p *Plan) HourlyUSD() (float64, bool) {
+	total := 0.0
+	known := true
+
+	for _, instance := range p.Instances {
+		if !instance.PriceKnown {
+			known = false
+			continue
+		}
+		total += instance.HourlyUSD + instance.VolumeHourlyUSD
+	}
+
+	return total, known
+}
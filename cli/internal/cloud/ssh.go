@@ -0,0 +1,12 @@
+package cloud
+
+// SSHAccess is what bench ssh and bench cp need to connect to an instance
+type SSHAccess struct {
+	Host string
+	User string
+	// the private key of the key pair registered when the instances were
+	// created
+	KeyPath string
+	// the host keys of the instances of the benchmark
+	KnownHostsPath string
+}
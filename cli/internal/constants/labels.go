@@ -7,5 +7,23 @@ const (
 )
 
 const (
-	BenchIDTag = "bench-id"
+	BenchIDTag     = "bench-id"
+	ManagedByTag   = "managed-by"
+	ManagedByValue = "benchmark-cli"
+)
+
+const (
+	// when the resource was created, in RFC 3339, the reaper finds the old
+	// resources with it
+	CreatedAtTag = "created-at"
+	// when the instance powers itself off, in RFC 3339
+	ExpiresAtTag = "expires-at"
+	// set on the images while they are built, an image still tagged was
+	// left by a failed build
+	ImageBuildTag          = "image-build"
+	ImageBuildPendingValue = "pending"
+	// the commit of the install scripts an image was built from
+	GitCommitTag = "git-commit"
+	// llm or bench, the role of the instances launched from an image
+	ImageRoleTag = "image-role"
 )
@@ -0,0 +1,164 @@
+package engines
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed vllm/*.json
+var specs embed.FS
+
+// DefaultVersions is the version of each engine installed on the images built
+// by the instance-builder, used when the config does not set one
+var DefaultVersions = map[string]string{
+	"vllm": "0.8.5.post1",
+}
+
+// ErrUnknownVersion is returned when there is no flag spec for the engine version
+var ErrUnknownVersion = errors.New("no flag spec for this engine version")
+
+// Arg type names used in the specs
+const (
+	String = "string"
+	Int    = "int"
+	Float  = "float"
+	Bool   = "bool"
+)
+
+// Arg is a command line argument of an inference engine
+type Arg struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Choices    []string `json:"choices,omitempty"`
+	Help       string   `json:"help,omitempty"`
+	Deprecated bool     `json:"deprecated,omitempty"`
+	// a bool that also has a --no-<name> form, its default may be true
+	Negatable bool `json:"negatable,omitempty"`
+}
+
+// Spec lists the arguments accepted by a version of an inference engine
+type Spec struct {
+	Engine  string `json:"engine"`
+	Version string `json:"version"`
+	Args    []Arg  `json:"args"`
+}
+
+// Arg returns the argument with the given name, nil if the version does not support it
+func (s *Spec) Arg(name string) *Arg {
+	for i := range s.Args {
+		if s.Args[i].Name == name {
+			return &s.Args[i]
+		}
+	}
+
+	return nil
+}
+
+// MarshalIndent encodes the spec in the format of the argument dumps
+func (s *Spec) MarshalIndent() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// ParseDump parses a JSON argument dump
+func ParseDump(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse the argument dump: %v", err)
+	}
+
+	if spec.Engine == "" || spec.Version == "" {
+		return nil, errors.New("the argument dump must set the engine and the version")
+	}
+
+	for i, arg := range spec.Args {
+		switch arg.Type {
+		case String, Int, Float, Bool:
+		default:
+			return nil, fmt.Errorf("unsupported type %q for argument %s", arg.Type, arg.Name)
+		}
+
+		spec.Args[i].Name = strings.TrimPrefix(arg.Name, "--")
+	}
+
+	return &spec, nil
+}
+
+// Load returns the embedded spec of the engine version
+func Load(engine string, version string) (*Spec, error) {
+	data, err := specs.ReadFile(path.Join(engine, version+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrUnknownVersion, engine, version)
+	}
+
+	return ParseDump(data)
+}
+
+// Versions returns the versions of the engine having an embedded spec, oldest first
+func Versions(engine string) []string {
+	entries, err := specs.ReadDir(engine)
+	if err != nil {
+		return nil
+	}
+
+	versions := []string{}
+	for _, entry := range entries {
+		versions = append(versions, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+
+	SortVersions(versions)
+	return versions
+}
+
+// SortVersions sorts versions like 0.7.3 or 0.8.5.post1, oldest first
+func SortVersions(versions []string) {
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+}
+
+func compareVersions(a string, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+
+		if x != y {
+			return x - y
+		}
+	}
+
+	return 0
+}
+
+// versionParts splits a version in numbers, 0.8.5.post1 gives [0 8 5 1]
+func versionParts(version string) []int {
+	parts := []int{}
+
+	for _, part := range strings.Split(strings.TrimPrefix(version, "v"), ".") {
+		part = strings.TrimLeft(part, "abcdefghijklmnopqrstuvwxyz")
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			n = 0
+		}
+		parts = append(parts, n)
+	}
+
+	return parts
+}
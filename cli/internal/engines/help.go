@@ -0,0 +1,143 @@
+package engines
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	optionLine   = regexp.MustCompile(`^\s{1,4}-`)
+	defaultValue = regexp.MustCompile(`\(default: ([^)]*)\)`)
+	columns      = regexp.MustCompile(`\s{2,}`)
+)
+
+// ParseHelp builds a spec from the --help output of an argparse based engine.
+// The help does not carry the argument types so they are guessed from the
+// choices and the default values, a JSON dump should be preferred when the
+// engine can be imported.
+func ParseHelp(engine string, version string, r io.Reader) (*Spec, error) {
+	spec := &Spec{Engine: engine, Version: version, Args: []Arg{}}
+
+	var current *Arg
+	var metavar string
+	help := []string{}
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+
+		current.Help = strings.Join(help, " ")
+		current.Deprecated = strings.Contains(strings.ToLower(current.Help), "deprecated")
+		current.Type = guessType(metavar, current.Choices, current.Help)
+
+		spec.Args = append(spec.Args, *current)
+		current, metavar, help = nil, "", []string{}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if optionLine.MatchString(line) {
+			flush()
+
+			fields := columns.Split(strings.TrimSpace(line), 2)
+			name, mv, negatable, ok := parseInvocation(fields[0])
+			if !ok {
+				continue
+			}
+
+			current = &Arg{Name: name, Negatable: negatable}
+			metavar = mv
+			if strings.HasPrefix(mv, "{") {
+				current.Choices = strings.Split(strings.Trim(mv, "{}"), ",")
+			}
+
+			if len(fields) > 1 {
+				help = append(help, fields[1])
+			}
+			continue
+		}
+
+		// continuation of the description of the current option
+		if current != nil && strings.HasPrefix(line, "    ") {
+			help = append(help, strings.TrimSpace(line))
+			continue
+		}
+
+		flush()
+	}
+
+	flush()
+
+	return spec, scanner.Err()
+}
+
+// parseInvocation returns the long name and the metavar of an option from
+// its invocation, e.g. "--dtype {auto,half}" or "--enable-x, --no-enable-x",
+// and whether it has a --no- form
+func parseInvocation(invocation string) (string, string, bool, bool) {
+	alternatives := strings.Split(invocation, ", ")
+
+	for _, alternative := range alternatives {
+		parts := strings.SplitN(alternative, " ", 2)
+		if !strings.HasPrefix(parts[0], "--") || strings.HasPrefix(parts[0], "--no-") {
+			continue
+		}
+
+		name := strings.TrimPrefix(parts[0], "--")
+		if name == "help" {
+			return "", "", false, false
+		}
+
+		negatable := slices.Contains(alternatives, "--no-"+name)
+
+		if len(parts) == 1 {
+			return name, "", negatable, true
+		}
+		return name, parts[1], negatable, true
+	}
+
+	return "", "", false, false
+}
+
+func guessType(metavar string, choices []string, help string) string {
+	if metavar == "" {
+		return Bool
+	}
+
+	values := choices
+	if len(values) == 0 {
+		if match := defaultValue.FindStringSubmatch(help); match != nil {
+			values = []string{match[1]}
+		}
+	}
+
+	if len(values) == 0 {
+		return String
+	}
+
+	if all(values, func(v string) bool { _, err := strconv.Atoi(v); return err == nil }) {
+		return Int
+	}
+
+	if all(values, func(v string) bool { _, err := strconv.ParseFloat(v, 64); return err == nil }) {
+		return Float
+	}
+
+	return String
+}
+
+func all(values []string, f func(string) bool) bool {
+	for _, v := range values {
+		if !f(v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Format is the output format of an export
+type Format string
+
+const (
+	CSV     Format = "csv"
+	Parquet Format = "parquet"
+	JSONL   Format = "jsonl"
+)
+
+// Formats lists the supported export formats
+var Formats = []Format{CSV, Parquet, JSONL}
+
+// ParseFormat returns the format matching the name
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported format %q, expected one of %v", name, Formats)
+}
+
+// Write writes the rows to w in the given format. The rows must be flat
+// structs, the columns are named after their json tags.
+func Write[T any](w io.Writer, format Format, rows []T) error {
+	switch format {
+	case CSV:
+		return writeCSV(w, rows)
+	case Parquet:
+		return parquet.Write(w, rows)
+	case JSONL:
+		return writeJSONL(w, rows)
+	}
+
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+func writeJSONL[T any](w io.Writer, rows []T) error {
+	encoder := json.NewEncoder(w)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeCSV[T any](w io.Writer, rows []T) error {
+	writer := csv.NewWriter(w)
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	header := make([]string, t.NumField())
+	for i := range header {
+		header[i] = strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+	}
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(header))
+	for _, row := range rows {
+		v := reflect.ValueOf(row)
+		for i := range record {
+			record[i] = formatValue(v.Field(i))
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float64, reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+
+	return fmt.Sprint(v.Interface())
+}
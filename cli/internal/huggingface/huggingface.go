@@ -0,0 +1,160 @@
+package huggingface
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// BASE_URL of the Hugging Face Hub
+var BASE_URL = "https://huggingface.co"
+
+// ErrInvalidToken is returned when the Hub rejects the token
+var ErrInvalidToken = errors.New("the Hugging Face token is not valid")
+
+// ErrNoAccess is returned when the token cannot download a gated model
+var ErrNoAccess = errors.New("the Hugging Face token has no access to the model")
+
+// ErrModelNotFound is returned when the model does not exist or is private
+var ErrModelNotFound = errors.New("the model does not exist on the Hugging Face Hub")
+
+// Client of the Hugging Face Hub API, authenticated with the token of the
+// benchmark
+type Client struct {
+	Token      string
+	httpClient *http.Client
+}
+
+func NewClient(token string) *Client {
+	return &Client{
+		Token: token,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			// the files are redirected to the CDN, the status of the Hub is enough
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// User is the owner of the token
+type User struct {
+	Name string `json:"name"`
+	Auth struct {
+		AccessToken struct {
+			DisplayName string `json:"displayName"`
+			Role        string `json:"role"`
+		} `json:"accessToken"`
+	} `json:"auth"`
+}
+
+// Model is the part of the model info the benchmark reads. Gated is false,
+// "auto" or "manual".
+type Model struct {
+	ID      string `json:"id"`
+	Gated   any    `json:"gated"`
+	Private bool   `json:"private"`
+}
+
+func (m *Model) IsGated() bool {
+	gated, ok := m.Gated.(bool)
+	return !ok || gated
+}
+
+// WhoAmI returns the owner of the token
+func (c *Client) WhoAmI() (*User, error) {
+	resp, err := c.do(http.MethodGet, "/api/whoami-v2")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrInvalidToken
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from the Hugging Face Hub: %d", resp.StatusCode)
+	}
+
+	user := &User{}
+	if err := json.NewDecoder(resp.Body).Decode(user); err != nil {
+		return nil, fmt.Errorf("cannot decode the Hugging Face user: %w", err)
+	}
+
+	return user, nil
+}
+
+// CheckModelAccess checks that the token can download the config of the model
+// at the revision, the model info is readable without the access to a gated
+// model but its files are not
+func (c *Client) CheckModelAccess(model string, revision string) (*Model, error) {
+	resp, err := c.do(http.MethodGet, "/api/models/"+model)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusNotFound:
+		// the Hub answers 401 for the private models the token cannot read
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, model)
+	default:
+		return nil, fmt.Errorf("unexpected status code from the Hugging Face Hub: %d", resp.StatusCode)
+	}
+
+	info := &Model{}
+	if err := json.NewDecoder(resp.Body).Decode(info); err != nil {
+		return nil, fmt.Errorf("cannot decode the model %s: %w", model, err)
+	}
+
+	if revision == "" {
+		revision = "main"
+	}
+
+	resp, err = c.do(http.MethodHead, fmt.Sprintf("/%s/resolve/%s/config.json", model, url.PathEscape(revision)))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("%w %s, request the access on %s/%s", ErrNoAccess, model, BASE_URL, model)
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("the model %s has no config.json at the revision %s", model, revision)
+	case resp.StatusCode >= 400:
+		return nil, fmt.Errorf("unexpected status code from the Hugging Face Hub: %d", resp.StatusCode)
+	}
+
+	return info, nil
+}
+
+// IsLocal returns true when the model is a path on the instance rather than
+// a repository of the Hub
+func IsLocal(model string) bool {
+	return strings.HasPrefix(model, "/") || strings.HasPrefix(model, ".") || strings.Count(model, "/") > 1
+}
+
+func (c *Client) do(method string, path string) (*http.Response, error) {
+	request, err := http.NewRequest(method, BASE_URL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Token != "" {
+		request.Header.Add("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach the Hugging Face Hub: %w", err)
+	}
+
+	return resp, nil
+}
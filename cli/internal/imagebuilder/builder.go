@@ -0,0 +1,108 @@
+package imagebuilder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	log "github.com/heka-ai/benchmark-cli/internal/logs"
+	"github.com/heka-ai/benchmark-cli/internal/ssh"
+)
+
+var logger = log.GetLogger("image-builder")
+
+// Target is the instance the image is built on
+type Target interface {
+	// Stream runs the command and writes its output as it comes
+	Stream(ctx context.Context, command string, stdout io.Writer, stderr io.Writer) error
+	// Upload copies a local file or directory to the instance
+	Upload(localPath string, remotePath string) error
+}
+
+// Upload is a local file or directory copied to the instance
+type Upload struct {
+	Local  string
+	Remote string
+}
+
+// Step is a part of the build. The uploads are copied first, then the
+// commands run in order, a command that fails stops the build.
+type Step struct {
+	Name     string
+	Uploads  []Upload
+	Commands []string
+	// a failed step is run again, it must be idempotent
+	Retries int
+}
+
+// Builder runs the steps of an image on the instance
+type Builder struct {
+	Target Target
+	Steps  []Step
+}
+
+func NewBuilder(target Target, steps []Step) *Builder {
+	return &Builder{
+		Target: target,
+		Steps:  steps,
+	}
+}
+
+// Build runs the steps in order on the instance, each build starts from a
+// fresh instance
+func (b *Builder) Build(ctx context.Context) error {
+	for i, step := range b.Steps {
+		stepLogger := logger.With().Str("step", step.Name).Int("index", i+1).Int("steps", len(b.Steps)).Logger()
+
+		stepLogger.Info().Msg("Running the step")
+		start := time.Now()
+
+		var err error
+		for attempt := 0; ; attempt++ {
+			err = b.run(ctx, step)
+			if err == nil || attempt >= step.Retries || ctx.Err() != nil {
+				break
+			}
+
+			stepLogger.Warn().Err(err).Int("attempt", attempt+1).Msg("Step failed, retrying")
+		}
+
+		if err != nil {
+			return fmt.Errorf("step %s: %w", step.Name, err)
+		}
+
+		stepLogger.Info().Dur("duration", time.Since(start)).Msg("Step done")
+	}
+
+	return nil
+}
+
+func (b *Builder) run(ctx context.Context, step Step) error {
+	for _, upload := range step.Uploads {
+		logger.Info().Str("step", step.Name).Str("local", upload.Local).Str("remote", upload.Remote).Msg("Uploading")
+
+		if err := b.Target.Upload(upload.Local, upload.Remote); err != nil {
+			return fmt.Errorf("cannot upload %s: %w", upload.Local, err)
+		}
+	}
+
+	for _, command := range step.Commands {
+		stdout := ssh.NewLineWriter(func(line string) {
+			logger.Info().Str("step", step.Name).Str("stream", "stdout").Msg(line)
+		})
+		stderr := ssh.NewLineWriter(func(line string) {
+			logger.Info().Str("step", step.Name).Str("stream", "stderr").Msg(line)
+		})
+
+		err := b.Target.Stream(ctx, command, stdout, stderr)
+		stdout.Flush()
+		stderr.Flush()
+
+		if err != nil {
+			return fmt.Errorf("%q failed: %w", command, err)
+		}
+	}
+
+	return nil
+}
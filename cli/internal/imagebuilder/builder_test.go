@@ -0,0 +1,187 @@
+package imagebuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeTarget records the uploads and the commands, the commands of fail
+// fail the number of times given
+type fakeTarget struct {
+	ops       []string
+	fail      map[string]int
+	uploadErr error
+	// called after each command, e.g. to cancel the build
+	onCommand func(command string)
+}
+
+func (t *fakeTarget) Stream(ctx context.Context, command string, stdout io.Writer, stderr io.Writer) error {
+	t.ops = append(t.ops, "run "+command)
+	if t.onCommand != nil {
+		defer t.onCommand(command)
+	}
+
+	if t.fail[command] > 0 {
+		t.fail[command]--
+		fmt.Fprintln(stderr, "failed")
+		return errors.New("exit status 1")
+	}
+
+	fmt.Fprintln(stdout, "ok")
+	return nil
+}
+
+func (t *fakeTarget) Upload(localPath string, remotePath string) error {
+	t.ops = append(t.ops, "upload "+localPath+" "+remotePath)
+	return t.uploadErr
+}
+
+func TestBuildRunsTheSteps(t *testing.T) {
+	target := &fakeTarget{}
+	steps := []Step{
+		{Name: "one", Uploads: []Upload{{Local: "a", Remote: "/a"}, {Local: "b", Remote: "/b"}}, Commands: []string{"ls /a", "ls /b"}},
+		{Name: "two", Commands: []string{"install"}},
+	}
+
+	if err := NewBuilder(target, steps).Build(context.Background()); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	want := []string{"upload a /a", "upload b /b", "run ls /a", "run ls /b", "run install"}
+	if !slices.Equal(target.ops, want) {
+		t.Errorf("ops = %q, want %q", target.ops, want)
+	}
+}
+
+func TestBuildStopsOnFailure(t *testing.T) {
+	target := &fakeTarget{fail: map[string]int{"install": 1}}
+	steps := []Step{
+		{Name: "one", Commands: []string{"install", "check"}},
+		{Name: "two", Commands: []string{"cleanup"}},
+	}
+
+	err := NewBuilder(target, steps).Build(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "step one") {
+		t.Fatalf("Build() error = %v, want the error of the step one", err)
+	}
+
+	want := []string{"run install"}
+	if !slices.Equal(target.ops, want) {
+		t.Errorf("ops = %q, want %q", target.ops, want)
+	}
+}
+
+func TestBuildStopsOnUploadFailure(t *testing.T) {
+	target := &fakeTarget{uploadErr: errors.New("connection lost")}
+	steps := []Step{
+		{Name: "one", Uploads: []Upload{{Local: "a", Remote: "/a"}}, Commands: []string{"ls /a"}},
+	}
+
+	err := NewBuilder(target, steps).Build(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "cannot upload a") {
+		t.Fatalf("Build() error = %v, want the upload error", err)
+	}
+
+	want := []string{"upload a /a"}
+	if !slices.Equal(target.ops, want) {
+		t.Errorf("ops = %q, want %q", target.ops, want)
+	}
+}
+
+func TestBuildRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		retries  int
+		failures int
+		attempts int
+		wantErr  bool
+	}{
+		{name: "no retry", retries: 0, failures: 1, attempts: 1, wantErr: true},
+		{name: "success after a retry", retries: 2, failures: 1, attempts: 2},
+		{name: "success on the last retry", retries: 2, failures: 2, attempts: 3},
+		{name: "out of retries", retries: 2, failures: 3, attempts: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &fakeTarget{fail: map[string]int{"download": tt.failures}}
+			steps := []Step{{Name: "download", Commands: []string{"download"}, Retries: tt.retries}}
+
+			err := NewBuilder(target, steps).Build(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Build() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if len(target.ops) != tt.attempts {
+				t.Errorf("got %d attempts, want %d", len(target.ops), tt.attempts)
+			}
+		})
+	}
+}
+
+func TestBuildDoesNotRetryWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	target := &fakeTarget{
+		fail:      map[string]int{"download": 1},
+		onCommand: func(string) { cancel() },
+	}
+	steps := []Step{
+		{Name: "download", Commands: []string{"download"}, Retries: 2},
+		{Name: "install", Commands: []string{"install"}},
+	}
+
+	if err := NewBuilder(target, steps).Build(ctx); err == nil {
+		t.Fatalf("Build() error = nil, want the error of the cancelled step")
+	}
+
+	want := []string{"run download"}
+	if !slices.Equal(target.ops, want) {
+		t.Errorf("ops = %q, want %q", target.ops, want)
+	}
+}
+
+func TestRecipe(t *testing.T) {
+	artifacts := t.TempDir()
+	for _, script := range []string{"api/install.sh", "gpu/install.sh"} {
+		path := filepath.Join(artifacts, script)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("#!/bin/bash\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps, err := Recipe(Options{Role: RoleLLM, ArtifactsDir: artifacts, APIBinary: "api"})
+	if err != nil {
+		t.Fatalf("Recipe() error = %v", err)
+	}
+
+	names := []string{}
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+
+	want := []string{"upload-artifacts", "upload-api", "install-api", "install-llm", "check-api", "cleanup"}
+	if !slices.Equal(names, want) {
+		t.Errorf("steps = %q, want %q", names, want)
+	}
+
+	// the cpu scripts are missing
+	if _, err := Recipe(Options{Role: RoleBench, ArtifactsDir: artifacts}); err == nil {
+		t.Errorf("Recipe() of the bench role error = nil, want the missing install script")
+	}
+
+	if _, err := Recipe(Options{Role: "gpu", ArtifactsDir: artifacts}); err == nil {
+		t.Errorf("Recipe() of an unknown role error = nil, want an error")
+	}
+}
@@ -0,0 +1,149 @@
+package imagebuilder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+// Roles of the images
+const (
+	RoleLLM   = "llm"
+	RoleBench = "bench"
+)
+
+// ArtifactsPath is where the install scripts are in the repository
+const ArtifactsPath = "instance-builder/aws/ec2"
+
+// where the install scripts are uploaded, the API and its service expect them there
+const remoteArtifacts = "/home/ubuntu/ec2"
+const remoteAPI = "/home/ubuntu/api"
+
+// Options of the build of an image
+type Options struct {
+	// llm or bench
+	Role string
+	// local directory holding the api, gpu and cpu install scripts
+	ArtifactsDir string
+	// local build of the API, for linux/amd64. The latest release is
+	// downloaded when empty.
+	APIBinary string
+}
+
+// Recipe returns the steps building the image of the role
+func Recipe(options Options) ([]Step, error) {
+	installDir := ""
+	switch options.Role {
+	case RoleLLM:
+		installDir = "gpu"
+	case RoleBench:
+		installDir = "cpu"
+	default:
+		return nil, fmt.Errorf("unknown image role %q, must be %s or %s", options.Role, RoleLLM, RoleBench)
+	}
+
+	for _, script := range []string{"api/install.sh", installDir + "/install.sh"} {
+		if _, err := os.Stat(filepath.Join(options.ArtifactsDir, script)); err != nil {
+			return nil, fmt.Errorf("%s is not in the artifacts directory %s: %w", script, options.ArtifactsDir, err)
+		}
+	}
+
+	steps := []Step{
+		{
+			Name:     "upload-artifacts",
+			Uploads:  []Upload{{Local: options.ArtifactsDir, Remote: remoteArtifacts}},
+			Commands: []string{"ls -la " + remoteArtifacts},
+			Retries:  2,
+		},
+	}
+
+	if options.APIBinary != "" {
+		steps = append(steps, Step{
+			Name:     "upload-api",
+			Uploads:  []Upload{{Local: options.APIBinary, Remote: remoteAPI}},
+			Commands: []string{"chmod +x " + remoteAPI},
+			Retries:  2,
+		})
+	}
+
+	steps = append(steps,
+		Step{
+			Name:     "install-api",
+			Commands: []string{"sudo bash " + remoteArtifacts + "/api/install.sh"},
+		},
+		Step{
+			Name:     "install-" + options.Role,
+			Commands: []string{fmt.Sprintf("cd %s/%s && sudo bash install.sh", remoteArtifacts, installDir)},
+		},
+		Step{
+			Name: "check-api",
+			Commands: []string{
+				"sudo systemctl is-enabled api.service",
+				"for i in $(seq 1 30); do curl -sf http://localhost:8001/health && exit 0; sleep 2; done; sudo journalctl -u api.service --no-pager -n 50; exit 1",
+			},
+		},
+		// the image must not carry the traces of the build
+		Step{
+			Name: "cleanup",
+			Commands: []string{
+				"sudo apt-get clean",
+				"rm -f ~/.bash_history",
+			},
+		},
+	)
+
+	return steps, nil
+}
+
+// FindArtifacts looks for the install scripts from the current directory up
+// to the root of the repository
+func FindArtifacts() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		candidate := filepath.Join(dir, ArtifactsPath)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("%s not found, run the command from the repository or set --artifacts", ArtifactsPath)
+		}
+		dir = parent
+	}
+}
+
+// GitCommit returns the commit of the repository holding dir, with a -dirty
+// suffix when it has local changes. The commit the CLI was built from is
+// used when dir is not in a repository.
+func GitCommit(dir string) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err == nil {
+		commit := strings.TrimSpace(string(out))
+
+		status, err := exec.Command("git", "-C", dir, "status", "--porcelain", "--", ".").Output()
+		if err == nil && len(strings.TrimSpace(string(status))) > 0 {
+			commit += "-dirty"
+		}
+
+		return commit, nil
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value, nil
+			}
+		}
+	}
+
+	return "", errors.New("cannot find the git commit of the artifacts")
+}
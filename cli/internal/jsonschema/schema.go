@@ -0,0 +1,249 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema used by the CLI
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 Types              `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Const                any                `json:"const,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Deprecated           bool               `json:"deprecated,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	If                   *Schema            `json:"if,omitempty"`
+	Then                 *Schema            `json:"then,omitempty"`
+}
+
+// Types is the type keyword, a single type or a list of types
+type Types []string
+
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// Error is a value that does not match the schema
+type Error struct {
+	// JSON path of the value, e.g. $.itls[3][0]
+	Path    string
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Parse reads a schema
+func Parse(data []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// Validate checks the decoded JSON value (as produced by encoding/json into an any) against the schema
+func (s *Schema) Validate(value any) []Error {
+	return s.validate("$", value)
+}
+
+func (s *Schema) validate(path string, value any) []Error {
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		return []Error{{Path: path, Message: fmt.Sprintf("expected %s, got %s", strings.Join(s.Type, " or "), typeOf(value))}}
+	}
+
+	errs := []Error{}
+
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		allowed := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			if e == nil {
+				allowed[i] = "null"
+				continue
+			}
+			allowed[i] = fmt.Sprint(e)
+		}
+		errs = append(errs, Error{Path: path, Message: fmt.Sprintf("must be one of %s, got %v", strings.Join(allowed, ", "), value)})
+	}
+
+	if s.Const != nil && fmt.Sprint(s.Const) != fmt.Sprint(value) {
+		errs = append(errs, Error{Path: path, Message: fmt.Sprintf("must be %v, got %v", s.Const, value)})
+	}
+
+	for _, sub := range s.AllOf {
+		errs = append(errs, sub.validate(path, value)...)
+	}
+
+	if len(s.AnyOf) > 0 {
+		errs = append(errs, s.validateAnyOf(path, value)...)
+	}
+
+	// the format is an annotation, it is not checked
+	if s.If != nil && s.Then != nil && len(s.If.validate(path, value)) == 0 {
+		errs = append(errs, s.Then.validate(path, value)...)
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			errs = append(errs, Error{Path: path, Message: fmt.Sprintf("must be >= %v, got %v", *s.Minimum, v)})
+		}
+	case map[string]any:
+		errs = append(errs, s.validateObject(path, v)...)
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				errs = append(errs, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateAnyOf reports the first error of each alternative when none matches
+func (s *Schema) validateAnyOf(path string, value any) []Error {
+	failures := []string{}
+
+	for _, sub := range s.AnyOf {
+		errs := sub.validate(path, value)
+		if len(errs) == 0 {
+			return nil
+		}
+		failures = append(failures, errs[0].Error())
+	}
+
+	return []Error{{Path: path, Message: "must match one of: " + strings.Join(failures, " or ")}}
+}
+
+func (s *Schema) validateObject(path string, object map[string]any) []Error {
+	errs := []Error{}
+
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			errs = append(errs, Error{Path: path + "." + name, Message: "is required"})
+		}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		property, ok := s.Properties[key]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				errs = append(errs, Error{Path: path + "." + key, Message: "is not allowed"})
+			}
+			continue
+		}
+
+		errs = append(errs, property.validate(path+"."+key, object[key])...)
+	}
+
+	return errs
+}
+
+func (s *Schema) matchesType(value any) bool {
+	for _, t := range s.Type {
+		switch t {
+		case "null":
+			if value == nil {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := value.(float64); ok {
+				return true
+			}
+		case "integer":
+			if f, ok := value.(float64); ok && f == float64(int64(f)) {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]any); ok {
+				return true
+			}
+		case "object":
+			if _, ok := value.(map[string]any); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (s *Schema) inEnum(value any) bool {
+	for _, e := range s.Enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,126 @@
+package pricing
+
+import (
+	"time"
+)
+
+// Instance describes the price and the average power draw of an instance type
+type Instance struct {
+	// on-demand price in USD per hour (us-east-1, linux)
+	HourlyUSD float64
+	// average power draw of the instance under load, in watts
+	Watts float64
+}
+
+// Prices of the instance types the benchmark is usually run on.
+// The power draw is an estimate based on the GPU TDP plus the host share.
+var instances = map[string]Instance{
+	// bench instances
+	"t2.micro":   {HourlyUSD: 0.0116, Watts: 5},
+	"t3.micro":   {HourlyUSD: 0.0104, Watts: 5},
+	"t3.small":   {HourlyUSD: 0.0208, Watts: 7},
+	"t3.medium":  {HourlyUSD: 0.0416, Watts: 10},
+	"t3.large":   {HourlyUSD: 0.0832, Watts: 15},
+	"c5.large":   {HourlyUSD: 0.085, Watts: 20},
+	"c5.xlarge":  {HourlyUSD: 0.17, Watts: 35},
+	"c5.2xlarge": {HourlyUSD: 0.34, Watts: 65},
+	"m5.large":   {HourlyUSD: 0.096, Watts: 20},
+	"m5.xlarge":  {HourlyUSD: 0.192, Watts: 35},
+
+	// llm instances
+	"g4dn.xlarge":   {HourlyUSD: 0.526, Watts: 120},
+	"g4dn.2xlarge":  {HourlyUSD: 0.752, Watts: 140},
+	"g4dn.12xlarge": {HourlyUSD: 3.912, Watts: 520},
+	"g5.xlarge":     {HourlyUSD: 1.006, Watts: 350},
+	"g5.2xlarge":    {HourlyUSD: 1.212, Watts: 370},
+	"g5.4xlarge":    {HourlyUSD: 1.624, Watts: 400},
+	"g5.12xlarge":   {HourlyUSD: 5.672, Watts: 1250},
+	"g5.48xlarge":   {HourlyUSD: 16.288, Watts: 2900},
+	"g6.xlarge":     {HourlyUSD: 0.805, Watts: 130},
+	"g6.2xlarge":    {HourlyUSD: 0.978, Watts: 150},
+	"g6.12xlarge":   {HourlyUSD: 4.602, Watts: 520},
+	"g6e.xlarge":    {HourlyUSD: 1.861, Watts: 400},
+	"g6e.2xlarge":   {HourlyUSD: 2.242, Watts: 420},
+	"p3.2xlarge":    {HourlyUSD: 3.06, Watts: 350},
+	"p4d.24xlarge":  {HourlyUSD: 32.773, Watts: 4000},
+	"p5.48xlarge":   {HourlyUSD: 98.32, Watts: 8000},
+}
+
+// Prices of the EBS volume types, in USD per GB-month (us-east-1)
+var volumes = map[string]float64{
+	"gp2":      0.10,
+	"gp3":      0.08,
+	"io1":      0.125,
+	"io2":      0.125,
+	"st1":      0.045,
+	"sc1":      0.015,
+	"standard": 0.05,
+}
+
+// AWS bills the volumes on a month of 730 hours
+const hoursPerMonth = 730
+
+// Carbon intensity of the electricity grid of each region, in gCO2eq/kWh
+var regions = map[string]float64{
+	"us-east-1":      379,
+	"us-east-2":      410,
+	"us-west-1":      220,
+	"us-west-2":      280,
+	"ca-central-1":   30,
+	"eu-west-1":      290,
+	"eu-west-2":      200,
+	"eu-west-3":      55,
+	"eu-central-1":   380,
+	"eu-north-1":     10,
+	"ap-northeast-1": 470,
+	"ap-southeast-1": 410,
+	"ap-southeast-2": 600,
+	"ap-south-1":     700,
+	"sa-east-1":      100,
+}
+
+// Power usage effectiveness applied on top of the instance power draw
+const pue = 1.135
+
+// GetInstance returns the price and power draw of an instance type
+func GetInstance(instanceType string) (Instance, bool) {
+	instance, ok := instances[instanceType]
+	return instance, ok
+}
+
+// Cost returns the estimated on-demand cost in USD of running the instance for the duration
+func Cost(instanceType string, duration time.Duration) (float64, bool) {
+	instance, ok := instances[instanceType]
+	if !ok {
+		return 0, false
+	}
+
+	return instance.HourlyUSD * duration.Hours(), true
+}
+
+// VolumeCost returns the estimated cost in USD of a volume of sizeGB kept for the duration
+func VolumeCost(volumeType string, sizeGB int, duration time.Duration) (float64, bool) {
+	monthly, ok := volumes[volumeType]
+	if !ok {
+		return 0, false
+	}
+
+	return monthly * float64(sizeGB) * duration.Hours() / hoursPerMonth, true
+}
+
+// Carbon returns the estimated emissions in gCO2eq of running the instance in the region for the duration
+func Carbon(instanceType string, region string, duration time.Duration) (float64, bool) {
+	instance, ok := instances[instanceType]
+	if !ok {
+		return 0, false
+	}
+
+	intensity, ok := regions[region]
+	if !ok {
+		return 0, false
+	}
+
+	kWh := instance.Watts * pue * duration.Hours() / 1000
+
+	return kWh * intensity, true
+}
@@ -0,0 +1,166 @@
+package publish
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/heka-ai/benchmark-cli/internal/logs"
+)
+
+var logger = log.GetLogger("publish")
+
+const maxAttempts = 3
+
+// the wait before the second attempt, it grows with the attempts
+var retryBackoff = 2 * time.Second
+
+// ErrConflict is returned when different results were already published for the bench id
+var ErrConflict = errors.New("different results were already published for this bench id")
+
+// ErrUnauthorized is returned when the token is rejected
+var ErrUnauthorized = errors.New("the publish token was rejected")
+
+// This client follows the results ingestion API described in docs/api-reference/openapi.json
+type Client struct {
+	Endpoint string
+	Token    string
+	// the uploads are only logged, nothing is sent
+	DryRun     bool
+	httpClient *http.Client
+}
+
+// Publication is the answer of the API to an upload
+type Publication struct {
+	BenchID string `json:"bench_id"`
+	Digest  string `json:"digest"`
+	Created bool   `json:"created"`
+	URL     string `json:"url"`
+}
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Details []struct {
+		Path    string `json:"path"`
+		Message string `json:"message"`
+	} `json:"details"`
+}
+
+func NewClient(endpoint string, token string) *Client {
+	return &Client{
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+		Token:      token,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Digest returns the sha256 of the payload, used to make the uploads idempotent
+func Digest(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// IdempotencyKey returns the key identifying an upload of the payload for the bench id
+func IdempotencyKey(benchID string, payload []byte) string {
+	return benchID + ":" + Digest(payload)
+}
+
+// Publish uploads the results of the bench id. Uploads are idempotent so
+// they are retried on network errors, server errors and rate limits.
+func (c *Client) Publish(benchID string, payload []byte) (*Publication, error) {
+	if c.DryRun {
+		logger.Info().
+			Str("endpoint", c.Endpoint).
+			Str("bench_id", benchID).
+			Str("idempotency_key", IdempotencyKey(benchID, payload)).
+			Int("bytes", len(payload)).
+			Bool("token", c.Token != "").
+			Msg("Dry run, the results are valid and were not uploaded")
+
+		return &Publication{BenchID: benchID, Digest: Digest(payload)}, nil
+	}
+
+	var err error
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var publication *Publication
+		var retry bool
+
+		publication, retry, err = c.publish(benchID, payload)
+		if err == nil {
+			return publication, nil
+		}
+
+		if !retry || attempt == maxAttempts {
+			break
+		}
+
+		logger.Warn().Err(err).Int("attempt", attempt).Msg("Upload failed, retrying")
+		time.Sleep(time.Duration(attempt) * retryBackoff)
+	}
+
+	return nil, err
+}
+
+func (c *Client) publish(benchID string, payload []byte) (*Publication, bool, error) {
+	request, err := http.NewRequest("PUT", fmt.Sprintf("%s/v1/results/%s", c.Endpoint, url.PathEscape(benchID)), bytes.NewReader(payload))
+	if err != nil {
+		return nil, false, err
+	}
+
+	request.Header.Add("Authorization", "Bearer "+c.Token)
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("Idempotency-Key", IdempotencyKey(benchID, payload))
+
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, true, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		var publication Publication
+		if err := json.Unmarshal(body, &publication); err != nil {
+			return nil, false, fmt.Errorf("failed to parse publish response: %v", err)
+		}
+		return &publication, false, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, false, ErrUnauthorized
+	case http.StatusConflict:
+		return nil, false, ErrConflict
+	}
+
+	err = fmt.Errorf("failed to publish: %s", resp.Status)
+
+	var apiErr apiError
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+		details := []string{}
+		for _, d := range apiErr.Details {
+			details = append(details, fmt.Sprintf("%s: %s", d.Path, d.Message))
+		}
+
+		err = fmt.Errorf("failed to publish: %s: %s", resp.Status, apiErr.Message)
+		if len(details) > 0 {
+			err = fmt.Errorf("%w (%s)", err, strings.Join(details, "; "))
+		}
+	}
+
+	// the rate limit and the server errors are transient
+	return nil, resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
@@ -0,0 +1,149 @@
+package publish
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+var payload = []byte(`{"benchmark_id":"bench-1"}`)
+
+// server answers the uploads with the statuses in order, the last one is
+// repeated, and counts the requests
+func server(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	retryBackoff = 0
+	requests := &atomic.Int32{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		status := statuses[min(n, len(statuses))-1]
+
+		w.WriteHeader(status)
+		if status == http.StatusCreated {
+			json.NewEncoder(w).Encode(Publication{BenchID: "bench-1", Digest: Digest(payload), Created: true})
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, requests
+}
+
+func TestPublishHeaders(t *testing.T) {
+	retryBackoff = 0
+
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Publication{BenchID: "bench-1", Created: true})
+	}))
+	defer srv.Close()
+
+	publication, err := NewClient(srv.URL+"/", "secret").Publish("bench-1", payload)
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	if !publication.Created {
+		t.Errorf("Created = false, want true")
+	}
+	if got.Method != http.MethodPut || got.URL.Path != "/v1/results/bench-1" {
+		t.Errorf("request = %s %s, want PUT /v1/results/bench-1", got.Method, got.URL.Path)
+	}
+	if auth := got.Header.Get("Authorization"); auth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", auth, "Bearer secret")
+	}
+	if key := got.Header.Get("Idempotency-Key"); key != IdempotencyKey("bench-1", payload) {
+		t.Errorf("Idempotency-Key = %q, want %q", key, IdempotencyKey("bench-1", payload))
+	}
+	if string(body) != string(payload) {
+		t.Errorf("body = %s, want %s", body, payload)
+	}
+}
+
+func TestPublishIdempotencyKeyIsStable(t *testing.T) {
+	keys := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	retryBackoff = 0
+	NewClient(srv.URL, "secret").Publish("bench-1", payload)
+
+	if len(keys) != maxAttempts {
+		t.Fatalf("got %d requests, want %d", len(keys), maxAttempts)
+	}
+	for _, key := range keys {
+		if key != keys[0] {
+			t.Errorf("the retries use the keys %v, want the same key", keys)
+		}
+	}
+}
+
+func TestPublishRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		requests int32
+		wantErr  error
+	}{
+		{name: "server error then success", statuses: []int{http.StatusBadGateway, http.StatusCreated}, requests: 2},
+		{name: "rate limited then success", statuses: []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusCreated}, requests: 3},
+		{name: "server error on every attempt", statuses: []int{http.StatusInternalServerError}, requests: maxAttempts},
+		{name: "bad request", statuses: []int{http.StatusBadRequest, http.StatusCreated}, requests: 1},
+		{name: "unauthorized", statuses: []int{http.StatusUnauthorized, http.StatusCreated}, requests: 1, wantErr: ErrUnauthorized},
+		{name: "conflict", statuses: []int{http.StatusConflict, http.StatusCreated}, requests: 1, wantErr: ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := server(t, tt.statuses...)
+
+			_, err := NewClient(srv.URL, "secret").Publish("bench-1", payload)
+
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("got %d requests, want %d", got, tt.requests)
+			}
+
+			wantSuccess := tt.statuses[min(int(tt.requests), len(tt.statuses))-1] == http.StatusCreated
+			switch {
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("Publish() error = %v, want %v", err, tt.wantErr)
+			case wantSuccess && err != nil:
+				t.Errorf("Publish() error = %v, want nil", err)
+			case !wantSuccess && err == nil:
+				t.Errorf("Publish() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestPublishDryRun(t *testing.T) {
+	srv, requests := server(t, http.StatusCreated)
+
+	client := NewClient(srv.URL, "secret")
+	client.DryRun = true
+
+	publication, err := client.Publish("bench-1", payload)
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	if got := requests.Load(); got != 0 {
+		t.Errorf("got %d requests on a dry run, want 0", got)
+	}
+	if publication.Created || publication.Digest != Digest(payload) {
+		t.Errorf("publication = %+v, want the digest and not created", publication)
+	}
+}
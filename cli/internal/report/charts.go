@@ -0,0 +1,195 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	chartWidth   = 640
+	chartHeight  = 320
+	chartPadding = 48
+	maxCDFPoints = 200
+	histogramBin = 30
+)
+
+var palette = []string{"#2563eb", "#dc2626", "#16a34a", "#d97706", "#7c3aed", "#0891b2", "#db2777", "#4b5563"}
+
+type point struct {
+	X float64
+	Y float64
+}
+
+type series struct {
+	Name   string
+	Points []point
+}
+
+// cdf returns the empirical cumulative distribution of the values, downsampled to maxCDFPoints
+func cdf(values []float64) []point {
+	if len(values) == 0 {
+		return nil
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	step := 1
+	if len(sorted) > maxCDFPoints {
+		step = len(sorted) / maxCDFPoints
+	}
+
+	points := []point{}
+	for i := 0; i < len(sorted); i += step {
+		points = append(points, point{X: sorted[i], Y: float64(i+1) / float64(len(sorted))})
+	}
+
+	last := len(sorted) - 1
+	if points[len(points)-1].X != sorted[last] || points[len(points)-1].Y != 1 {
+		points = append(points, point{X: sorted[last], Y: 1})
+	}
+
+	return points
+}
+
+// histogram returns the number of values in each of the bins between 0 and max
+func histogram(values []int, max int) []point {
+	if len(values) == 0 || max <= 0 {
+		return nil
+	}
+
+	width := math.Ceil(float64(max+1) / histogramBin)
+	counts := make([]float64, histogramBin)
+
+	for _, v := range values {
+		bin := int(float64(v) / width)
+		if bin >= histogramBin {
+			bin = histogramBin - 1
+		}
+		counts[bin]++
+	}
+
+	points := make([]point, histogramBin)
+	for i, c := range counts {
+		points[i] = point{X: float64(i) * width, Y: c}
+	}
+
+	return points
+}
+
+func bounds(all []series) (float64, float64) {
+	maxX, maxY := 0.0, 0.0
+	for _, s := range all {
+		for _, p := range s.Points {
+			maxX = math.Max(maxX, p.X)
+			maxY = math.Max(maxY, p.Y)
+		}
+	}
+
+	if maxX == 0 {
+		maxX = 1
+	}
+	if maxY == 0 {
+		maxY = 1
+	}
+
+	return maxX, maxY
+}
+
+func scale(p point, maxX, maxY float64) (float64, float64) {
+	x := chartPadding + p.X/maxX*(chartWidth-2*chartPadding)
+	y := chartHeight - chartPadding - p.Y/maxY*(chartHeight-2*chartPadding)
+	return x, y
+}
+
+func axes(b *strings.Builder, maxX, maxY float64, xLabel, yLabel string, yFormat string) {
+	bottom := chartHeight - chartPadding
+	right := chartWidth - chartPadding
+
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#9ca3af"/>`, chartPadding, bottom, right, bottom)
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#9ca3af"/>`, chartPadding, chartPadding, chartPadding, bottom)
+
+	for i := 0; i <= 4; i++ {
+		ratio := float64(i) / 4
+		x := chartPadding + ratio*(chartWidth-2*chartPadding)
+		y := float64(bottom) - ratio*(chartHeight-2*chartPadding)
+
+		fmt.Fprintf(b, `<text x="%.1f" y="%d" font-size="10" text-anchor="middle" fill="#4b5563">%.0f</text>`, x, bottom+14, ratio*maxX)
+		fmt.Fprintf(b, `<text x="%d" y="%.1f" font-size="10" text-anchor="end" fill="#4b5563">`+yFormat+`</text>`, chartPadding-4, y+3, ratio*maxY)
+		fmt.Fprintf(b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#e5e7eb"/>`, chartPadding, y, right, y)
+	}
+
+	fmt.Fprintf(b, `<text x="%d" y="%d" font-size="11" text-anchor="middle" fill="#111827">%s</text>`, chartWidth/2, chartHeight-12, template.HTMLEscapeString(xLabel))
+	fmt.Fprintf(b, `<text x="14" y="%d" font-size="11" text-anchor="middle" fill="#111827" transform="rotate(-90 14 %d)">%s</text>`, chartHeight/2, chartHeight/2, template.HTMLEscapeString(yLabel))
+}
+
+func legend(b *strings.Builder, all []series) {
+	for i, s := range all {
+		y := chartPadding + i*14
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/>`, chartWidth-chartPadding-150, y-9, palette[i%len(palette)])
+		fmt.Fprintf(b, `<text x="%d" y="%d" font-size="10" fill="#111827">%s</text>`, chartWidth-chartPadding-136, y, template.HTMLEscapeString(s.Name))
+	}
+}
+
+// lineChart renders the series as an inline SVG line chart
+func lineChart(all []series, xLabel, yLabel string) template.HTML {
+	maxX, _ := bounds(all)
+	maxY := 1.0
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d">`, chartWidth, chartHeight, chartWidth, chartHeight)
+	axes(b, maxX, maxY, xLabel, yLabel, "%.2f")
+
+	for i, s := range all {
+		coords := make([]string, len(s.Points))
+		for j, p := range s.Points {
+			x, y := scale(p, maxX, maxY)
+			coords[j] = fmt.Sprintf("%.1f,%.1f", x, y)
+		}
+
+		fmt.Fprintf(b, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, palette[i%len(palette)], strings.Join(coords, " "))
+	}
+
+	legend(b, all)
+	b.WriteString(`</svg>`)
+
+	return template.HTML(b.String())
+}
+
+// barChart renders the series as an inline SVG histogram, series are overlaid
+func barChart(all []series, xLabel, yLabel string) template.HTML {
+	maxX, maxY := bounds(all)
+
+	// the last bin starts at maxX, leave room for its width
+	barWidth := 0.0
+	for _, s := range all {
+		if len(s.Points) > 1 {
+			barWidth = s.Points[1].X - s.Points[0].X
+			break
+		}
+	}
+	maxX += barWidth
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d">`, chartWidth, chartHeight, chartWidth, chartHeight)
+	axes(b, maxX, maxY, xLabel, yLabel, "%.0f")
+
+	for i, s := range all {
+		for _, p := range s.Points {
+			x, y := scale(p, maxX, maxY)
+			x2, _ := scale(point{X: p.X + barWidth}, maxX, maxY)
+			height := float64(chartHeight-chartPadding) - y
+
+			fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" fill-opacity="0.5"/>`, x, y, math.Max(x2-x-1, 1), height, palette[i%len(palette)])
+		}
+	}
+
+	legend(b, all)
+	b.WriteString(`</svg>`)
+
+	return template.HTML(b.String())
+}
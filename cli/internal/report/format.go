@@ -0,0 +1,21 @@
+package report
+
+import (
+	"strconv"
+)
+
+func maxInt(values *[]int) int {
+	if values == nil {
+		return 0
+	}
+
+	m := 0
+	for _, v := range *values {
+		m = max(m, v)
+	}
+	return m
+}
+
+func formatFloat(f float64, precision int) string {
+	return strconv.FormatFloat(f, 'f', precision, 64)
+}
@@ -0,0 +1,216 @@
+package report
+
+import (
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/heka-ai/benchmark-cli/internal/pricing"
+	"github.com/heka-ai/benchmark-cli/pkg/results"
+)
+
+//go:embed report.html.tmpl
+var reportTemplate string
+
+// Run is a benchmark run to include in the report
+type Run struct {
+	// name of the file the results were read from
+	Source  string
+	Results *results.Results
+}
+
+type runSummary struct {
+	Name                 string
+	Model                string
+	Date                 string
+	Completed            int
+	DurationS            float64
+	RequestThroughput    float64
+	OutputThroughput     float64
+	TotalTokenThroughput float64
+	MeanTtftMs           float64
+	MedianTtftMs         float64
+	P99TtftMs            float64
+	MeanItlMs            float64
+	MedianItlMs          float64
+	P99ItlMs             float64
+	Failed               int
+	Cost                 string
+	Carbon               string
+}
+
+// environmentSummary is where and how a run happened, read from its results
+// as the local config may describe another run
+type environmentSummary struct {
+	Name            string
+	Region          string
+	GPUInstanceType string
+	CPUInstanceType string
+	AddressMode     string
+	Backend         string
+	Model           string
+	Dataset         string
+	NumPrompts      int
+	RequestRate     string
+}
+
+type reportData struct {
+	Title        string
+	GeneratedAt  string
+	Runs         []runSummary
+	Environments []environmentSummary
+	TtftCDF      template.HTML
+	ItlCDF       template.HTML
+	InputHist    template.HTML
+	OutputHist   template.HTML
+	Dataset      template.JS
+}
+
+// Render writes a self-contained HTML report of the runs to w.
+// The full results are embedded in the page so the file can be shared as is.
+func Render(w io.Writer, title string, runs []Run) error {
+	tmpl, err := template.New("report").Parse(reportTemplate)
+	if err != nil {
+		return err
+	}
+
+	data := reportData{
+		Title:       title,
+		GeneratedAt: time.Now().Format(time.RFC1123),
+	}
+
+	ttfts := []series{}
+	itls := []series{}
+	inputs := []series{}
+	outputs := []series{}
+
+	maxInput, maxOutput := 0, 0
+	for _, run := range runs {
+		maxInput = max(maxInput, maxInt(run.Results.InputLens))
+		maxOutput = max(maxOutput, maxInt(run.Results.OutputLens))
+	}
+
+	dataset := map[string]*results.Results{}
+
+	for _, run := range runs {
+		name := runName(run)
+
+		data.Runs = append(data.Runs, summarizeRun(name, run.Results))
+		data.Environments = append(data.Environments, summarizeEnvironment(name, run.Results))
+		dataset[name] = run.Results
+
+		// the same requests as the summary, export and gate
+		samples := run.Results.SuccessfulSamples()
+		ttfts = append(ttfts, series{Name: name, Points: cdf(samples.Ttft)})
+		itls = append(itls, series{Name: name, Points: cdf(samples.Itl)})
+
+		if run.Results.InputLens != nil {
+			inputs = append(inputs, series{Name: name, Points: histogram(*run.Results.InputLens, maxInput)})
+		}
+
+		if run.Results.OutputLens != nil {
+			outputs = append(outputs, series{Name: name, Points: histogram(*run.Results.OutputLens, maxOutput)})
+		}
+	}
+
+	data.TtftCDF = lineChart(ttfts, "time to first token (ms)", "fraction of requests")
+	data.ItlCDF = lineChart(itls, "inter-token latency (ms)", "fraction of tokens")
+	data.InputHist = barChart(inputs, "input length (tokens)", "requests")
+	data.OutputHist = barChart(outputs, "output length (tokens)", "requests")
+
+	// json.Marshal escapes <, > and & so the dataset cannot close the script tag
+	raw, err := json.Marshal(dataset)
+	if err != nil {
+		return err
+	}
+	data.Dataset = template.JS(raw)
+
+	return tmpl.Execute(w, data)
+}
+
+func runName(run Run) string {
+	if run.Results.BenchmarkID != nil && *run.Results.BenchmarkID != "" {
+		return *run.Results.BenchmarkID
+	}
+
+	return strings.TrimSuffix(filepath.Base(run.Source), filepath.Ext(run.Source))
+}
+
+// summarizeRun reuses the summary of bench export, the latency metrics are
+// computed from the successful requests
+func summarizeRun(name string, r *results.Results) runSummary {
+	row := r.Summary()
+
+	summary := runSummary{
+		Name:                 name,
+		Model:                row.ModelID,
+		Date:                 row.Date,
+		Completed:            row.Completed,
+		DurationS:            row.DurationS,
+		RequestThroughput:    row.RequestThroughput,
+		OutputThroughput:     row.OutputThroughput,
+		TotalTokenThroughput: row.TotalTokenThroughput,
+		MeanTtftMs:           row.MeanTtftMs,
+		MedianTtftMs:         row.MedianTtftMs,
+		P99TtftMs:            row.P99TtftMs,
+		MeanItlMs:            row.MeanItlMs,
+		MedianItlMs:          row.MedianItlMs,
+		P99ItlMs:             row.P99ItlMs,
+		Failed:               row.Failed,
+		Cost:                 "n/a",
+		Carbon:               "n/a",
+	}
+
+	region, gpu, cpu := environment(r)
+	duration := time.Duration(summary.DurationS * float64(time.Second))
+
+	gpuCost, gpuOk := pricing.Cost(gpu, duration)
+	cpuCost, cpuOk := pricing.Cost(cpu, duration)
+	if gpuOk && cpuOk {
+		summary.Cost = formatFloat(gpuCost+cpuCost, 4) + " USD"
+	}
+
+	gpuCarbon, gpuOk := pricing.Carbon(gpu, region, duration)
+	cpuCarbon, cpuOk := pricing.Carbon(cpu, region, duration)
+	if gpuOk && cpuOk {
+		summary.Carbon = formatFloat(gpuCarbon+cpuCarbon, 2) + " gCO2eq"
+	}
+
+	return summary
+}
+
+// environment returns the region and instance types of the run, empty when
+// the results do not record them
+func environment(r *results.Results) (string, string, string) {
+	if r.Environment == nil {
+		return "", "", ""
+	}
+
+	return results.ValueOrZero(r.Environment.Regions), results.ValueOrZero(r.Environment.Ec2GpuInstanceType), results.ValueOrZero(r.Environment.Ec2CpuInstanceType)
+}
+
+func summarizeEnvironment(name string, r *results.Results) environmentSummary {
+	region, gpu, cpu := environment(r)
+
+	summary := environmentSummary{
+		Name:            name,
+		Region:          region,
+		GPUInstanceType: gpu,
+		CPUInstanceType: cpu,
+		Backend:         results.ValueOrZero(r.Backend),
+		Model:           results.ValueOrZero(r.ModelID),
+		Dataset:         results.ValueOrZero(r.DatasetPath),
+		NumPrompts:      results.ValueOrZero(r.NumPrompts),
+		RequestRate:     results.ValueOrZero(r.RequestRate),
+	}
+
+	if r.Environment != nil {
+		summary.AddressMode = results.ValueOrZero(r.Environment.AddressMode)
+	}
+
+	return summary
+}
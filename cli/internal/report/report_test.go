@@ -0,0 +1,71 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/heka-ai/benchmark-cli/pkg/results"
+)
+
+// failedRun has two successful requests and a failed one with a partial
+// timing, which must not count in the latencies
+func failedRun() *results.Results {
+	id := "run-1"
+	ttfts := []float64{0.1, 0.3, 0.002}
+	itls := [][]float64{{0.01, 0.03}, {0.02}, {}}
+	outputLens := []int{3, 2, 0}
+	errors := []string{"", "", "connection reset"}
+
+	return &results.Results{
+		BenchmarkID: &id,
+		Ttfts:       &ttfts,
+		Itls:        &itls,
+		OutputLens:  &outputLens,
+		Errors:      &errors,
+	}
+}
+
+func TestSummarizeRunSkipsFailedRequests(t *testing.T) {
+	r := failedRun()
+
+	summary := summarizeRun("run-1", r)
+	want := r.Summary()
+
+	if summary.MeanTtftMs != 200 || summary.MeanTtftMs != want.MeanTtftMs {
+		t.Errorf("MeanTtftMs = %v, want 200 as bench export %v", summary.MeanTtftMs, want.MeanTtftMs)
+	}
+
+	if summary.MedianTtftMs != want.MedianTtftMs || summary.P99TtftMs != want.P99TtftMs {
+		t.Errorf("TTFT percentiles = %v, %v, want %v, %v", summary.MedianTtftMs, summary.P99TtftMs, want.MedianTtftMs, want.P99TtftMs)
+	}
+
+	if summary.MeanItlMs != 20 || summary.MeanItlMs != want.MeanItlMs {
+		t.Errorf("MeanItlMs = %v, want 20 as bench export %v", summary.MeanItlMs, want.MeanItlMs)
+	}
+
+	if summary.Failed != 1 {
+		t.Errorf("Failed = %d, want 1", summary.Failed)
+	}
+}
+
+func TestRenderPlotsSuccessfulRequests(t *testing.T) {
+	var out bytes.Buffer
+	if err := Render(&out, "report", []Run{{Source: "run-1.json", Results: failedRun()}}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	samples := failedRun().SuccessfulSamples()
+	if len(samples.Ttft) != 2 || len(samples.Itl) != 3 {
+		t.Fatalf("SuccessfulSamples() = %d TTFTs and %d ITLs, want 2 and 3", len(samples.Ttft), len(samples.Itl))
+	}
+
+	points := cdf(samples.Ttft)
+	if points[0].X != 100 {
+		t.Errorf("the TTFT CDF starts at %v, want 100 without the failed request", points[0].X)
+	}
+
+	if !strings.Contains(out.String(), "<td>1</td>") {
+		t.Errorf("the report does not show the failed request")
+	}
+}
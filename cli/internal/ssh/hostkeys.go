@@ -0,0 +1,180 @@
+package ssh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+var (
+	// ErrHostKeyChanged is returned when the host key of an instance is not
+	// the one pinned for its address
+	ErrHostKeyChanged = errors.New("the host key changed")
+	// ErrHostKeyNotPinned is returned when no host key is pinned for the
+	// address of an instance
+	ErrHostKeyNotPinned = errors.New("no host key is pinned")
+	// ErrNoConsoleHostKeys is returned when the console output of an instance
+	// does not hold its host keys yet
+	ErrNoConsoleHostKeys = errors.New("the console output has no host keys")
+)
+
+// the block cloud-init prints on the console of the instances at boot
+const (
+	beginHostKeys = "-----BEGIN SSH HOST KEY KEYS-----"
+	endHostKeys   = "-----END SSH HOST KEY KEYS-----"
+)
+
+// hostKeyCallback only accepts the host keys pinned in the known_hosts file,
+// see PinHostKeys. The instances are not in ~/.ssh/known_hosts, their
+// addresses are reused by AWS.
+func hostKeyCallback(knownHostsPath string) ssh.HostKeyCallback {
+	return func(host string, remote net.Addr, key ssh.PublicKey) error {
+		callback, err := knownhosts.New(knownHostsPath)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w for %s in %s", ErrHostKeyNotPinned, host, knownHostsPath)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = callback(host, remote, key)
+
+		var keyErr *knownhosts.KeyError
+		switch {
+		case errors.As(err, &keyErr) && len(keyErr.Want) > 0:
+			return fmt.Errorf("%w for %s, it is %s and is not pinned in %s", ErrHostKeyChanged, host, ssh.FingerprintSHA256(key), knownHostsPath)
+		case errors.As(err, &keyErr):
+			return fmt.Errorf("%w for %s in %s", ErrHostKeyNotPinned, host, knownHostsPath)
+		}
+
+		return err
+	}
+}
+
+// ConsoleHostKeys returns the host keys cloud-init printed in the console
+// output of an instance, the last block when the instance booted several
+// times
+func ConsoleHostKeys(output string) ([]ssh.PublicKey, error) {
+	var keys []ssh.PublicKey
+	var block []ssh.PublicKey
+	inBlock := false
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.Contains(line, beginHostKeys):
+			inBlock, block = true, nil
+		case strings.Contains(line, endHostKeys):
+			if inBlock && len(block) > 0 {
+				keys = block
+			}
+			inBlock = false
+		case inBlock:
+			// the lines of the console can have a prefix, e.g. a timestamp
+			fields := strings.Fields(line)
+			for i := range fields {
+				key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.Join(fields[i:], " ")))
+				if err == nil {
+					block = append(block, key)
+					break
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoConsoleHostKeys
+	}
+
+	return keys, nil
+}
+
+// PinHostKeys replaces the host keys pinned for the host in the known_hosts
+// file by the keys, the file is created when missing
+func PinHostKeys(knownHostsPath string, host string, keys []ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(knownHostsPath), 0700); err != nil {
+		return err
+	}
+
+	lines, err := readKnownHosts(knownHostsPath)
+	if err != nil {
+		return err
+	}
+
+	// the address was used by another instance
+	address := knownhosts.Normalize(host)
+	kept := []string{}
+	for _, line := range lines {
+		if !knownHostsLineMatches(line, address) {
+			kept = append(kept, line)
+		}
+	}
+
+	for _, key := range keys {
+		kept = append(kept, knownhosts.Line([]string{address}, key))
+	}
+
+	return os.WriteFile(knownHostsPath, []byte(strings.Join(kept, "\n")+"\n"), 0600)
+}
+
+// HostKeysPinned tells whether the known_hosts file has host keys for the host
+func HostKeysPinned(knownHostsPath string, host string) (bool, error) {
+	lines, err := readKnownHosts(knownHostsPath)
+	if err != nil {
+		return false, err
+	}
+
+	address := knownhosts.Normalize(host)
+	for _, line := range lines {
+		if knownHostsLineMatches(line, address) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func readKnownHosts(knownHostsPath string) ([]string, error) {
+	data, err := os.ReadFile(knownHostsPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+// knownHostsLineMatches tells whether the line pins a key for the normalized
+// address, the lines written by PinHostKeys have a single address
+func knownHostsLineMatches(line string, address string) bool {
+	hosts, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+	for _, host := range strings.Split(hosts, ",") {
+		if host == address {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,143 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func authorizedKey(key ssh.PublicKey) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+}
+
+func TestConsoleHostKeys(t *testing.T) {
+	first, second, third := newHostKey(t), newHostKey(t), newHostKey(t)
+
+	// the instance booted twice, the keys of the last boot are kept
+	output := fmt.Sprintf(`[   10.1] cloud-init[800]: Generating public/private ed25519 key pair.
+-----BEGIN SSH HOST KEY KEYS-----
+%s root@ip-10-0-0-1
+-----END SSH HOST KEY KEYS-----
+[   50.2] cloud-init[812]: -----BEGIN SSH HOST KEY KEYS-----
+[   50.2] cloud-init[812]: %s root@ip-10-0-0-1
+[   50.2] cloud-init[812]: %s root@ip-10-0-0-1
+[   50.2] cloud-init[812]: -----END SSH HOST KEY KEYS-----
+`, authorizedKey(first), authorizedKey(second), authorizedKey(third))
+
+	keys, err := ConsoleHostKeys(output)
+	if err != nil {
+		t.Fatalf("ConsoleHostKeys() error = %v", err)
+	}
+
+	if len(keys) != 2 || !bytes.Equal(keys[0].Marshal(), second.Marshal()) || !bytes.Equal(keys[1].Marshal(), third.Marshal()) {
+		t.Errorf("ConsoleHostKeys() = %d keys, want the keys of the last block", len(keys))
+	}
+}
+
+func TestConsoleHostKeysNotPrinted(t *testing.T) {
+	tests := map[string]string{
+		"empty":      "",
+		"no block":   "[    1.0] Booting the kernel\n",
+		"unfinished": "-----BEGIN SSH HOST KEY KEYS-----\n" + authorizedKey(newHostKey(t)) + "\n",
+		"no key":     "-----BEGIN SSH HOST KEY KEYS-----\n-----END SSH HOST KEY KEYS-----\n",
+	}
+
+	for name, output := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ConsoleHostKeys(output); !errors.Is(err, ErrNoConsoleHostKeys) {
+				t.Errorf("ConsoleHostKeys() error = %v, want %v", err, ErrNoConsoleHostKeys)
+			}
+		})
+	}
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	knownHostsPath := filepath.Join(t.TempDir(), "known_hosts")
+	remote := &net.TCPAddr{IP: net.ParseIP("203.0.113.10"), Port: 22}
+	pinned, other := newHostKey(t), newHostKey(t)
+
+	callback := hostKeyCallback(knownHostsPath)
+
+	if err := callback("203.0.113.10:22", remote, pinned); !errors.Is(err, ErrHostKeyNotPinned) {
+		t.Errorf("callback() without known_hosts error = %v, want %v", err, ErrHostKeyNotPinned)
+	}
+
+	if err := PinHostKeys(knownHostsPath, "203.0.113.10", []ssh.PublicKey{pinned}); err != nil {
+		t.Fatalf("PinHostKeys() error = %v", err)
+	}
+
+	if err := callback("203.0.113.10:22", remote, pinned); err != nil {
+		t.Errorf("callback() of the pinned key error = %v", err)
+	}
+
+	if err := callback("203.0.113.10:22", remote, other); !errors.Is(err, ErrHostKeyChanged) {
+		t.Errorf("callback() of another key error = %v, want %v", err, ErrHostKeyChanged)
+	}
+
+	otherRemote := &net.TCPAddr{IP: net.ParseIP("203.0.113.11"), Port: 22}
+	if err := callback("203.0.113.11:22", otherRemote, pinned); !errors.Is(err, ErrHostKeyNotPinned) {
+		t.Errorf("callback() of another host error = %v, want %v", err, ErrHostKeyNotPinned)
+	}
+}
+
+func TestPinHostKeysReplacesTheKeysOfTheHost(t *testing.T) {
+	knownHostsPath := filepath.Join(t.TempDir(), "benchmark", "known_hosts")
+	old, replacement, kept := newHostKey(t), newHostKey(t), newHostKey(t)
+
+	if err := PinHostKeys(knownHostsPath, "203.0.113.10", []ssh.PublicKey{old}); err != nil {
+		t.Fatal(err)
+	}
+	if err := PinHostKeys(knownHostsPath, "203.0.113.11", []ssh.PublicKey{kept}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the address was given to a new instance
+	if err := PinHostKeys(knownHostsPath, "203.0.113.10", []ssh.PublicKey{replacement}); err != nil {
+		t.Fatal(err)
+	}
+
+	callback := hostKeyCallback(knownHostsPath)
+	remote := &net.TCPAddr{IP: net.ParseIP("203.0.113.10"), Port: 22}
+
+	if err := callback("203.0.113.10:22", remote, replacement); err != nil {
+		t.Errorf("callback() of the new key error = %v", err)
+	}
+	if err := callback("203.0.113.10:22", remote, old); !errors.Is(err, ErrHostKeyChanged) {
+		t.Errorf("callback() of the replaced key error = %v, want %v", err, ErrHostKeyChanged)
+	}
+
+	for _, host := range []string{"203.0.113.10", "203.0.113.11"} {
+		pinned, err := HostKeysPinned(knownHostsPath, host)
+		if err != nil || !pinned {
+			t.Errorf("HostKeysPinned(%s) = %v, %v, want true", host, pinned, err)
+		}
+	}
+
+	if pinned, err := HostKeysPinned(knownHostsPath, "203.0.113.12"); err != nil || pinned {
+		t.Errorf("HostKeysPinned() of an unknown host = %v, %v, want false", pinned, err)
+	}
+}
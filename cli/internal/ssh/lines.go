@@ -0,0 +1,41 @@
+package ssh
+
+import (
+	"bytes"
+	"strings"
+)
+
+// LineWriter calls the function for each line written to it, the output of
+// a remote command can be logged as it comes
+type LineWriter struct {
+	line   func(string)
+	buffer []byte
+}
+
+func NewLineWriter(line func(string)) *LineWriter {
+	return &LineWriter{line: line}
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.buffer = append(w.buffer, p...)
+
+	for {
+		i := bytes.IndexByte(w.buffer, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.line(strings.TrimRight(string(w.buffer[:i]), "\r"))
+		w.buffer = w.buffer[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush passes the last line when it has no newline
+func (w *LineWriter) Flush() {
+	if len(w.buffer) > 0 {
+		w.line(strings.TrimRight(string(w.buffer), "\r"))
+		w.buffer = nil
+	}
+}
@@ -1,61 +1,352 @@
 package ssh
 
 import (
-	"net"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
 
 	log "github.com/heka-ai/benchmark-cli/internal/logs"
 	"github.com/melbahja/goph"
+	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 )
 
 var logger = log.GetLogger("ssh")
 
+// SSHClient runs the commands on an instance, the connection is opened on the
+// first command and kept until Close
 type SSHClient struct {
 	Host    string
 	User    string
 	KeyPath string
+	// only the host keys pinned there are accepted, see PinHostKeys
+	KnownHostsPath string
+
+	mu     sync.Mutex
+	client *goph.Client
 }
 
-func NewSSHClient(keyPath string, host string, user string) *SSHClient {
+func NewSSHClient(keyPath string, host string, user string, knownHostsPath string) *SSHClient {
 	return &SSHClient{
-		Host:    host,
-		User:    user,
-		KeyPath: keyPath,
+		Host:           host,
+		User:           user,
+		KeyPath:        keyPath,
+		KnownHostsPath: knownHostsPath,
 	}
 }
 
-func (c *SSHClient) Run(command string) error {
+func (c *SSHClient) connect() (*goph.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client != nil {
+		return c.client, nil
+	}
+
 	auth, err := goph.Key(c.KeyPath, "")
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := goph.NewConn(&goph.Config{
+		User:     c.User,
+		Auth:     auth,
+		Addr:     c.Host,
+		Port:     22,
+		Timeout:  15 * time.Second,
+		Callback: hostKeyCallback(c.KnownHostsPath),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.client = client
+
+	return client, nil
+}
+
+// WaitReady retries to connect until the SSH server of the instance answers,
+// it starts after the instance is running
+func (c *SSHClient) WaitReady(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		_, err := c.connect()
+		if err == nil || errors.Is(err, ErrHostKeyChanged) || errors.Is(err, ErrHostKeyNotPinned) {
+			return err
+		}
+
+		logger.Debug().Err(err).Str("host", c.Host).Msg("SSH is not ready yet")
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cannot connect to %s over SSH: %w", c.Host, err)
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+// Close closes the connection, the next command opens a new one
+func (c *SSHClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client == nil {
+		return nil
+	}
+
+	err := c.client.Close()
+	c.client = nil
+
+	return err
+}
 
+// Run runs the command and logs its output line by line
+func (c *SSHClient) Run(command string) error {
+	stdout := NewLineWriter(func(line string) {
+		logger.Info().Str("host", c.Host).Str("stream", "stdout").Msg(line)
+	})
+	stderr := NewLineWriter(func(line string) {
+		logger.Info().Str("host", c.Host).Str("stream", "stderr").Msg(line)
+	})
+	defer stdout.Flush()
+	defer stderr.Flush()
+
+	logger.Debug().Str("host", c.Host).Str("command", command).Msg("Running the command")
+
+	return c.Stream(context.Background(), command, stdout, stderr)
+}
+
+// Output runs the command and returns its combined output
+func (c *SSHClient) Output(command string) ([]byte, error) {
+	client, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Run(command)
+}
+
+// Stream runs the command and writes its output as it comes, the command is
+// killed when the context is done
+func (c *SSHClient) Stream(ctx context.Context, command string, stdout io.Writer, stderr io.Writer) error {
+	client, err := c.connect()
 	if err != nil {
 		return err
 	}
 
-	client, err := goph.NewConn(&goph.Config{
-		User: c.User,
-		Auth: auth,
-		Addr: c.Host,
-		Port: 22,
-		Callback: func(host string, remote net.Addr, key ssh.PublicKey) error {
-			return goph.AddKnownHost(host, remote, key, "")
-		},
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	if err := session.Start(command); err != nil {
+		return err
+	}
+
+	return waitSession(ctx, session)
+}
+
+// Shell opens an interactive shell on a terminal of width x height, or runs
+// the command on it when not empty
+func (c *SSHClient) Shell(ctx context.Context, command string, term string, width int, height int, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	client, err := c.connect()
+	if err != nil {
+		return err
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty(term, height, width, modes); err != nil {
+		return fmt.Errorf("cannot open a terminal: %w", err)
+	}
+
+	if command == "" {
+		err = session.Shell()
+	} else {
+		err = session.Start(command)
+	}
+	if err != nil {
+		return err
+	}
+
+	return waitSession(ctx, session)
+}
+
+func waitSession(ctx context.Context, session *ssh.Session) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		return ctx.Err()
+	}
+}
+
+// ExitStatus returns the exit status of the remote command of the error,
+// false when the command did not run to its end
+func ExitStatus(err error) (int, bool) {
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), true
+	}
+
+	return 1, false
+}
+
+func (c *SSHClient) sftp() (*sftp.Client, error) {
+	client, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.NewSftp()
+}
+
+// Upload copies the local file or directory to the remote path over SFTP, the
+// parents of the remote path are created and the file modes are kept
+func (c *SSHClient) Upload(localPath string, remotePath string) error {
+	sftp, err := c.sftp()
+	if err != nil {
+		return err
+	}
+	defer sftp.Close()
+
+	return filepath.Walk(localPath, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(localPath, file)
+		if err != nil {
+			return err
+		}
+		remote := path.Join(remotePath, filepath.ToSlash(relative))
+
+		if info.IsDir() {
+			return sftp.MkdirAll(remote)
+		}
+
+		if !info.Mode().IsRegular() {
+			return errors.New("only the regular files can be uploaded: " + file)
+		}
+
+		if err := sftp.MkdirAll(path.Dir(remote)); err != nil {
+			return err
+		}
+
+		local, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer local.Close()
+
+		dest, err := sftp.Create(remote)
+		if err != nil {
+			return fmt.Errorf("cannot create %s: %w", remote, err)
+		}
+		defer dest.Close()
+
+		if _, err := io.Copy(dest, local); err != nil {
+			return fmt.Errorf("cannot upload %s: %w", file, err)
+		}
+
+		return dest.Chmod(info.Mode().Perm())
 	})
+}
 
+// Download copies the remote file or directory to the local path over SFTP,
+// the parents of the local path are created and the file modes are kept
+func (c *SSHClient) Download(remotePath string, localPath string) error {
+	sftp, err := c.sftp()
 	if err != nil {
 		return err
 	}
+	defer sftp.Close()
+
+	walker := sftp.Walk(remotePath)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return err
+		}
 
-	// Defer closing the network connection.
-	defer client.Close()
+		relative, err := filepath.Rel(remotePath, walker.Path())
+		if err != nil {
+			return err
+		}
+		local := filepath.Join(localPath, relative)
+		info := walker.Stat()
 
-	// Execute your command.
-	out, err := client.Run(command)
+		if info.IsDir() {
+			if err := os.MkdirAll(local, 0755); err != nil {
+				return err
+			}
+			continue
+		}
 
-	logger.Debug().Str("command", command).Msgf("Executed command %s", out)
+		if !info.Mode().IsRegular() {
+			logger.Warn().Str("file", walker.Path()).Msg("Skipping the file, it is not a regular file")
+			continue
+		}
 
+		if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+			return err
+		}
+
+		if err := downloadFile(sftp, walker.Path(), local, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func downloadFile(client *sftp.Client, remotePath string, localPath string, mode os.FileMode) error {
+	remote, err := client.Open(remotePath)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %w", remotePath, err)
+	}
+	defer remote.Close()
+
+	local, err := os.OpenFile(localPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
 	if err != nil {
 		return err
 	}
+	defer local.Close()
+
+	if _, err := io.Copy(local, remote); err != nil {
+		return fmt.Errorf("cannot download %s: %w", remotePath, err)
+	}
 
 	return nil
 }
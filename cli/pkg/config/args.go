@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// generateFlags turns the fields of a struct into command line flags, in the
+// order of the fields. The flag name is the json tag of the field, fields
+// without a json tag, nil pointers and the skipped names are ignored.
+// Bool fields are store-true flags: true emits `--flag`, false emits nothing,
+// unless the field is tagged negatable, false then emits `--no-flag`.
+func generateFlags(v interface{}, skip ...string) ([]string, error) {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct, got %s", value.Kind())
+	}
+
+	args := []string{}
+	t := value.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		name := flagName(t.Field(i))
+		if name == "" || slices.Contains(skip, name) {
+			continue
+		}
+
+		field := value.Field(i)
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+
+		flag := "--" + name
+
+		switch field.Kind() {
+		case reflect.String:
+			if field.String() == "" {
+				continue
+			}
+			args = append(args, flag, field.String())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			args = append(args, flag, strconv.FormatInt(field.Int(), 10))
+		case reflect.Float32, reflect.Float64:
+			args = append(args, flag, strconv.FormatFloat(field.Float(), 'f', -1, 64))
+		case reflect.Bool:
+			if field.Bool() {
+				args = append(args, flag)
+			} else if t.Field(i).Tag.Get("negatable") == "true" {
+				args = append(args, "--no-"+name)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported type %s for flag %s", field.Type(), name)
+		}
+	}
+
+	return args, nil
+}
+
+func flagName(field reflect.StructField) string {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return ""
+	}
+
+	name := strings.Split(tag, ",")[0]
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// fillAll sets every field of the config to a value derived from its
+// position, the bools are set to true
+func fillAll(t *testing.T) *VLLMConfig {
+	t.Helper()
+
+	conf := &VLLMConfig{}
+	value := reflect.ValueOf(conf).Elem()
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		name := value.Type().Field(i).Name
+
+		if field.Kind() == reflect.Pointer {
+			field.Set(reflect.New(field.Type().Elem()))
+			field = field.Elem()
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(strings.ToLower(name))
+		case reflect.Int:
+			field.SetInt(int64(i))
+		case reflect.Float64:
+			field.SetFloat(float64(i) + 0.25)
+		case reflect.Bool:
+			field.SetBool(true)
+		default:
+			t.Fatalf("no test value for the field %s of type %s", name, field.Type())
+		}
+	}
+
+	return conf
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestGenerateVLLMCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(t *testing.T) *VLLMConfig
+	}{
+		{
+			name:   "all_fields",
+			config: fillAll,
+		},
+		{
+			name: "model_only",
+			config: func(t *testing.T) *VLLMConfig {
+				return &VLLMConfig{Model: "meta-llama/Llama-3.1-8B-Instruct"}
+			},
+		},
+		{
+			name: "bools",
+			config: func(t *testing.T) *VLLMConfig {
+				return &VLLMConfig{
+					Model:                "model",
+					EnforceEager:         ptr(true),
+					TrustRemoteCode:      ptr(false),
+					EnablePrefixCaching:  ptr(false),
+					EnableChunkedPrefill: ptr(true),
+					// nil bools are left to the vllm default
+					DisableLogStats: nil,
+				}
+			},
+		},
+		{
+			name: "floats",
+			config: func(t *testing.T) *VLLMConfig {
+				return &VLLMConfig{
+					Model:                "model",
+					GPUMemoryUtilization: ptr(0.9),
+					// the floats are never written in the exponent form
+					RopeTheta: ptr(1000000.0),
+					TypicalAcceptanceSamplerPosteriorThreshold: ptr(0.00001),
+				}
+			},
+		},
+		{
+			name: "zero_values",
+			config: func(t *testing.T) *VLLMConfig {
+				return &VLLMConfig{
+					Model:                "model",
+					Tokenizer:            ptr(""),
+					Seed:                 ptr(0),
+					GPUMemoryUtilization: ptr(0.0),
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := GenerateVLLMCommand(tt.config(t))
+			if err != nil {
+				t.Fatalf("GenerateVLLMCommand() error = %v", err)
+			}
+
+			// one argument per line, a flag and its value are easy to review
+			got := strings.Join(args, "\n") + "\n"
+			golden := filepath.Join("testdata", tt.name+".golden")
+
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("cannot read the golden file, run go test with -update: %v", err)
+			}
+
+			if got != string(want) {
+				t.Errorf("the command does not match %s, run go test with -update and review the diff\ngot:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+// every field of the config must reach the command, a field without a flag
+// would be silently ignored
+func TestGenerateVLLMCommandCoversEveryField(t *testing.T) {
+	args, err := GenerateVLLMCommand(fillAll(t))
+	if err != nil {
+		t.Fatalf("GenerateVLLMCommand() error = %v", err)
+	}
+
+	flags := map[string]bool{}
+	for _, arg := range args {
+		flags[arg] = true
+	}
+
+	fields := reflect.TypeOf(VLLMConfig{})
+	for i := 0; i < fields.NumField(); i++ {
+		name := flagName(fields.Field(i))
+		if name == "" {
+			continue
+		}
+
+		if !flags["--"+name] {
+			t.Errorf("the field %s has no --%s flag in the command", fields.Field(i).Name, name)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"reflect"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// Unmarshal resolves the secret references of the settings read by v, see
+// Interpolate, and decodes them in out. The keys that are not part of the
+// config are rejected with KeyErrors, instead of being silently ignored.
+func Unmarshal(v *viper.Viper, out *Config) error {
+	settings := v.AllSettings()
+	if errs := unknownKeys(settings); len(errs) > 0 {
+		return errs
+	}
+
+	if err := interpolateSettings(settings, ""); err != nil {
+		return err
+	}
+
+	// same options as viper.Unmarshal
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           out,
+		WeaklyTypedInput: true,
+		DecodeHook:       decodeHook(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return decoder.Decode(settings)
+}
+
+// decodeHook keeps the default viper hooks and treats an empty string as an
+// unset optional value, so `max-num-seqs = ""` does not become `--max-num-seqs 0`.
+func decodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		// must stay last, the other hooks do not accept a nil value
+		emptyStringToNilHook,
+	)
+}
+
+func emptyStringToNilHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() == reflect.String && to.Kind() == reflect.Pointer && data == "" {
+		return nil, nil
+	}
+
+	return data, nil
+}
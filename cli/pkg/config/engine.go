@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/heka-ai/benchmark-cli/internal/engines"
+)
+
+// EngineVersion returns the version of the inference engine, the one
+// installed by the instance-builder when the config does not set it
+func (c *Config) EngineVersion() string {
+	if c.InferenceEngineVersion != "" {
+		return c.InferenceEngineVersion
+	}
+
+	return engines.DefaultVersions[c.InferenceEngine]
+}
+
+// CheckEngineFlags returns a warning for each engine flag of the config that
+// is unsupported or deprecated in the engine version. The config struct
+// accepts the flags of every known version, so these are not validation errors.
+func CheckEngineFlags(c *Config) ([]string, error) {
+	if c.VLLMConfig == nil {
+		return nil, nil
+	}
+
+	spec, err := engines.Load(c.InferenceEngine, c.EngineVersion())
+	if errors.Is(err, engines.ErrUnknownVersion) {
+		return []string{fmt.Sprintf("no flag spec for %s %s, the engine flags are not checked (known versions: %v)", c.InferenceEngine, c.EngineVersion(), engines.Versions(c.InferenceEngine))}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := []string{}
+
+	value := reflect.ValueOf(c.VLLMConfig).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name := flagName(value.Type().Field(i))
+		field := value.Field(i)
+		if name == "" || field.Kind() != reflect.Pointer || field.IsNil() {
+			continue
+		}
+
+		arg := spec.Arg(name)
+		switch {
+		case arg == nil:
+			warnings = append(warnings, fmt.Sprintf("--%s is not supported by %s %s", name, spec.Engine, spec.Version))
+		case arg.Deprecated:
+			warnings = append(warnings, fmt.Sprintf("--%s is deprecated in %s %s", name, spec.Engine, spec.Version))
+		case len(arg.Choices) > 0 && !slices.Contains(arg.Choices, fmt.Sprint(field.Elem().Interface())):
+			warnings = append(warnings, fmt.Sprintf("--%s %v is not supported by %s %s, expected one of %v", name, field.Elem().Interface(), spec.Engine, spec.Version, arg.Choices))
+		}
+	}
+
+	return warnings, nil
+}
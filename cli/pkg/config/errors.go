@@ -0,0 +1,181 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	log "github.com/heka-ai/benchmark-cli/internal/logs"
+)
+
+var logger = log.GetLogger("config")
+
+// KeyError is a problem of the config at a TOML key
+type KeyError struct {
+	// dotted path of the key, e.g. vllm.max-num-seqs
+	Path    string `json:"key"`
+	Message string `json:"message"`
+}
+
+func (e KeyError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// KeyErrors lists the problems of a config, sorted by key
+type KeyErrors []KeyError
+
+func (e KeyErrors) Error() string {
+	messages := []string{}
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks the config against its validate tags, the fields listed
+// in except are skipped (see SecretFields). The errors are KeyErrors
+// pointing to the TOML keys.
+func Validate(c *Config, except ...string) error {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(tomlName)
+
+	err := validate.StructExcept(c, except...)
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	keyErrors := KeyErrors{}
+	for _, fieldError := range validationErrors {
+		keyErrors = append(keyErrors, KeyError{
+			Path:    tomlPath(fieldError.Namespace()),
+			Message: validationMessage(fieldError),
+		})
+	}
+
+	return keyErrors
+}
+
+// tomlName is the name of the field in the TOML file
+func tomlName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+// tomlPath drops the name of the root struct from the validator namespace
+func tomlPath(namespace string) string {
+	_, path, _ := strings.Cut(namespace, ".")
+	return path
+}
+
+func validationMessage(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required":
+		return "is required"
+	case "required_if":
+		return fmt.Sprintf("is required when %s", describeCondition(err))
+	case "required_without":
+		return fmt.Sprintf("is required when %s is not set", siblingKey(err.StructNamespace(), err.Param()))
+	case "oneof":
+		return fmt.Sprintf("must be one of %s, got %q", strings.Join(strings.Fields(err.Param()), ", "), fmt.Sprint(err.Value()))
+	case "url":
+		return fmt.Sprintf("must be a URL, got %q", fmt.Sprint(err.Value()))
+	case "gte":
+		return fmt.Sprintf("must be at least %s, got %v", err.Param(), err.Value())
+	case "cidr":
+		return fmt.Sprintf("must be a CIDR such as 203.0.113.7/32, got %q", fmt.Sprint(err.Value()))
+	}
+
+	if err.Param() != "" {
+		return fmt.Sprintf("does not satisfy %s=%s, got %v", err.Tag(), err.Param(), err.Value())
+	}
+
+	return fmt.Sprintf("does not satisfy %s, got %v", err.Tag(), err.Value())
+}
+
+// describeCondition turns the `Field value` pairs of a required_if tag into
+// TOML keys, e.g. provider = "aws"
+func describeCondition(err validator.FieldError) string {
+	params := strings.Fields(err.Param())
+
+	conditions := []string{}
+	for i := 0; i+1 < len(params); i += 2 {
+		conditions = append(conditions, fmt.Sprintf("%s = %q", siblingKey(err.StructNamespace(), params[i]), params[i+1]))
+	}
+
+	return strings.Join(conditions, " and ")
+}
+
+// siblingKey returns the TOML name of a field of the struct holding the
+// field of the struct namespace, the tags of the validator name the fields
+// by their Go name
+func siblingKey(structNamespace string, name string) string {
+	parent := parentType(structNamespace)
+	if parent == nil {
+		return name
+	}
+
+	if field, ok := parent.FieldByName(name); ok && tomlName(field) != "" {
+		return tomlName(field)
+	}
+
+	return name
+}
+
+// parentType returns the struct holding the field of the struct namespace,
+// e.g. AWSConfig for Config.AWSConfig.AWSAccessKey
+func parentType(structNamespace string) reflect.Type {
+	names := strings.Split(structNamespace, ".")
+	t := reflect.TypeOf(Config{})
+
+	// the first name is the root struct and the last one the field
+	for _, name := range names[1 : len(names)-1] {
+		field, ok := t.FieldByName(strings.Split(name, "[")[0])
+		if !ok {
+			return nil
+		}
+
+		t = structType(field.Type)
+		if t == nil {
+			return nil
+		}
+	}
+
+	return t
+}
+
+// structType returns the struct type behind a field type, nil for the
+// other types
+func structType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return t
+}
+
+// LogErrors logs the KeyErrors one key at a time, the other errors as is
+func LogErrors(err error, msg string) {
+	var keyErrors KeyErrors
+	if !errors.As(err, &keyErrors) {
+		logger.Error().Err(err).Msg(msg)
+		return
+	}
+
+	for _, keyError := range keyErrors {
+		logger.Error().Str("key", keyError.Path).Msg(keyError.Message)
+	}
+	logger.Error().Int("errors", len(keyErrors)).Msg(msg)
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// unknownKeys returns a KeyError for each setting that is not a field of
+// the config, with the keys it was likely meant to be
+func unknownKeys(settings map[string]interface{}) KeyErrors {
+	root := reflect.TypeOf(Config{})
+
+	known := knownPaths(root, "")
+	sort.Strings(known)
+
+	return checkKeys(settings, root, "", known)
+}
+
+func checkKeys(settings map[string]interface{}, t reflect.Type, prefix string, known []string) KeyErrors {
+	fields := fieldTypes(t)
+	errs := KeyErrors{}
+
+	for _, key := range keys(settings) {
+		fieldType, ok := fields[key]
+		if !ok {
+			errs = append(errs, KeyError{Path: prefix + key, Message: unknownKeyMessage(key, prefix, fields, known)})
+			continue
+		}
+
+		table, isTable := settings[key].(map[string]interface{})
+		if !isTable {
+			continue
+		}
+
+		// the tables decoded in maps accept any key
+		if nested := structType(fieldType); nested != nil {
+			errs = append(errs, checkKeys(table, nested, prefix+key+".", known)...)
+		}
+	}
+
+	return errs
+}
+
+func unknownKeyMessage(key string, prefix string, fields map[string]reflect.Type, known []string) string {
+	siblings := []string{}
+	for name := range fields {
+		siblings = append(siblings, name)
+	}
+
+	if suggestion := closest(key, siblings); suggestion != "" {
+		return fmt.Sprintf("unknown key, did you mean %s?", prefix+suggestion)
+	}
+
+	// the key may be in the wrong table
+	elsewhere := []string{}
+	for _, path := range known {
+		leaf := path[strings.LastIndex(path, ".")+1:]
+		if normalizeKey(leaf) == normalizeKey(key) && path != prefix+leaf {
+			elsewhere = append(elsewhere, path)
+		}
+	}
+
+	if len(elsewhere) > 0 {
+		return fmt.Sprintf("unknown key, did you mean %s?", strings.Join(elsewhere, " or "))
+	}
+
+	return "unknown key"
+}
+
+// fieldTypes returns the type of the fields of a config struct by TOML name
+func fieldTypes(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		if name := tomlName(t.Field(i)); name != "" {
+			fields[name] = t.Field(i).Type
+		}
+	}
+
+	return fields
+}
+
+// knownPaths lists the dotted paths of all the keys of the config
+func knownPaths(t reflect.Type, prefix string) []string {
+	paths := []string{}
+	for name, fieldType := range fieldTypes(t) {
+		paths = append(paths, prefix+name)
+
+		if nested := structType(fieldType); nested != nil {
+			paths = append(paths, knownPaths(nested, prefix+name+".")...)
+		}
+	}
+
+	return paths
+}
+
+// valuePaths returns the dotted paths of the values of t, the tables are
+// left out
+func valuePaths(t reflect.Type, prefix string) []string {
+	paths := []string{}
+	for name, fieldType := range fieldTypes(t) {
+		if nested := structType(fieldType); nested != nil {
+			paths = append(paths, valuePaths(nested, prefix+name+".")...)
+			continue
+		}
+
+		paths = append(paths, prefix+name)
+	}
+
+	return paths
+}
+
+// closest returns the candidate the key is a typo of, if any. The dashes
+// and underscores are interchangeable, `max_num_seqs` is `max-num-seqs`.
+func closest(key string, candidates []string) string {
+	best, bestDistance := "", -1
+
+	for _, candidate := range candidates {
+		distance := levenshtein(normalizeKey(key), normalizeKey(candidate))
+
+		// a short key is close to too many others to be a typo of one of them
+		if distance > 2 || 2*distance >= len(key) {
+			continue
+		}
+
+		if bestDistance == -1 || distance < bestDistance || (distance == bestDistance && candidate < best) {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	return best
+}
+
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+func levenshtein(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
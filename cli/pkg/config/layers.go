@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+const (
+	extendsKey  = "extends"
+	profilesKey = "profiles"
+)
+
+// Options selects the layers merged on top of the config file, from the
+// lowest precedence to the highest: the files it extends, the file, the
+// profile, the environment and the --set overrides
+type Options struct {
+	Profile string
+	// key=value overrides, the key is the dotted path of the setting
+	Set []string
+	// fields left out of the validation, e.g. SecretFields on the instances
+	Except []string
+}
+
+// readLayers reads the config file with the files it extends and applies the
+// profile, the result only holds config settings
+func readLayers(path string, profile string) (map[string]interface{}, error) {
+	settings, err := readExtended(path, []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, _ := settings[profilesKey].(map[string]interface{})
+	delete(settings, profilesKey)
+
+	if profile == "" {
+		return settings, nil
+	}
+
+	selected, ok := profiles[profile].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q, the config defines %v", profile, keys(profiles))
+	}
+
+	merge(settings, selected)
+
+	return settings, nil
+}
+
+// readExtended reads a file merged over the files of its extends key,
+// chain holds the files being read to detect the cycles
+func readExtended(path string, chain []string) (map[string]interface{}, error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range chain {
+		if p == absolute {
+			return nil, fmt.Errorf("%s extends itself through %s", path, strings.Join(chain, " -> "))
+		}
+	}
+	chain = append(chain, absolute)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[string]interface{}{}
+	if err := toml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	bases, err := extendedFiles(settings[extendsKey])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	delete(settings, extendsKey)
+
+	merged := map[string]interface{}{}
+	for _, base := range bases {
+		// the bases are relative to the file extending them
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(filepath.Dir(path), base)
+		}
+
+		baseSettings, err := readExtended(base, chain)
+		if err != nil {
+			return nil, err
+		}
+
+		merge(merged, baseSettings)
+	}
+
+	merge(merged, settings)
+
+	return merged, nil
+}
+
+// extendedFiles accepts `extends = "base.toml"` or a list, merged in order
+func extendedFiles(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		files := []string{}
+		for _, item := range v {
+			file, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("extends must be a path or a list of paths, got %v", item)
+			}
+			files = append(files, file)
+		}
+		return files, nil
+	}
+
+	return nil, fmt.Errorf("extends must be a path or a list of paths, got %v", value)
+}
+
+// merge deep merges src into dst, the tables are merged and the other values replaced
+func merge(dst map[string]interface{}, src map[string]interface{}) {
+	for key, value := range src {
+		srcTable, srcIsTable := value.(map[string]interface{})
+		dstTable, dstIsTable := dst[key].(map[string]interface{})
+
+		if srcIsTable && dstIsTable {
+			merge(dstTable, srcTable)
+			continue
+		}
+
+		if srcIsTable {
+			copied := map[string]interface{}{}
+			merge(copied, srcTable)
+			value = copied
+		}
+
+		dst[key] = value
+	}
+}
+
+// parseSet parses a key=value override, the value is read as a TOML value
+// (42, true, 0.9, "text") and falls back to a plain string
+func parseSet(set string) (string, interface{}, error) {
+	key, raw, ok := strings.Cut(set, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", nil, fmt.Errorf("invalid override %q, expected key=value", set)
+	}
+
+	document := map[string]interface{}{}
+	if err := toml.Unmarshal([]byte("value = "+raw), &document); err == nil {
+		return key, document["value"], nil
+	}
+
+	return key, raw, nil
+}
+
+func keys(m map[string]interface{}) []string {
+	names := []string{}
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validConfig is the smallest config Load accepts
+const validConfig = `
+bench_id = "base"
+provider = "aws"
+inference_engine = "vllm"
+api_key = "api-key"
+
+[aws]
+region = "us-east-1"
+gpu_ami = "ami-0123456789abcdef0"
+cpu_ami = "ami-0123456789abcdef1"
+gpu_instance_type = "g5.xlarge"
+cpu_instance_type = "t3.micro"
+profile_name = "default"
+
+[benchmark]
+token = "hf-token"
+backend = "openai"
+dataset_name = "dataset"
+dataset_path = "dataset"
+hf_revision = "main"
+hf_split = "train"
+num_prompts = 10
+seed = 42
+
+[vllm]
+model = "meta-llama/Llama-3.2-3B-Instruct"
+`
+
+func writeConfig(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadReadsNestedKeysFromTheEnvironment(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "bench.toml", validConfig)
+
+	t.Setenv("BENCH_VLLM_MAX_NUM_SEQS", "64")
+	t.Setenv("BENCH_AWS_NETWORK_ALLOWED_CIDR", "203.0.113.0/24")
+
+	c, err := Load(path, Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if c.VLLMConfig.MaxNumSeqs == nil || *c.VLLMConfig.MaxNumSeqs != 64 {
+		t.Errorf("vllm.max-num-seqs = %v, want 64", c.VLLMConfig.MaxNumSeqs)
+	}
+
+	if c.AWSConfig.Network == nil || c.AWSConfig.Network.AllowedCIDR != "203.0.113.0/24" {
+		t.Errorf("aws.network.allowed_cidr = %+v, want 203.0.113.0/24", c.AWSConfig.Network)
+	}
+}
+
+func TestLoadLayerPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		profile string
+		env     string
+		set     []string
+		want    string
+	}{
+		{name: "extended file", want: "extended"},
+		{name: "file over extended file", file: `bench_id = "file"`, want: "file"},
+		{name: "profile over file", file: `bench_id = "file"`, profile: "large", want: "profile"},
+		{name: "environment over profile", file: `bench_id = "file"`, profile: "large", env: "env", want: "env"},
+		{name: "set over environment", file: `bench_id = "file"`, profile: "large", env: "env", set: []string{"bench_id=set"}, want: "set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeConfig(t, dir, "base.toml", strings.Replace(validConfig, `bench_id = "base"`, `bench_id = "extended"`, 1))
+
+			content := "extends = \"base.toml\"\n" + tt.file + `
+
+[profiles.large]
+bench_id = "profile"
+`
+			path := writeConfig(t, dir, "bench.toml", content)
+
+			if tt.env != "" {
+				t.Setenv("BENCH_BENCH_ID", tt.env)
+			}
+
+			c, err := Load(path, Options{Profile: tt.profile, Set: tt.set})
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if c.BenchID != tt.want {
+				t.Errorf("bench_id = %q, want %q", c.BenchID, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMergesTheTablesOfTheLayers(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "base.toml", validConfig)
+	path := writeConfig(t, dir, "bench.toml", `
+extends = "base.toml"
+
+[vllm]
+max-num-seqs = 8
+`)
+
+	c, err := Load(path, Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if c.VLLMConfig.Model == "" || c.VLLMConfig.MaxNumSeqs == nil || *c.VLLMConfig.MaxNumSeqs != 8 {
+		t.Errorf("vllm = %q, %v, want the model of the base and 8 sequences", c.VLLMConfig.Model, c.VLLMConfig.MaxNumSeqs)
+	}
+}
+
+func TestLoadExtendsCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "a.toml", `extends = "b.toml"`)
+	writeConfig(t, dir, "b.toml", `extends = "a.toml"`)
+
+	_, err := Load(filepath.Join(dir, "a.toml"), Options{})
+	if err == nil || !strings.Contains(err.Error(), "extends itself") {
+		t.Errorf("Load() error = %v, want the extends cycle", err)
+	}
+}
+
+func TestLoadUnknownProfile(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "bench.toml", validConfig)
+
+	_, err := Load(path, Options{Profile: "missing"})
+	if err == nil || !strings.Contains(err.Error(), `unknown profile "missing"`) {
+		t.Errorf("Load() error = %v, want the unknown profile", err)
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// envPrefix prefixes the environment variables of the settings, the dots
+// and dashes of the key are underscores: vllm.max-num-seqs is read from
+// BENCH_VLLM_MAX_NUM_SEQS
+const envPrefix = "BENCH"
+
+// Load reads the config file at path with the files it extends, applies
+// the profile, the environment and the overrides of opts, then decodes and
+// validates the result. The errors of the keys are KeyErrors.
+// Load has no side effect, each call reads the files again.
+func Load(path string, opts Options) (*Config, error) {
+	settings, err := readLayers(path, opts.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the config: %w", err)
+	}
+
+	v := viper.New()
+	v.SetConfigType("toml")
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	v.AutomaticEnv()
+
+	// viper only reads the environment of the keys it knows, the values
+	// missing from the files are bound too
+	for _, key := range valuePaths(reflect.TypeOf(Config{}), "") {
+		if err := v.BindEnv(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, fmt.Errorf("failed to read the config: %w", err)
+	}
+
+	// the overrides take precedence over everything else
+	for _, set := range opts.Set {
+		key, value, err := parseSet(set)
+		if err != nil {
+			return nil, err
+		}
+		v.Set(key, value)
+	}
+
+	c := &Config{}
+	if err := Unmarshal(v, c); err != nil {
+		return nil, err
+	}
+
+	if err := Validate(c, opts.Except...); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
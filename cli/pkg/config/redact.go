@@ -0,0 +1,159 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Fields tagged `secret:"true"` are never sent to the instances nor logged
+
+// RedactedValue replaces the secrets that are set in the masked configs
+const RedactedValue = "<redacted>"
+
+// Redact returns a copy of the config without the secrets
+func Redact(c *Config) *Config {
+	copied := *c
+	redact(reflect.ValueOf(&copied).Elem(), "")
+
+	return &copied
+}
+
+// Mask returns a copy of the config where the secrets that are set are
+// replaced by RedactedValue, to show the config without leaking them
+func Mask(c *Config) *Config {
+	copied := *c
+	redact(reflect.ValueOf(&copied).Elem(), RedactedValue)
+
+	return &copied
+}
+
+func redact(v reflect.Value, mask string) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+
+		if v.Type().Field(i).Tag.Get("secret") == "true" {
+			if mask != "" && field.Kind() == reflect.String && field.String() != "" {
+				field.SetString(mask)
+				continue
+			}
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+
+		// copy the nested configs so the original keeps its secrets
+		if field.Kind() == reflect.Pointer && !field.IsNil() && field.Elem().Kind() == reflect.Struct {
+			copied := reflect.New(field.Elem().Type())
+			copied.Elem().Set(field.Elem())
+			redact(copied.Elem(), mask)
+			field.Set(copied)
+		}
+	}
+}
+
+// SecretFields returns the namespaced names of the secret fields, as
+// expected by validator's StructExcept
+func SecretFields() []string {
+	return secretFields(reflect.TypeOf(Config{}), "")
+}
+
+func secretFields(t reflect.Type, prefix string) []string {
+	fields := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Tag.Get("secret") == "true" {
+			fields = append(fields, prefix+field.Name)
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct {
+			fields = append(fields, secretFields(field.Type.Elem(), prefix+field.Name+".")...)
+		}
+	}
+
+	return fields
+}
+
+// HashAPIKey returns the salted hash of the API key, written <salt>:<hash>
+// in hex, the instances only know the hash of the key to check the requests.
+// Each call draws a new random salt, the hashes of the instances cannot be
+// matched against precomputed ones.
+func HashAPIKey(key string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(salt) + ":" + hashAPIKey(salt, key), nil
+}
+
+// CheckAPIKeyHash tells whether the key is the one of the salted hash
+func CheckAPIKeyHash(key string, salted string) bool {
+	encodedSalt, hash, ok := strings.Cut(strings.ToLower(strings.TrimSpace(salted)), ":")
+	if !ok {
+		return false
+	}
+
+	salt, err := hex.DecodeString(encodedSalt)
+	if err != nil || len(salt) == 0 {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(hashAPIKey(salt, key)), []byte(hash)) == 1
+}
+
+func hashAPIKey(salt []byte, key string) string {
+	hash := sha256.Sum256(append(slices.Clone(salt), key...))
+	return hex.EncodeToString(hash[:])
+}
+
+// Marshal encodes the config in TOML, unset values are left out. The result
+// can be loaded again, the references that were escaped stay escaped.
+func Marshal(c *Config) ([]byte, error) {
+	return toml.Marshal(toMap(reflect.ValueOf(c).Elem()))
+}
+
+// toMap converts a config struct to a map keyed by the mapstructure tags
+func toMap(v reflect.Value) map[string]interface{} {
+	m := map[string]interface{}{}
+
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("mapstructure"), ",")[0]
+		field := v.Field(i)
+		if name == "" || name == "-" {
+			continue
+		}
+
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		} else if field.IsZero() {
+			continue
+		}
+
+		if field.Kind() == reflect.Struct {
+			m[name] = toMap(field)
+			continue
+		}
+
+		// the values are already resolved, a reference left is a literal one
+		if field.Kind() == reflect.String {
+			m[name] = reference.ReplaceAllString(field.String(), "$$$0")
+			continue
+		}
+
+		m[name] = field.Interface()
+	}
+
+	return m
+}
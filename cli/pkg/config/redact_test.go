@@ -0,0 +1,47 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashAPIKey(t *testing.T) {
+	first, err := HashAPIKey("secret")
+	if err != nil {
+		t.Fatalf("HashAPIKey() error = %v", err)
+	}
+
+	second, err := HashAPIKey("secret")
+	if err != nil {
+		t.Fatalf("HashAPIKey() error = %v", err)
+	}
+
+	if first == second {
+		t.Errorf("the hashes of the same key are equal, want a new salt on each call")
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		salted string
+		want   bool
+	}{
+		{name: "right key", key: "secret", salted: first, want: true},
+		{name: "right key, other salt", key: "secret", salted: second, want: true},
+		{name: "trailing newline", key: "secret", salted: first + "\n", want: true},
+		{name: "upper case", key: "secret", salted: strings.ToUpper(first), want: true},
+		{name: "wrong key", key: "Secret", salted: first, want: false},
+		{name: "empty key", key: "", salted: first, want: false},
+		{name: "no salt", key: "secret", salted: strings.SplitN(first, ":", 2)[1], want: false},
+		{name: "empty salt", key: "secret", salted: ":" + strings.SplitN(first, ":", 2)[1], want: false},
+		{name: "salt not in hex", key: "secret", salted: "zz:" + strings.SplitN(first, ":", 2)[1], want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckAPIKeyHash(tt.key, tt.salted); got != tt.want {
+				t.Errorf("CheckAPIKeyHash(%q, %q) = %v, want %v", tt.key, tt.salted, got, tt.want)
+			}
+		})
+	}
+}
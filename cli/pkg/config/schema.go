@@ -0,0 +1,208 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/heka-ai/benchmark-cli/internal/engines"
+	"github.com/heka-ai/benchmark-cli/internal/jsonschema"
+)
+
+// SchemaID is the $id of the config JSON Schema
+const SchemaID = "https://github.com/heka-ai/sia-benchmark/bench.schema.json"
+
+// Schema returns the JSON Schema of the config file, built from the config
+// structs and their validate tags. The vLLM flags are described with the
+// help of the newest vLLM version supporting them.
+func Schema() *jsonschema.Schema {
+	schema := structSchema(reflect.TypeOf(Config{}), vllmHelp())
+	schema.Schema = jsonschema.Draft
+	schema.ID = SchemaID
+	schema.Title = "Sia Benchmark config"
+
+	// the keys of the file that are not part of the config, see readLayers
+	schema.Properties[extendsKey] = &jsonschema.Schema{
+		Description: "Config files this file is merged over, relative to this file",
+		Type:        jsonschema.Types{"string", "array"},
+		Items:       &jsonschema.Schema{Type: jsonschema.Types{"string"}},
+	}
+	schema.Properties[profilesKey] = &jsonschema.Schema{
+		Description: "Partial configs merged over this file with --profile <name>",
+		Type:        jsonschema.Types{"object"},
+	}
+
+	return schema
+}
+
+// SchemaJSON returns the indented JSON encoding of Schema
+func SchemaJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(Schema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// vllmHelp returns the help of the vLLM flags, newest version first
+func vllmHelp() map[string]engines.Arg {
+	args := map[string]engines.Arg{}
+
+	versions := engines.Versions("vllm")
+	for i := len(versions) - 1; i >= 0; i-- {
+		spec, err := engines.Load("vllm", versions[i])
+		if err != nil {
+			continue
+		}
+
+		for _, arg := range spec.Args {
+			if _, ok := args[arg.Name]; !ok {
+				args[arg.Name] = arg
+			}
+		}
+	}
+
+	return args
+}
+
+func structSchema(t reflect.Type, help map[string]engines.Arg) *jsonschema.Schema {
+	closed := false
+	schema := &jsonschema.Schema{
+		Type:                 jsonschema.Types{"object"},
+		Properties:           map[string]*jsonschema.Schema{},
+		AdditionalProperties: &closed,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := tomlName(field)
+		if name == "" {
+			continue
+		}
+
+		property := typeSchema(field.Type, help)
+
+		if t == reflect.TypeOf(VLLMConfig{}) {
+			if arg, ok := help[name]; ok {
+				property.Description = arg.Help
+				property.Deprecated = arg.Deprecated
+			}
+		}
+
+		if field.Tag.Get("secret") == "true" {
+			property.Description = "Secret, write a reference such as ${env:NAME} rather than the value"
+		}
+
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			tag, param, _ := strings.Cut(rule, "=")
+
+			switch tag {
+			case "required":
+				schema.Required = append(schema.Required, name)
+			case "required_if":
+				schema.AllOf = append(schema.AllOf, requiredIf(t, name, param))
+			case "required_without":
+				schema.AllOf = append(schema.AllOf, &jsonschema.Schema{
+					AnyOf: []*jsonschema.Schema{
+						{Required: []string{siblingName(t, param)}},
+						{Required: []string{name}},
+					},
+				})
+			case "oneof":
+				for _, value := range strings.Fields(param) {
+					property.Enum = append(property.Enum, typedValue(field.Type, value))
+				}
+			case "url":
+				property.Format = "uri"
+			case "gte":
+				if minimum, err := strconv.ParseFloat(param, 64); err == nil {
+					property.Minimum = &minimum
+				}
+			}
+		}
+
+		schema.Properties[name] = property
+	}
+
+	return schema
+}
+
+func typeSchema(t reflect.Type, help map[string]engines.Arg) *jsonschema.Schema {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t, help)
+	case reflect.String:
+		return &jsonschema.Schema{Type: jsonschema.Types{"string"}}
+	case reflect.Bool:
+		return &jsonschema.Schema{Type: jsonschema.Types{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &jsonschema.Schema{Type: jsonschema.Types{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &jsonschema.Schema{Type: jsonschema.Types{"number"}}
+	case reflect.Slice:
+		return &jsonschema.Schema{Type: jsonschema.Types{"array"}, Items: typeSchema(t.Elem(), help)}
+	case reflect.Map:
+		return &jsonschema.Schema{Type: jsonschema.Types{"object"}}
+	}
+
+	return &jsonschema.Schema{}
+}
+
+// requiredIf translates `required_if=Field value...` to an if/then on the
+// object holding the field
+func requiredIf(t reflect.Type, name string, param string) *jsonschema.Schema {
+	params := strings.Fields(param)
+	condition := &jsonschema.Schema{Properties: map[string]*jsonschema.Schema{}}
+
+	for i := 0; i+1 < len(params); i += 2 {
+		sibling := siblingName(t, params[i])
+
+		value := any(params[i+1])
+		if field, ok := t.FieldByName(params[i]); ok {
+			value = typedValue(field.Type, params[i+1])
+		}
+
+		condition.Properties[sibling] = &jsonschema.Schema{Const: value}
+		condition.Required = append(condition.Required, sibling)
+	}
+
+	return &jsonschema.Schema{If: condition, Then: &jsonschema.Schema{Required: []string{name}}}
+}
+
+func siblingName(t reflect.Type, goName string) string {
+	if field, ok := t.FieldByName(goName); ok && tomlName(field) != "" {
+		return tomlName(field)
+	}
+
+	return goName
+}
+
+// typedValue parses a value of a validate tag as the type of the field
+func typedValue(t reflect.Type, value string) any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+
+	return value
+}
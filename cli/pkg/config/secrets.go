@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SecretBackend resolves the references of a scheme, ${<scheme>:<ref>} in the config
+type SecretBackend interface {
+	Resolve(ref string) (string, error)
+}
+
+// SecretBackendFunc adapts a function to a SecretBackend
+type SecretBackendFunc func(ref string) (string, error)
+
+func (f SecretBackendFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+var (
+	secretBackendsMu sync.RWMutex
+	secretBackends   = map[string]SecretBackend{
+		"env":  SecretBackendFunc(resolveEnv),
+		"file": SecretBackendFunc(resolveFile),
+	}
+)
+
+// ${scheme:ref}, $${ escapes a literal ${
+var reference = regexp.MustCompile(`\$?\$\{([a-z][a-z0-9-]*):([^}]*)\}`)
+
+// RegisterSecretBackend makes the backend available for the ${<scheme>:<ref>} references
+func RegisterSecretBackend(scheme string, backend SecretBackend) {
+	secretBackendsMu.Lock()
+	defer secretBackendsMu.Unlock()
+
+	secretBackends[scheme] = backend
+}
+
+// SecretSchemes returns the schemes of the registered backends
+func SecretSchemes() []string {
+	secretBackendsMu.RLock()
+	defer secretBackendsMu.RUnlock()
+
+	schemes := []string{}
+	for scheme := range secretBackends {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+
+	return schemes
+}
+
+// Interpolate replaces the references of the string by their value
+func Interpolate(value string) (string, error) {
+	var err error
+
+	resolved := reference.ReplaceAllStringFunc(value, func(match string) string {
+		if err != nil {
+			return match
+		}
+
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+
+		groups := reference.FindStringSubmatch(match)
+		scheme, ref := groups[1], groups[2]
+
+		secretBackendsMu.RLock()
+		backend, ok := secretBackends[scheme]
+		secretBackendsMu.RUnlock()
+
+		if !ok {
+			err = fmt.Errorf("unknown secret backend %q in %s, expected one of %v", scheme, match, SecretSchemes())
+			return match
+		}
+
+		var secret string
+		secret, err = backend.Resolve(ref)
+		if err != nil {
+			err = fmt.Errorf("failed to resolve %s: %w", match, err)
+		}
+
+		return secret
+	})
+
+	return resolved, err
+}
+
+// interpolateSettings resolves the references in the string values of the
+// settings read by viper, before they are decoded so numbers can be referenced too
+func interpolateSettings(settings map[string]interface{}, path string) error {
+	for key, value := range settings {
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+
+		switch v := value.(type) {
+		case string:
+			resolved, err := Interpolate(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", keyPath, err)
+			}
+			settings[key] = resolved
+		case map[string]interface{}:
+			if err := interpolateSettings(v, keyPath); err != nil {
+				return err
+			}
+		case []interface{}:
+			for i, item := range v {
+				if s, ok := item.(string); ok {
+					resolved, err := Interpolate(s)
+					if err != nil {
+						return fmt.Errorf("%s[%d]: %w", keyPath, i, err)
+					}
+					v[i] = resolved
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func resolveEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("the environment variable %s is not set", name)
+	}
+
+	return value, nil
+}
+
+func resolveFile(path string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[2:])
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,236 @@
+// Code generated by vllmgen from internal/engines/vllm. DO NOT EDIT.
+
+package config
+
+// VLLMConfig holds the arguments of vllm serve, it covers every vLLM version
+// with a spec in internal/engines/vllm
+type VLLMConfig struct {
+	// Name or path of the huggingface model to use.
+	Model string `mapstructure:"model" validate:"required"`
+	// The task to use the model for.
+	Task *string `mapstructure:"task" json:"task" validate:"omitempty,oneof=auto generate embedding embed classify score reward"`
+	// Name or path of the huggingface tokenizer to use, defaults to the model.
+	Tokenizer *string `mapstructure:"tokenizer" json:"tokenizer" validate:"omitempty"`
+	// Name or path of the huggingface config to use, defaults to the model.
+	HFConfigPath *string `mapstructure:"hf-config-path" json:"hf-config-path" validate:"omitempty"`
+	// Skip initialization of tokenizer and detokenizer.
+	SkipTokenizerInit *bool `mapstructure:"skip-tokenizer-init" json:"skip-tokenizer-init"`
+	// The specific model version to use, a branch name, a tag name or a commit id.
+	Revision *string `mapstructure:"revision" json:"revision" validate:"omitempty"`
+	// The specific revision to use for the model code on the Hugging Face Hub.
+	CodeRevision *string `mapstructure:"code-revision" json:"code-revision" validate:"omitempty"`
+	// Revision of the huggingface tokenizer to use.
+	TokenizerRevision *string `mapstructure:"tokenizer-revision" json:"tokenizer-revision" validate:"omitempty"`
+	// The tokenizer mode.
+	TokenizerMode *string `mapstructure:"tokenizer-mode" json:"tokenizer-mode" validate:"omitempty,oneof=auto slow mistral"`
+	// Trust remote code from huggingface.
+	TrustRemoteCode *bool `mapstructure:"trust-remote-code" json:"trust-remote-code"`
+	// Allow API requests to read local images or videos from this directory.
+	AllowedLocalMediaPath *string `mapstructure:"allowed-local-media-path" json:"allowed-local-media-path" validate:"omitempty"`
+	// Directory to download and load the weights.
+	DownloadDir *string `mapstructure:"download-dir" json:"download-dir" validate:"omitempty"`
+	// The format of the model weights to load.
+	LoadFormat *string `mapstructure:"load-format" json:"load-format" validate:"omitempty,oneof=auto pt safetensors npcache dummy tensorizer sharded-state gguf bitsandbytes mistral runai-streamer"`
+	// The format of the model config to load.
+	ConfigFormat *string `mapstructure:"config-format" json:"config-format" validate:"omitempty,oneof=auto hf mistral"`
+	// Data type for model weights and activations.
+	Dtype *string `mapstructure:"dtype" json:"dtype" validate:"omitempty,oneof=auto half float16 bfloat16 float float32"`
+	// Data type for kv cache storage, auto uses the model data type.
+	KVCacheDtype *string `mapstructure:"kv-cache-dtype" json:"kv-cache-dtype" validate:"omitempty,oneof=auto fp8 fp8-e5m2 fp8-e4m3"`
+	// Model context length, derived from the model config when unset.
+	MaxModelLen *int `mapstructure:"max-model-len" json:"max-model-len" validate:"omitempty"`
+	// Which engine will be used for guided decoding by default. Accepts a backend name followed by options, e.g. xgrammar:disable-any-whitespace.
+	GuidedDecodingBackend *string `mapstructure:"guided-decoding-backend" json:"guided-decoding-backend" validate:"omitempty"`
+	// Regex pattern of the logits processors allowed in the logits_processors request argument.
+	LogitsProcessorPattern *string `mapstructure:"logits-processor-pattern" json:"logits-processor-pattern" validate:"omitempty"`
+	// Which implementation of the model to use.
+	ModelImpl *string `mapstructure:"model-impl" json:"model-impl" validate:"omitempty,oneof=auto vllm transformers"`
+	// Backend to use for distributed model workers.
+	DistributedExecutorBackend *string `mapstructure:"distributed-executor-backend" json:"distributed-executor-backend" validate:"omitempty,oneof=ray mp uni external-launcher"`
+	// Number of pipeline stages.
+	PipelineParallelSize *int `mapstructure:"pipeline-parallel-size" json:"pipeline-parallel-size" validate:"omitempty"`
+	// Number of tensor parallel replicas.
+	TensorParallelSize *int `mapstructure:"tensor-parallel-size" json:"tensor-parallel-size" validate:"omitempty"`
+	// Number of data parallel replicas.
+	DataParallelSize *int `mapstructure:"data-parallel-size" json:"data-parallel-size" validate:"omitempty"`
+	// Use expert parallelism instead of tensor parallelism for MoE layers.
+	EnableExpertParallel *bool `mapstructure:"enable-expert-parallel" json:"enable-expert-parallel"`
+	// Load the model sequentially in multiple batches.
+	MaxParallelLoadingWorkers *int `mapstructure:"max-parallel-loading-workers" json:"max-parallel-loading-workers" validate:"omitempty"`
+	// Profile the ray workers with nsight.
+	RayWorkersUseNsight *bool `mapstructure:"ray-workers-use-nsight" json:"ray-workers-use-nsight"`
+	// Token block size for contiguous chunks of tokens.
+	BlockSize *int `mapstructure:"block-size" json:"block-size" validate:"omitempty,oneof=8 16 32 64 128"`
+	// Enables automatic prefix caching.
+	EnablePrefixCaching *bool `mapstructure:"enable-prefix-caching" json:"enable-prefix-caching" negatable:"true"`
+	// Hash algorithm used for prefix caching.
+	PrefixCachingHashAlgo *string `mapstructure:"prefix-caching-hash-algo" json:"prefix-caching-hash-algo" validate:"omitempty,oneof=builtin sha256"`
+	// Disables sliding window, capping to sliding window size.
+	DisableSlidingWindow *bool `mapstructure:"disable-sliding-window" json:"disable-sliding-window"`
+	// [DEPRECATED] block manager v1 has been removed, this flag has no effect.
+	UseV2BlockManager *bool `mapstructure:"use-v2-block-manager" json:"use-v2-block-manager"`
+	// Experimental scheduling config necessary for speculative decoding.
+	NumLookaheadSlots *int `mapstructure:"num-lookahead-slots" json:"num-lookahead-slots" validate:"omitempty"`
+	// Random seed for operations.
+	Seed *int `mapstructure:"seed" json:"seed" validate:"omitempty"`
+	// CPU swap space size (GiB) per GPU.
+	SwapSpace *int `mapstructure:"swap-space" json:"swap-space" validate:"omitempty"`
+	// The space in GiB to offload to CPU, per GPU.
+	CPUOffloadGB *int `mapstructure:"cpu-offload-gb" json:"cpu-offload-gb" validate:"omitempty"`
+	// The fraction of GPU memory to be used for the model executor, from 0 to 1.
+	GPUMemoryUtilization *float64 `mapstructure:"gpu-memory-utilization" json:"gpu-memory-utilization" validate:"omitempty"`
+	// If specified, ignore GPU profiling result and use this number of GPU blocks.
+	NumGPUBlocksOverride *int `mapstructure:"num-gpu-blocks-override" json:"num-gpu-blocks-override" validate:"omitempty"`
+	// Maximum number of batched tokens per iteration.
+	MaxNumBatchedTokens *int `mapstructure:"max-num-batched-tokens" json:"max-num-batched-tokens" validate:"omitempty"`
+	// For chunked prefill, the max number of concurrent partial prefills.
+	MaxNumPartialPrefills *int `mapstructure:"max-num-partial-prefills" json:"max-num-partial-prefills" validate:"omitempty"`
+	// For chunked prefill, the maximum number of prompts longer than long-prefill-token-threshold prefilled concurrently.
+	MaxLongPartialPrefills *int `mapstructure:"max-long-partial-prefills" json:"max-long-partial-prefills" validate:"omitempty"`
+	// For chunked prefill, a request is considered long if the prompt is longer than this number of tokens.
+	LongPrefillTokenThreshold *int `mapstructure:"long-prefill-token-threshold" json:"long-prefill-token-threshold" validate:"omitempty"`
+	// Maximum number of sequences per iteration.
+	MaxNumSeqs *int `mapstructure:"max-num-seqs" json:"max-num-seqs" validate:"omitempty"`
+	// Max number of log probs to return when logprobs is specified in the sampling parameters.
+	MaxLogprobs *int `mapstructure:"max-logprobs" json:"max-logprobs" validate:"omitempty"`
+	// Disable logging statistics.
+	DisableLogStats *bool `mapstructure:"disable-log-stats" json:"disable-log-stats"`
+	// Method used to quantize the weights.
+	Quantization *string `mapstructure:"quantization" json:"quantization" validate:"omitempty,oneof=aqlm awq deepspeedfp tpu-int8 fp8 fbgemm-fp8 modelopt marlin gguf gptq-marlin-24 gptq-marlin awq-marlin gptq compressed-tensors bitsandbytes qqq hqq experts-int8 neuron-quant ipex quark moe-wna16 None ptpc_fp8 torchao"`
+	// RoPE scaling configuration in JSON format.
+	RopeScaling *string `mapstructure:"rope-scaling" json:"rope-scaling" validate:"omitempty"`
+	// RoPE theta, use with rope-scaling.
+	RopeTheta *float64 `mapstructure:"rope-theta" json:"rope-theta" validate:"omitempty"`
+	// Extra arguments for the HuggingFace config, in JSON format.
+	HFOverrides *string `mapstructure:"hf-overrides" json:"hf-overrides" validate:"omitempty"`
+	// Always use eager-mode PyTorch instead of CUDA graphs.
+	EnforceEager *bool `mapstructure:"enforce-eager" json:"enforce-eager"`
+	// Maximum sequence length covered by CUDA graphs.
+	MaxSeqLenToCapture *int `mapstructure:"max-seq-len-to-capture" json:"max-seq-len-to-capture" validate:"omitempty"`
+	// See ParallelConfig.
+	DisableCustomAllReduce *bool `mapstructure:"disable-custom-all-reduce" json:"disable-custom-all-reduce"`
+	// [DEPRECATED] Size of tokenizer pool to use for asynchronous tokenization, 0 for synchronous tokenization.
+	TokenizerPoolSize *int `mapstructure:"tokenizer-pool-size" json:"tokenizer-pool-size" validate:"omitempty"`
+	// [DEPRECATED] Type of tokenizer pool to use for asynchronous tokenization.
+	TokenizerPoolType *string `mapstructure:"tokenizer-pool-type" json:"tokenizer-pool-type" validate:"omitempty"`
+	// [DEPRECATED] Extra config for the tokenizer pool, in JSON format.
+	TokenizerPoolExtraConfig *string `mapstructure:"tokenizer-pool-extra-config" json:"tokenizer-pool-extra-config" validate:"omitempty"`
+	// For each multimodal plugin, limit how many input instances to allow for each prompt, in JSON format.
+	LimitMMPerPrompt *string `mapstructure:"limit-mm-per-prompt" json:"limit-mm-per-prompt" validate:"omitempty"`
+	// Overrides for the multimodal input mapping and processing, in JSON format.
+	MMProcessorKwargs *string `mapstructure:"mm-processor-kwargs" json:"mm-processor-kwargs" validate:"omitempty"`
+	// Disable the caching of the multimodal preprocessor and mapper.
+	DisableMMPreprocessorCache *bool `mapstructure:"disable-mm-preprocessor-cache" json:"disable-mm-preprocessor-cache"`
+	// Enable handling of LoRA adapters.
+	EnableLora *bool `mapstructure:"enable-lora" json:"enable-lora"`
+	// Enable bias for LoRA adapters.
+	EnableLoraBias *bool `mapstructure:"enable-lora-bias" json:"enable-lora-bias"`
+	// Max number of LoRAs in a single batch.
+	MaxLoras *int `mapstructure:"max-loras" json:"max-loras" validate:"omitempty"`
+	// Max LoRA rank.
+	MaxLoraRank *int `mapstructure:"max-lora-rank" json:"max-lora-rank" validate:"omitempty"`
+	// Maximum size of extra vocabulary that can be present in a LoRA adapter.
+	LoraExtraVocabSize *int `mapstructure:"lora-extra-vocab-size" json:"lora-extra-vocab-size" validate:"omitempty"`
+	// Data type for LoRA, auto uses the model data type.
+	LoraDtype *string `mapstructure:"lora-dtype" json:"lora-dtype" validate:"omitempty,oneof=auto float16 bfloat16"`
+	// Specify multiple scaling factors to allow multiple LoRA adapters trained with those factors.
+	LongLoraScalingFactors *string `mapstructure:"long-lora-scaling-factors" json:"long-lora-scaling-factors" validate:"omitempty"`
+	// Maximum number of LoRAs to store in CPU memory.
+	MaxCPULoras *int `mapstructure:"max-cpu-loras" json:"max-cpu-loras" validate:"omitempty"`
+	// Use fully sharded LoRA layers.
+	FullyShardedLoras *bool `mapstructure:"fully-sharded-loras" json:"fully-sharded-loras"`
+	// Enable handling of prompt adapters.
+	EnablePromptAdapter *bool `mapstructure:"enable-prompt-adapter" json:"enable-prompt-adapter"`
+	// Max number of prompt adapters in a batch.
+	MaxPromptAdapters *int `mapstructure:"max-prompt-adapters" json:"max-prompt-adapters" validate:"omitempty"`
+	// Max number of prompt adapter tokens.
+	MaxPromptAdapterToken *int `mapstructure:"max-prompt-adapter-token" json:"max-prompt-adapter-token" validate:"omitempty"`
+	// Device type for vLLM execution.
+	Device *string `mapstructure:"device" json:"device" validate:"omitempty,oneof=auto cuda neuron cpu openvino tpu xpu hpu"`
+	// Maximum number of forward steps per scheduler call. Not supported by the V1 engine.
+	NumSchedulerSteps *int `mapstructure:"num-scheduler-steps" json:"num-scheduler-steps" validate:"omitempty"`
+	// Stream all the outputs of multi-step scheduling.
+	MultiStepStreamOutputs *bool `mapstructure:"multi-step-stream-outputs" json:"multi-step-stream-outputs"`
+	// Apply a delay (of delay factor multiplied by previous prompt latency) before scheduling next prompt.
+	SchedulerDelayFactor *float64 `mapstructure:"scheduler-delay-factor" json:"scheduler-delay-factor" validate:"omitempty"`
+	// Chunk the prefill requests based on max-num-batched-tokens.
+	EnableChunkedPrefill *bool `mapstructure:"enable-chunked-prefill" json:"enable-chunked-prefill"`
+	// The name of the draft model to be used in speculative decoding.
+	SpeculativeModel *string `mapstructure:"speculative-model" json:"speculative-model" validate:"omitempty"`
+	// Method used to quantize the weights of the speculative model.
+	SpeculativeModelQuantization *string `mapstructure:"speculative-model-quantization" json:"speculative-model-quantization" validate:"omitempty,oneof=aqlm awq deepspeedfp tpu-int8 fp8 fbgemm-fp8 modelopt marlin gguf gptq-marlin-24 gptq-marlin awq-marlin gptq compressed-tensors bitsandbytes qqq hqq experts-int8 neuron-quant ipex quark moe-wna16 None"`
+	// The number of speculative tokens to sample from the draft model.
+	NumSpeculativeTokens *int `mapstructure:"num-speculative-tokens" json:"num-speculative-tokens" validate:"omitempty"`
+	// Disable the MQA scorer in speculative decoding and fall back to batch expansion.
+	SpeculativeDisableMQAScorer *bool `mapstructure:"speculative-disable-mqa-scorer" json:"speculative-disable-mqa-scorer"`
+	// Number of tensor parallel replicas for the draft model.
+	SpeculativeDraftTensorParallelSize *int `mapstructure:"speculative-draft-tensor-parallel-size" json:"speculative-draft-tensor-parallel-size" validate:"omitempty"`
+	// The maximum sequence length supported by the draft model.
+	SpeculativeMaxModelLen *int `mapstructure:"speculative-max-model-len" json:"speculative-max-model-len" validate:"omitempty"`
+	// Disable speculative decoding for new requests when the number of enqueued requests is larger than this value.
+	SpeculativeDisableByBatchSize *bool `mapstructure:"speculative-disable-by-batch-size" json:"speculative-disable-by-batch-size"`
+	// Max size of the window for ngram prompt lookup in speculative decoding.
+	NgramPromptLookupMax *int `mapstructure:"ngram-prompt-lookup-max" json:"ngram-prompt-lookup-max" validate:"omitempty"`
+	// Min size of the window for ngram prompt lookup in speculative decoding.
+	NgramPromptLookupMin *int `mapstructure:"ngram-prompt-lookup-min" json:"ngram-prompt-lookup-min" validate:"omitempty"`
+	// The acceptance method to use during draft token verification in speculative decoding.
+	SpecDecodingAcceptanceMethod *string `mapstructure:"spec-decoding-acceptance-method" json:"spec-decoding-acceptance-method" validate:"omitempty,oneof=rejection-sampler typical-acceptance-sampler"`
+	// Lower bound threshold for the posterior probability of a token to be accepted.
+	TypicalAcceptanceSamplerPosteriorThreshold *float64 `mapstructure:"typical-acceptance-sampler-posterior-threshold" json:"typical-acceptance-sampler-posterior-threshold" validate:"omitempty"`
+	// Scaling factor for the entropy-based threshold for token acceptance.
+	TypicalAcceptanceSamplerPosteriorAlpha *float64 `mapstructure:"typical-acceptance-sampler-posterior-alpha" json:"typical-acceptance-sampler-posterior-alpha" validate:"omitempty"`
+	// Do not return the token log probabilities during speculative decoding.
+	DisableLogprobsDuringSpecDecoding *bool `mapstructure:"disable-logprobs-during-spec-decoding" json:"disable-logprobs-during-spec-decoding"`
+	// The configuration for speculative decoding, in JSON format.
+	SpeculativeConfig *string `mapstructure:"speculative-config" json:"speculative-config" validate:"omitempty"`
+	// Extra config for the model loader, in JSON format.
+	ModelLoaderExtraConfig *string `mapstructure:"model-loader-extra-config" json:"model-loader-extra-config" validate:"omitempty"`
+	// The patterns to ignore when loading the model.
+	IgnorePatterns *string `mapstructure:"ignore-patterns" json:"ignore-patterns" validate:"omitempty"`
+	// If recompute, the engine performs preemption by recomputing, if swap, by block swapping.
+	PreemptionMode *string `mapstructure:"preemption-mode" json:"preemption-mode" validate:"omitempty"`
+	// The model name used in the API, defaults to the model.
+	ServedModelName *string `mapstructure:"served-model-name" json:"served-model-name" validate:"omitempty"`
+	// Enable deprecated Prometheus metrics that have been hidden since the specified version.
+	ShowHiddenMetricsForVersion *string `mapstructure:"show-hidden-metrics-for-version" json:"show-hidden-metrics-for-version" validate:"omitempty"`
+	// Name or path of the QLoRA adapter.
+	QLoraAdapterNameOrPath *string `mapstructure:"qlora-adapter-name-or-path" json:"qlora-adapter-name-or-path" validate:"omitempty"`
+	// Target URL to which OpenTelemetry traces will be sent.
+	OtlpTracesEndpoint *string `mapstructure:"otlp-traces-endpoint" json:"otlp-traces-endpoint" validate:"omitempty"`
+	// Collect detailed traces for the specified modules.
+	CollectDetailedTraces *bool `mapstructure:"collect-detailed-traces" json:"collect-detailed-traces"`
+	// Disable async output processing.
+	DisableAsyncOutputProc *bool `mapstructure:"disable-async-output-proc" json:"disable-async-output-proc"`
+	// The scheduling policy to use.
+	SchedulingPolicy *string `mapstructure:"scheduling-policy" json:"scheduling-policy" validate:"omitempty,oneof=fcfs priority"`
+	// The scheduler class to use.
+	SchedulerCls *string `mapstructure:"scheduler-cls" json:"scheduler-cls" validate:"omitempty"`
+	// Override or set neuron device configuration, in JSON format.
+	OverrideNeuronConfig *string `mapstructure:"override-neuron-config" json:"override-neuron-config" validate:"omitempty"`
+	// Override or set the pooling method for pooling models, in JSON format.
+	OverridePoolerConfig *string `mapstructure:"override-pooler-config" json:"override-pooler-config" validate:"omitempty"`
+	// torch.compile configuration for the model, an optimization level or a JSON config.
+	CompilationConfig *string `mapstructure:"compilation-config" json:"compilation-config" validate:"omitempty"`
+	// The configurations for distributed KV cache transfer, in JSON format.
+	KVTransferConfig *string `mapstructure:"kv-transfer-config" json:"kv-transfer-config" validate:"omitempty"`
+	// The worker class to use for distributed execution.
+	WorkerCls *string `mapstructure:"worker-cls" json:"worker-cls" validate:"omitempty"`
+	// The worker extension class on top of the worker class.
+	WorkerExtensionCls *string `mapstructure:"worker-extension-cls" json:"worker-extension-cls" validate:"omitempty"`
+	// The folder path to the generation config, auto loads it from the model path.
+	GenerationConfig *string `mapstructure:"generation-config" json:"generation-config" validate:"omitempty"`
+	// Overrides or sets generation config, in JSON format.
+	OverrideGenerationConfig *string `mapstructure:"override-generation-config" json:"override-generation-config" validate:"omitempty"`
+	// Enable sleep mode for the engine.
+	EnableSleepMode *bool `mapstructure:"enable-sleep-mode" json:"enable-sleep-mode"`
+	// Enable dynamic calculation of k_scale and v_scale when kv-cache-dtype is fp8.
+	CalculateKVScales *bool `mapstructure:"calculate-kv-scales" json:"calculate-kv-scales"`
+	// Additional config for the specified platform, in JSON format.
+	AdditionalConfig *string `mapstructure:"additional-config" json:"additional-config" validate:"omitempty"`
+	// Enable the reasoning content of the model.
+	EnableReasoning *bool `mapstructure:"enable-reasoning" json:"enable-reasoning"`
+	// The reasoning parser to use to extract the reasoning content.
+	ReasoningParser *string `mapstructure:"reasoning-parser" json:"reasoning-parser" validate:"omitempty,oneof=deepseek_r1 granite"`
+	// Disable cascade attention for V1.
+	DisableCascadeAttn *bool `mapstructure:"disable-cascade-attn" json:"disable-cascade-attn"`
+}
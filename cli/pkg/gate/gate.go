@@ -0,0 +1,143 @@
+package gate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/heka-ai/benchmark-cli/pkg/results"
+)
+
+// Outcome is the evaluation of a rule against the two runs
+type Outcome struct {
+	Rule      Rule
+	Baseline  float64
+	Current   float64
+	ChangePct float64
+	Passed    bool
+	// why the rule failed, empty when it passed
+	Failures []string
+}
+
+// Report is the evaluation of all the rules
+type Report struct {
+	BaselineID string
+	CurrentID  string
+	Outcomes   []Outcome
+}
+
+// Passed is true when every rule passed
+func (r *Report) Passed() bool {
+	for _, o := range r.Outcomes {
+		if !o.Passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Evaluate checks the current run against the baseline run
+func Evaluate(baseline, current *results.Results, rules []Rule) (*Report, error) {
+	baselineSummary := baseline.Summary()
+	currentSummary := current.Summary()
+
+	report := &Report{
+		BaselineID: baselineSummary.BenchmarkID,
+		CurrentID:  currentSummary.BenchmarkID,
+	}
+
+	baselineSizes := sampleSizes(baseline)
+	currentSizes := sampleSizes(current)
+
+	for _, rule := range rules {
+		b, baselineErr := metric(baselineSummary, baselineSizes, rule.Metric)
+		c, currentErr := metric(currentSummary, currentSizes, rule.Metric)
+
+		// a metric without values fails the rule instead of comparing zeros
+		if errors.Is(baselineErr, errEmptySample) || errors.Is(currentErr, errEmptySample) {
+			outcome := Outcome{Rule: rule, Baseline: b, Current: c}
+			if baselineErr != nil {
+				outcome.Failures = append(outcome.Failures, "baseline: "+baselineErr.Error())
+			}
+			if currentErr != nil {
+				outcome.Failures = append(outcome.Failures, "current: "+currentErr.Error())
+			}
+
+			report.Outcomes = append(report.Outcomes, outcome)
+			continue
+		}
+
+		if err := errors.Join(baselineErr, currentErr); err != nil {
+			return nil, err
+		}
+
+		report.Outcomes = append(report.Outcomes, evaluate(rule, b, c))
+	}
+
+	return report, nil
+}
+
+func evaluate(rule Rule, baseline, current float64) Outcome {
+	outcome := Outcome{
+		Rule:     rule,
+		Baseline: baseline,
+		Current:  current,
+	}
+
+	switch {
+	case baseline != 0:
+		outcome.ChangePct = (current - baseline) / math.Abs(baseline) * 100
+	case current != 0:
+		outcome.ChangePct = math.Inf(int(math.Copysign(1, current)))
+	}
+
+	if rule.MaxIncreasePct != nil && outcome.ChangePct > *rule.MaxIncreasePct {
+		outcome.Failures = append(outcome.Failures, fmt.Sprintf("increased by %s, more than the allowed %.2f%%", formatPct(outcome.ChangePct), *rule.MaxIncreasePct))
+	}
+
+	if rule.MaxDecreasePct != nil && -outcome.ChangePct > *rule.MaxDecreasePct {
+		outcome.Failures = append(outcome.Failures, fmt.Sprintf("decreased by %s, more than the allowed %.2f%%", formatPct(-outcome.ChangePct), *rule.MaxDecreasePct))
+	}
+
+	if rule.Max != nil && current > *rule.Max {
+		outcome.Failures = append(outcome.Failures, fmt.Sprintf("%.4g is above the maximum %.4g", current, *rule.Max))
+	}
+
+	if rule.Min != nil && current < *rule.Min {
+		outcome.Failures = append(outcome.Failures, fmt.Sprintf("%.4g is below the minimum %.4g", current, *rule.Min))
+	}
+
+	outcome.Passed = len(outcome.Failures) == 0
+
+	return outcome
+}
+
+// Threshold describes the bounds of the rule
+func (r Rule) Threshold() string {
+	parts := []string{}
+
+	if r.MaxIncreasePct != nil {
+		parts = append(parts, fmt.Sprintf("+%.2f%% max", *r.MaxIncreasePct))
+	}
+	if r.MaxDecreasePct != nil {
+		parts = append(parts, fmt.Sprintf("-%.2f%% max", *r.MaxDecreasePct))
+	}
+	if r.Max != nil {
+		parts = append(parts, fmt.Sprintf("<= %.4g", *r.Max))
+	}
+	if r.Min != nil {
+		parts = append(parts, fmt.Sprintf(">= %.4g", *r.Min))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func formatPct(pct float64) string {
+	if math.IsInf(pct, 0) {
+		return "inf%"
+	}
+
+	return fmt.Sprintf("%.2f%%", pct)
+}
@@ -0,0 +1,223 @@
+package gate
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/heka-ai/benchmark-cli/pkg/results"
+)
+
+func float(v float64) *float64 {
+	return &v
+}
+
+// run has one request per TTFT, each with two inter-token latencies of 10ms
+// and three output tokens, the requests with an error failed
+func run(id string, throughput float64, ttfts []float64, errs []string) *results.Results {
+	itls := [][]float64{}
+	outputLens := []int{}
+	for range ttfts {
+		itls = append(itls, []float64{0.01, 0.01})
+		outputLens = append(outputLens, 3)
+	}
+
+	return &results.Results{
+		BenchmarkID:      &id,
+		OutputThroughput: &throughput,
+		Ttfts:            &ttfts,
+		Itls:             &itls,
+		OutputLens:       &outputLens,
+		Errors:           &errs,
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	baseline := run("baseline", 100, []float64{0.1, 0.1}, []string{"", ""})
+
+	tests := []struct {
+		name       string
+		current    *results.Results
+		rule       Rule
+		wantPassed bool
+		wantFailed string
+	}{
+		{
+			name:       "latency within the increase",
+			current:    run("current", 100, []float64{0.105, 0.105}, []string{"", ""}),
+			rule:       Rule{Name: "ttft", Metric: "mean_ttft_ms", MaxIncreasePct: float(10)},
+			wantPassed: true,
+		},
+		{
+			name:       "latency above the increase",
+			current:    run("current", 100, []float64{0.2, 0.2}, []string{"", ""}),
+			rule:       Rule{Name: "ttft", Metric: "mean_ttft_ms", MaxIncreasePct: float(10)},
+			wantFailed: "increased by 100.00%",
+		},
+		{
+			name:       "throughput above the decrease",
+			current:    run("current", 50, []float64{0.1, 0.1}, []string{"", ""}),
+			rule:       Rule{Name: "throughput", Metric: "output_throughput", MaxDecreasePct: float(20)},
+			wantFailed: "decreased by 50.00%",
+		},
+		{
+			name:       "absolute maximum",
+			current:    run("current", 100, []float64{0.3, 0.3}, []string{"", ""}),
+			rule:       Rule{Name: "ttft", Metric: "p99_ttft_ms", Max: float(250)},
+			wantFailed: "above the maximum",
+		},
+		{
+			name:       "absolute minimum",
+			current:    run("current", 100, []float64{0.1, 0.1}, []string{"", ""}),
+			rule:       Rule{Name: "throughput", Metric: "output_throughput", Min: float(50)},
+			wantPassed: true,
+		},
+		{
+			name:       "failed requests are left out",
+			current:    run("current", 100, []float64{0.1, 0.001}, []string{"", "timeout"}),
+			rule:       Rule{Name: "ttft", Metric: "mean_ttft_ms", MaxDecreasePct: float(10)},
+			wantPassed: true,
+		},
+		{
+			name:       "empty sample",
+			current:    run("current", 100, []float64{0.1, 0.1}, []string{"timeout", "timeout"}),
+			rule:       Rule{Name: "ttft", Metric: "mean_ttft_ms", Max: float(1000)},
+			wantFailed: "current: no successful request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Evaluate(baseline, tt.current, []Rule{tt.rule})
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+
+			outcome := report.Outcomes[0]
+			if outcome.Passed != tt.wantPassed || report.Passed() != tt.wantPassed {
+				t.Errorf("Passed = %v, want %v, failures %q", outcome.Passed, tt.wantPassed, outcome.Failures)
+			}
+
+			if tt.wantFailed != "" && !strings.Contains(strings.Join(outcome.Failures, "; "), tt.wantFailed) {
+				t.Errorf("Failures = %q, want %q", outcome.Failures, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestEvaluateUnknownMetric(t *testing.T) {
+	r := run("run", 100, []float64{0.1}, []string{""})
+
+	if _, err := Evaluate(r, r, []Rule{{Metric: "p42_ttft_ms", Max: float(1)}}); err == nil {
+		t.Errorf("Evaluate() error = nil, want the unknown metric")
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	baseline := run("baseline", 100, []float64{0.1}, []string{""})
+	current := run("current", 50, []float64{0.1}, []string{""})
+
+	report, err := Evaluate(baseline, current, []Rule{
+		{Name: "ttft", Metric: "mean_ttft_ms", MaxIncreasePct: float(10)},
+		{Name: "throughput", Metric: "output_throughput", MaxDecreasePct: float(20)},
+	})
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteJUnit(&out); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(out.Bytes(), &suites); err != nil {
+		t.Fatalf("the JUnit output is not valid XML: %v", err)
+	}
+
+	if suites.Tests != 2 || suites.Failures != 1 || len(suites.Suites) != 1 {
+		t.Fatalf("testsuites = %d tests, %d failures, %d suites, want 2, 1, 1", suites.Tests, suites.Failures, len(suites.Suites))
+	}
+
+	suite := suites.Suites[0]
+	if suite.Name != "current vs baseline" {
+		t.Errorf("testsuite name = %q, want current vs baseline", suite.Name)
+	}
+
+	if suite.TestCases[0].Failure != nil {
+		t.Errorf("the ttft test case failed: %+v", suite.TestCases[0].Failure)
+	}
+
+	failure := suite.TestCases[1].Failure
+	if failure == nil || failure.Type != "regression" || !strings.Contains(failure.Message, "decreased by 50.00%") {
+		t.Errorf("the throughput failure = %+v, want the decrease", failure)
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			content: `[[rules]]
+metric = "p99_ttft_ms"
+max_increase_pct = 10
+`,
+		},
+		{
+			name: "unknown metric",
+			content: `[[rules]]
+metric = "p99_ttft"
+max = 10
+`,
+			wantErr: "unknown metric",
+		},
+		{
+			name: "no threshold",
+			content: `[[rules]]
+metric = "p99_ttft_ms"
+`,
+			wantErr: "no threshold set",
+		},
+		{
+			name: "negative percentage",
+			content: `[[rules]]
+metric = "p99_ttft_ms"
+max_increase_pct = -1
+`,
+			wantErr: "MaxIncreasePct",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			rules, err := LoadRules(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadRules() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("LoadRules() error = %v", err)
+			}
+
+			// the name defaults to the metric
+			if len(rules) != 1 || rules[0].Name != "p99_ttft_ms" {
+				t.Errorf("LoadRules() = %+v, want one rule named after its metric", rules)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package gate
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as JUnit XML, one test case per rule
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suite := junitTestSuite{
+		Name:  fmt.Sprintf("%s vs %s", r.CurrentID, r.BaselineID),
+		Tests: len(r.Outcomes),
+	}
+
+	for _, o := range r.Outcomes {
+		testCase := junitTestCase{
+			Name:      o.Rule.Name,
+			ClassName: "bench.gate",
+			SystemOut: fmt.Sprintf("%s: baseline %.4f, current %.4f, change %s (threshold %s)", o.Rule.Metric, o.Baseline, o.Current, formatPct(o.ChangePct), o.Rule.Threshold()),
+		}
+
+		if !o.Passed {
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message: strings.Join(o.Failures, "; "),
+				Type:    "regression",
+				Content: testCase.SystemOut,
+			}
+		}
+
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	suites := junitTestSuites{
+		Name:     "bench gate",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,138 @@
+package gate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/heka-ai/benchmark-cli/pkg/results"
+	"github.com/spf13/viper"
+)
+
+// Rule is a threshold a metric of the current run must satisfy
+type Rule struct {
+	// human readable name of the rule, defaults to the metric
+	Name string `mapstructure:"name"`
+	// metric of the run summary, e.g. p99_ttft_ms or output_throughput
+	Metric string `mapstructure:"metric" validate:"required"`
+
+	// maximum relative increase compared to the baseline, in percent
+	MaxIncreasePct *float64 `mapstructure:"max_increase_pct" validate:"omitempty,gte=0"`
+	// maximum relative decrease compared to the baseline, in percent
+	MaxDecreasePct *float64 `mapstructure:"max_decrease_pct" validate:"omitempty,gte=0"`
+
+	// absolute bounds of the current value
+	Max *float64 `mapstructure:"max"`
+	Min *float64 `mapstructure:"min"`
+}
+
+type rulesFile struct {
+	Rules []Rule `mapstructure:"rules" validate:"required,min=1,dive"`
+}
+
+// LoadRules reads the rules from a TOML file
+func LoadRules(path string) ([]Rule, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	var file rulesFile
+	if err := v.Unmarshal(&file); err != nil {
+		return nil, err
+	}
+
+	if err := validator.New().Struct(file); err != nil {
+		return nil, err
+	}
+
+	for i, rule := range file.Rules {
+		if _, err := metric(results.SummaryRow{}, nil, rule.Metric); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+
+		if rule.MaxIncreasePct == nil && rule.MaxDecreasePct == nil && rule.Max == nil && rule.Min == nil {
+			return nil, fmt.Errorf("rule %d (%s): no threshold set", i, rule.Metric)
+		}
+
+		if rule.Name == "" {
+			file.Rules[i].Name = rule.Metric
+		}
+	}
+
+	return file.Rules, nil
+}
+
+// Metrics lists the metrics rules can be written against
+func Metrics() []string {
+	t := reflect.TypeOf(results.SummaryRow{})
+
+	metrics := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		if isNumber(t.Field(i).Type.Kind()) {
+			metrics = append(metrics, jsonName(t.Field(i)))
+		}
+	}
+
+	return metrics
+}
+
+// errEmptySample is returned for a latency metric of a run without any
+// successful request, its summary value is 0 and would pass the rules
+var errEmptySample = errors.New("no successful request to compute it from")
+
+// sampleSizes counts the values each latency metric of the summary is
+// computed from, keyed by ttft, tpot, itl and e2el
+func sampleSizes(r *results.Results) map[string]int {
+	samples := r.SuccessfulSamples()
+
+	return map[string]int{
+		"ttft": len(samples.Ttft),
+		"tpot": len(samples.Tpot),
+		"itl":  len(samples.Itl),
+		"e2el": len(samples.E2el),
+	}
+}
+
+// metric returns the value of the summary field named after the json tag,
+// the sample sizes are not checked when nil
+func metric(summary results.SummaryRow, sizes map[string]int, name string) (float64, error) {
+	v := reflect.ValueOf(summary)
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) != name {
+			continue
+		}
+
+		// the latency metrics are named <statistic>_<latency>_ms
+		if parts := strings.Split(name, "_"); sizes != nil && len(parts) == 3 && parts[2] == "ms" {
+			if size, ok := sizes[parts[1]]; !ok || size == 0 {
+				return 0, errEmptySample
+			}
+		}
+
+		field := v.Field(i)
+		switch {
+		case field.CanFloat():
+			return field.Float(), nil
+		case field.CanInt():
+			return float64(field.Int()), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown metric %q, expected one of %s", name, strings.Join(Metrics(), ", "))
+}
+
+func jsonName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+func isNumber(kind reflect.Kind) bool {
+	return kind == reflect.Int || kind == reflect.Float64
+}
@@ -0,0 +1,229 @@
+package matrix
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/heka-ai/benchmark-cli/pkg/config"
+)
+
+// Axes of the matrix, in the order they are expanded
+const (
+	AxisModel           = "model"
+	AxisGPUInstanceType = "gpu_instance_type"
+	AxisDtype           = "dtype"
+	AxisQuantization    = "quantization"
+	AxisMaxNumSeqs      = "max_num_seqs"
+)
+
+// Value is the value taken by a variant on an axis of the matrix
+type Value struct {
+	Axis  string
+	Value string
+}
+
+// Variant is a concrete config of the matrix
+type Variant struct {
+	BenchID string
+	// the values of the axes having more than one value, they tell the
+	// variants apart
+	Values []Value
+	Config *config.Config
+	// set when the variant is not a valid config
+	Err error
+}
+
+type axis struct {
+	name   string
+	values []string
+	// slug of the value used in the bench id
+	slug func(value string) string
+	// apply sets the value on the variant config
+	apply func(c *config.Config, value string) error
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slugify(value string) string {
+	return strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToLower(value), "-"), "-")
+}
+
+// the bench id only keeps the name of the model, not its organization
+func modelSlug(model string) string {
+	return slugify(model[strings.LastIndex(model, "/")+1:])
+}
+
+func axes(m *config.MatrixConfig) []axis {
+	maxNumSeqs := []string{}
+	for _, v := range m.MaxNumSeqs {
+		maxNumSeqs = append(maxNumSeqs, strconv.Itoa(v))
+	}
+
+	return []axis{
+		{
+			name:   AxisModel,
+			values: m.Models,
+			slug:   modelSlug,
+			apply: func(c *config.Config, value string) error {
+				// the revisions of the config are the ones of its model
+				if value != c.VLLMConfig.Model {
+					c.VLLMConfig.Revision = nil
+					c.VLLMConfig.CodeRevision = nil
+					c.VLLMConfig.TokenizerRevision = nil
+				}
+				c.VLLMConfig.Model = value
+				return nil
+			},
+		},
+		{
+			name:   AxisGPUInstanceType,
+			values: m.GPUInstanceTypes,
+			slug:   slugify,
+			apply: func(c *config.Config, value string) error {
+				if c.AWSConfig == nil {
+					return fmt.Errorf("matrix.gpu_instance_types needs the [aws] table")
+				}
+				c.AWSConfig.GPUInstanceType = value
+				return nil
+			},
+		},
+		{
+			name:   AxisDtype,
+			values: m.Dtypes,
+			slug:   slugify,
+			apply: func(c *config.Config, value string) error {
+				c.VLLMConfig.Dtype = &value
+				return nil
+			},
+		},
+		{
+			name:   AxisQuantization,
+			values: m.Quantizations,
+			slug: func(value string) string {
+				if isNoQuantization(value) {
+					return "none"
+				}
+				return slugify(value)
+			},
+			apply: func(c *config.Config, value string) error {
+				if isNoQuantization(value) {
+					c.VLLMConfig.Quantization = nil
+					return nil
+				}
+				c.VLLMConfig.Quantization = &value
+				return nil
+			},
+		},
+		{
+			name:   AxisMaxNumSeqs,
+			values: maxNumSeqs,
+			slug: func(value string) string {
+				return "seqs" + value
+			},
+			apply: func(c *config.Config, value string) error {
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					return err
+				}
+				c.VLLMConfig.MaxNumSeqs = &n
+				return nil
+			},
+		},
+	}
+}
+
+func isNoQuantization(value string) bool {
+	return value == "" || strings.EqualFold(value, "none")
+}
+
+// Expand returns a variant for each combination of the axes values, the
+// axes are expanded in order so the variants of a model follow each other.
+// The bench id of a variant is the one of the base config followed by the
+// values of the axes having more than one value, e.g. llama-3-8b-g5-xlarge-seqs256.
+// A config without a [matrix] table has a single variant, the config itself.
+func Expand(base *config.Config) ([]Variant, error) {
+	if base.VLLMConfig == nil {
+		return nil, fmt.Errorf("the matrix needs the [vllm] table")
+	}
+
+	variants := []Variant{newVariant(base, base.BenchID, nil)}
+
+	if base.Matrix == nil {
+		validateVariants(variants)
+		return variants, nil
+	}
+
+	for _, a := range axes(base.Matrix) {
+		if len(a.values) == 0 {
+			continue
+		}
+
+		expanded := []Variant{}
+		for _, variant := range variants {
+			for _, value := range a.values {
+				v := newVariant(variant.Config, variant.BenchID, variant.Values)
+
+				if len(a.values) > 1 {
+					v.BenchID = fmt.Sprintf("%s-%s", v.BenchID, a.slug(value))
+					v.Values = append(v.Values, Value{Axis: a.name, Value: value})
+				}
+
+				if err := a.apply(v.Config, value); err != nil {
+					return nil, err
+				}
+
+				expanded = append(expanded, v)
+			}
+		}
+
+		variants = expanded
+	}
+
+	seen := map[string]bool{}
+	for _, v := range variants {
+		if seen[v.BenchID] {
+			return nil, fmt.Errorf("the matrix gives the bench id %s to several variants, the values of an axis must be distinct", v.BenchID)
+		}
+		seen[v.BenchID] = true
+	}
+
+	validateVariants(variants)
+
+	return variants, nil
+}
+
+// validateVariants sets the bench id of the variants configs and records the
+// validation errors on the variants, the other variants can still run
+func validateVariants(variants []Variant) {
+	for i := range variants {
+		v := &variants[i]
+
+		v.Config.BenchID = v.BenchID
+		if err := config.Validate(v.Config); err != nil {
+			v.Err = err
+		}
+	}
+}
+
+// newVariant copies the config, the tables changed by the axes are copied
+// so the variants never share them
+func newVariant(c *config.Config, benchID string, values []Value) Variant {
+	copied := *c
+	copied.Matrix = nil
+
+	vllm := *c.VLLMConfig
+	copied.VLLMConfig = &vllm
+
+	if c.AWSConfig != nil {
+		aws := *c.AWSConfig
+		copied.AWSConfig = &aws
+	}
+
+	return Variant{
+		BenchID: benchID,
+		Values:  append([]Value{}, values...),
+		Config:  &copied,
+	}
+}
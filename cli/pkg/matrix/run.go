@@ -0,0 +1,270 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/heka-ai/benchmark-cli/internal/bench"
+	"github.com/heka-ai/benchmark-cli/internal/cloud"
+	cloud_generator "github.com/heka-ai/benchmark-cli/internal/cloud/generator"
+	log "github.com/heka-ai/benchmark-cli/internal/logs"
+	"github.com/heka-ai/benchmark-cli/pkg/results"
+)
+
+var logger = log.GetLogger("matrix")
+
+const (
+	pollInterval = 10 * time.Second
+	// the instances boot and start the API
+	instancesTimeout = 15 * time.Minute
+	// the model is downloaded and loaded on the GPU
+	llmTimeout = 45 * time.Minute
+	// there is no limit for the benchmark, it stops when the matrix is cancelled
+	benchmarkPollInterval = 30 * time.Second
+)
+
+// the instances of the variants share the IAM instance profile, creating it
+// from two variants at once fails for the last one
+var createMu sync.Mutex
+
+// Runner runs the variants of a matrix, each one on its own instances
+type Runner struct {
+	// number of variants running at the same time
+	Concurrency int
+	// directory where the results of each variant are written
+	OutputDir string
+	// keep the instances of the variants once they are done
+	Keep bool
+}
+
+// Outcome is the result of a variant
+type Outcome struct {
+	Variant Variant
+	Results *results.Results
+	// file the results were written to
+	File string
+	Err  error
+	// number of times the variant was run, more than one when it was
+	// interrupted and retried
+	Attempts int
+}
+
+// Interrupted tells whether the variant was stopped by a spot interruption
+// and not retried
+func (o *Outcome) Interrupted() bool {
+	return errors.Is(o.Err, bench.ErrInterrupted)
+}
+
+// Run runs the valid variants and returns their outcomes in the order of
+// the variants. The variants that are not valid are not run, their outcome
+// holds the validation error.
+func (r *Runner) Run(ctx context.Context, variants []Variant) []Outcome {
+	outcomes := make([]Outcome, len(variants))
+
+	concurrency := max(r.Concurrency, 1)
+	slots := make(chan struct{}, concurrency)
+
+	wg := sync.WaitGroup{}
+	for i, variant := range variants {
+		outcomes[i].Variant = variant
+
+		if variant.Err != nil {
+			outcomes[i].Err = variant.Err
+			continue
+		}
+
+		wg.Add(1)
+		go func(outcome *Outcome) {
+			defer wg.Done()
+
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				outcome.Err = ctx.Err()
+				return
+			}
+			defer func() { <-slots }()
+
+			r.runWithRetries(ctx, outcome)
+		}(&outcomes[i])
+	}
+	wg.Wait()
+
+	return outcomes
+}
+
+// runWithRetries runs the variant again on new instances when a spot
+// interruption stops it, up to aws.spot_retries times
+func (r *Runner) runWithRetries(ctx context.Context, outcome *Outcome) {
+	retries := 0
+	if outcome.Variant.Config.AWSConfig != nil {
+		retries = outcome.Variant.Config.AWSConfig.SpotRetries
+	}
+
+	for {
+		outcome.Attempts++
+		outcome.Results, outcome.File, outcome.Err = r.runVariant(ctx, outcome.Variant)
+
+		if !outcome.Interrupted() || outcome.Attempts > retries || ctx.Err() != nil {
+			return
+		}
+
+		logger.Warn().Err(outcome.Err).Str("bench_id", outcome.Variant.BenchID).Int("attempt", outcome.Attempts).Msg("The variant was interrupted, running it again on new instances")
+	}
+}
+
+// runVariant creates the instances of the variant, runs the benchmark and
+// writes its results, the instances are destroyed even when the run fails.
+// The instances of an interrupted run are always destroyed, the run can be
+// retried with the same bench id.
+func (r *Runner) runVariant(ctx context.Context, variant Variant) (res *results.Results, file string, err error) {
+	c := variant.Config
+	variantLogger := logger.With().Str("bench_id", variant.BenchID).Logger()
+
+	client := bench.NewClient(c.APIKey)
+	provider := cloud_generator.NewCloud(c)
+
+	variantLogger.Info().Msg("Creating the instances")
+
+	createMu.Lock()
+	err = provider.Create()
+	createMu.Unlock()
+
+	// a failed creation can leave one of the instances behind
+	defer func() {
+		if r.Keep && !errors.Is(err, bench.ErrInterrupted) {
+			return
+		}
+
+		variantLogger.Info().Msg("Destroying the instances")
+		if err := provider.Destroy(); err != nil {
+			variantLogger.Error().Err(err).Msg("Cannot destroy the instances, run bench destroy with the bench id of the variant")
+		}
+	}()
+
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot create the instances: %w", err)
+	}
+
+	var benchIP, llmIP string
+	var llmAddresses *cloud.Addresses
+	// the instances only have an IP once they are running
+	err = poll(ctx, instancesTimeout, func() bool {
+		var ipErr error
+
+		benchIP, ipErr = provider.GetBenchInstanceIP()
+		if ipErr != nil {
+			return false
+		}
+
+		llmAddresses, ipErr = provider.GetLLMInstanceAddresses()
+		if ipErr != nil {
+			return false
+		}
+		llmIP = llmAddresses.Public
+
+		return client.HealthCheck(benchIP) == nil && client.HealthCheck(llmIP) == nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("the instances are not ready: %w", err)
+	}
+
+	variantLogger.Info().Msg("Deploying the model")
+
+	for _, ip := range []string{llmIP, benchIP} {
+		if err := client.PushConfig(ip, c); err != nil {
+			return nil, "", fmt.Errorf("cannot push the config to %s: %w", ip, err)
+		}
+	}
+
+	err = client.Deploy(llmIP, c.InferenceEngine, c.BenchmarkConfig.Token)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot deploy the model: %w", err)
+	}
+
+	var interruptErr error
+	err = poll(ctx, llmTimeout, func() bool {
+		// the model can take longer to load than the spot notice
+		if interruptErr = client.CheckInterruptions(llmIP, benchIP); interruptErr != nil {
+			return true
+		}
+
+		ready, _ := client.ModelStatus(llmIP)
+		return ready
+	})
+	if interruptErr != nil {
+		return nil, "", interruptErr
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("the model is not ready: %w", err)
+	}
+
+	variantLogger.Info().Msg("Running the benchmark")
+
+	loadIP, addressMode := llmAddresses.LoadAddress()
+	err = client.RunBenchmark(benchIP, loadIP, addressMode, c.InferenceEngine, c.BenchmarkConfig.Token)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot run the benchmark: %w", err)
+	}
+
+	err = client.WaitForBenchmark(ctx, benchIP, llmIP, c.InferenceEngine, benchmarkPollInterval)
+	if err != nil {
+		return nil, "", err
+	}
+
+	res, err = client.GetResults(benchIP, c.InferenceEngine)
+	if err != nil {
+		return nil, "", err
+	}
+
+	res.FillFromConfig(c)
+
+	file = filepath.Join(r.OutputDir, variant.BenchID+".json")
+	if err := writeResults(file, res); err != nil {
+		return res, "", err
+	}
+
+	variantLogger.Info().Str("file", file).Msg("Results written")
+
+	return res, file, nil
+}
+
+func writeResults(file string, res *results.Results) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, data, 0644)
+}
+
+// poll calls done every pollInterval until it returns true
+func poll(ctx context.Context, timeout time.Duration, done func() bool) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		if done() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timed out after %s", timeout)
+			}
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package results
+
+import (
+	"github.com/heka-ai/benchmark-cli/pkg/config"
+)
+
+// FillFromConfig records the bench id and the environment of the run, the
+// benchmark script does not know where it ran. The values already set, such
+// as the address mode recorded by the bench instance, are kept.
+func (r *Results) FillFromConfig(c *config.Config) {
+	if r.BenchmarkID == nil {
+		r.BenchmarkID = &c.BenchID
+	}
+
+	if c.AWSConfig == nil {
+		return
+	}
+
+	if r.Environment == nil {
+		r.Environment = &Environment{}
+	}
+
+	if r.Environment.Regions == nil {
+		r.Environment.Regions = &c.AWSConfig.Region
+	}
+	if r.Environment.Ec2CpuInstanceType == nil {
+		r.Environment.Ec2CpuInstanceType = &c.AWSConfig.CPUInstanceType
+	}
+	if r.Environment.Ec2GpuInstanceType == nil {
+		r.Environment.Ec2GpuInstanceType = &c.AWSConfig.GPUInstanceType
+	}
+}
@@ -0,0 +1,35 @@
+package results
+
+import (
+	"fmt"
+	"os"
+)
+
+// ReadFile reads a results file, older schema versions are migrated
+func ReadFile(path string) (*Results, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse results %s: %w", path, err)
+	}
+
+	return results, nil
+}
+
+// Parse decodes results, older schema versions are migrated
+func Parse(data []byte) (*Results, error) {
+	document, err := decodeDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrate(document); err != nil {
+		return nil, err
+	}
+
+	return fromDocument(document)
+}
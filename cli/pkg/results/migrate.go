@@ -0,0 +1,91 @@
+package results
+
+import (
+	"fmt"
+)
+
+// migrations[i] upgrades a document from version i to version i+1
+var migrations = []func(document map[string]any) error{
+	migrateV0ToV1,
+	migrateV1ToV2,
+}
+
+// migrate upgrades the document in place to CurrentSchemaVersion
+func migrate(document map[string]any) error {
+	version, err := documentVersion(document)
+	if err != nil {
+		return err
+	}
+
+	if version > CurrentSchemaVersion {
+		return fmt.Errorf("results schema version %d is newer than the supported version %d, upgrade the CLI", version, CurrentSchemaVersion)
+	}
+
+	for v := version; v < CurrentSchemaVersion; v++ {
+		if err := migrations[v](document); err != nil {
+			return fmt.Errorf("failed to migrate results from version %d to %d: %w", v, v+1, err)
+		}
+
+		// keep the type encoding/json decodes numbers to
+		document["schema_version"] = float64(v + 1)
+	}
+
+	return nil
+}
+
+func documentVersion(document map[string]any) (int, error) {
+	raw, ok := document["schema_version"]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+
+	version, ok := raw.(float64)
+	if !ok || version < 0 || version != float64(int(version)) {
+		return 0, fmt.Errorf("invalid schema_version %v", raw)
+	}
+
+	return int(version), nil
+}
+
+// version 0 is the raw output of the benchmark script, the bench id may only
+// be set in the nested benchmark object. The API passes the address mode as a
+// metadata of the script, it is written at the top level.
+func migrateV0ToV1(document map[string]any) error {
+	if addressMode, ok := document["address_mode"].(string); ok {
+		delete(document, "address_mode")
+
+		environment, ok := document["environment"].(map[string]any)
+		if !ok {
+			environment = map[string]any{}
+			document["environment"] = environment
+		}
+		environment["address_mode"] = addressMode
+	}
+
+	if id, ok := document["benchmark_id"].(string); ok && id != "" {
+		return nil
+	}
+
+	if benchmark, ok := document["benchmark"].(map[string]any); ok {
+		if id, ok := benchmark["id"].(string); ok && id != "" {
+			document["benchmark_id"] = id
+		}
+	}
+
+	return nil
+}
+
+// before version 2 the bench instance always reached the LLM instance
+// through its public address
+func migrateV1ToV2(document map[string]any) error {
+	environment, ok := document["environment"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	if _, ok := environment["address_mode"]; !ok {
+		environment["address_mode"] = "public"
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package results
 
 type Results struct {
+	SchemaVersion        int          `json:"schema_version"`
 	Date                 *string      `json:"date"`
 	Backend              *string      `json:"backend"`
 	ModelID              *string      `json:"model_id"`
@@ -47,6 +48,8 @@ type Environment struct {
 	Regions            *string `json:"regions"`
 	Ec2CpuInstanceType *string `json:"ec2_cpu_instance_type"`
 	Ec2GpuInstanceType *string `json:"ec2_gpu_instance_type"`
+	// private or public, see cloud.AddressModePrivate
+	AddressMode *string `json:"address_mode"`
 }
 
 type Model struct {
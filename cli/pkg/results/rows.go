@@ -0,0 +1,165 @@
+package results
+
+// RequestRow is the flattened view of a single request of a run
+type RequestRow struct {
+	BenchmarkID   string  `json:"benchmark_id" parquet:"benchmark_id"`
+	Index         int     `json:"index" parquet:"index"`
+	Success       bool    `json:"success" parquet:"success"`
+	InputLen      int     `json:"input_len" parquet:"input_len"`
+	OutputLen     int     `json:"output_len" parquet:"output_len"`
+	TtftMs        float64 `json:"ttft_ms" parquet:"ttft_ms"`
+	TpotMs        float64 `json:"tpot_ms" parquet:"tpot_ms"`
+	E2elMs        float64 `json:"e2el_ms" parquet:"e2el_ms"`
+	MeanItlMs     float64 `json:"mean_itl_ms" parquet:"mean_itl_ms"`
+	MedianItlMs   float64 `json:"median_itl_ms" parquet:"median_itl_ms"`
+	P99ItlMs      float64 `json:"p99_itl_ms" parquet:"p99_itl_ms"`
+	MaxItlMs      float64 `json:"max_itl_ms" parquet:"max_itl_ms"`
+	GeneratedText string  `json:"generated_text" parquet:"generated_text"`
+	Error         string  `json:"error" parquet:"error"`
+}
+
+// SummaryRow is the aggregated view of a run, the latency metrics are
+// computed from the successful requests
+type SummaryRow struct {
+	BenchmarkID          string  `json:"benchmark_id" parquet:"benchmark_id"`
+	Date                 string  `json:"date" parquet:"date"`
+	ModelID              string  `json:"model_id" parquet:"model_id"`
+	Backend              string  `json:"backend" parquet:"backend"`
+	AddressMode          string  `json:"address_mode" parquet:"address_mode"`
+	NumPrompts           int     `json:"num_prompts" parquet:"num_prompts"`
+	Completed            int     `json:"completed" parquet:"completed"`
+	Failed               int     `json:"failed" parquet:"failed"`
+	DurationS            float64 `json:"duration_s" parquet:"duration_s"`
+	TotalInputTokens     int     `json:"total_input_tokens" parquet:"total_input_tokens"`
+	TotalOutputTokens    int     `json:"total_output_tokens" parquet:"total_output_tokens"`
+	RequestThroughput    float64 `json:"request_throughput" parquet:"request_throughput"`
+	OutputThroughput     float64 `json:"output_throughput" parquet:"output_throughput"`
+	TotalTokenThroughput float64 `json:"total_token_throughput" parquet:"total_token_throughput"`
+	MeanTtftMs           float64 `json:"mean_ttft_ms" parquet:"mean_ttft_ms"`
+	MedianTtftMs         float64 `json:"median_ttft_ms" parquet:"median_ttft_ms"`
+	P90TtftMs            float64 `json:"p90_ttft_ms" parquet:"p90_ttft_ms"`
+	P99TtftMs            float64 `json:"p99_ttft_ms" parquet:"p99_ttft_ms"`
+	MeanTpotMs           float64 `json:"mean_tpot_ms" parquet:"mean_tpot_ms"`
+	MedianTpotMs         float64 `json:"median_tpot_ms" parquet:"median_tpot_ms"`
+	P90TpotMs            float64 `json:"p90_tpot_ms" parquet:"p90_tpot_ms"`
+	P99TpotMs            float64 `json:"p99_tpot_ms" parquet:"p99_tpot_ms"`
+	MeanItlMs            float64 `json:"mean_itl_ms" parquet:"mean_itl_ms"`
+	MedianItlMs          float64 `json:"median_itl_ms" parquet:"median_itl_ms"`
+	P90ItlMs             float64 `json:"p90_itl_ms" parquet:"p90_itl_ms"`
+	P99ItlMs             float64 `json:"p99_itl_ms" parquet:"p99_itl_ms"`
+	MeanE2elMs           float64 `json:"mean_e2el_ms" parquet:"mean_e2el_ms"`
+	MedianE2elMs         float64 `json:"median_e2el_ms" parquet:"median_e2el_ms"`
+	P90E2elMs            float64 `json:"p90_e2el_ms" parquet:"p90_e2el_ms"`
+	P99E2elMs            float64 `json:"p99_e2el_ms" parquet:"p99_e2el_ms"`
+}
+
+// Rows flattens the parallel arrays of the results into one row per request
+func (r *Results) Rows() []RequestRow {
+	count := 0
+	if r.Ttfts != nil {
+		count = len(*r.Ttfts)
+	}
+	if r.InputLens != nil {
+		count = max(count, len(*r.InputLens))
+	}
+
+	rows := make([]RequestRow, count)
+	for i := range rows {
+		row := RequestRow{
+			BenchmarkID: r.ID(),
+			Index:       i,
+		}
+
+		if r.InputLens != nil && i < len(*r.InputLens) {
+			row.InputLen = (*r.InputLens)[i]
+		}
+
+		if r.OutputLens != nil && i < len(*r.OutputLens) {
+			row.OutputLen = (*r.OutputLens)[i]
+		}
+
+		if r.GeneratedTexts != nil && i < len(*r.GeneratedTexts) {
+			row.GeneratedText = (*r.GeneratedTexts)[i]
+		}
+
+		if r.Errors != nil && i < len(*r.Errors) {
+			row.Error = (*r.Errors)[i]
+		}
+
+		if r.Ttfts != nil && i < len(*r.Ttfts) {
+			row.TtftMs = (*r.Ttfts)[i] * 1000
+		}
+
+		itls := []float64{}
+		if r.Itls != nil && i < len(*r.Itls) {
+			for _, v := range (*r.Itls)[i] {
+				itls = append(itls, v*1000)
+			}
+		}
+
+		row.E2elMs = row.TtftMs
+		for _, v := range itls {
+			row.E2elMs += v
+			row.MaxItlMs = max(row.MaxItlMs, v)
+		}
+
+		row.MeanItlMs = Mean(itls)
+		row.MedianItlMs = Percentile(itls, 50)
+		row.P99ItlMs = Percentile(itls, 99)
+
+		if row.OutputLen > 1 {
+			row.TpotMs = (row.E2elMs - row.TtftMs) / float64(row.OutputLen-1)
+		}
+
+		row.Success = row.Error == "" && row.OutputLen > 0
+		rows[i] = row
+	}
+
+	return rows
+}
+
+// Summary aggregates the results into a single row
+func (r *Results) Summary() SummaryRow {
+	summary := SummaryRow{
+		BenchmarkID:          r.ID(),
+		Date:                 ValueOrZero(r.Date),
+		ModelID:              ValueOrZero(r.ModelID),
+		Backend:              ValueOrZero(r.Backend),
+		AddressMode:          r.AddressMode(),
+		NumPrompts:           ValueOrZero(r.NumPrompts),
+		Completed:            ValueOrZero(r.Completed),
+		DurationS:            ValueOrZero(r.Duration),
+		TotalInputTokens:     ValueOrZero(r.TotalInputTokens),
+		TotalOutputTokens:    ValueOrZero(r.TotalOutputTokens),
+		RequestThroughput:    ValueOrZero(r.RequestThroughput),
+		OutputThroughput:     ValueOrZero(r.OutputThroughput),
+		TotalTokenThroughput: ValueOrZero(r.TotalTokenThroughput),
+	}
+
+	for _, row := range r.Rows() {
+		if !row.Success {
+			summary.Failed++
+		}
+	}
+
+	samples := r.SuccessfulSamples()
+	summary.MeanTtftMs, summary.MedianTtftMs, summary.P90TtftMs, summary.P99TtftMs = distribution(samples.Ttft)
+	summary.MeanTpotMs, summary.MedianTpotMs, summary.P90TpotMs, summary.P99TpotMs = distribution(samples.Tpot)
+	summary.MeanItlMs, summary.MedianItlMs, summary.P90ItlMs, summary.P99ItlMs = distribution(samples.Itl)
+	summary.MeanE2elMs, summary.MedianE2elMs, summary.P90E2elMs, summary.P99E2elMs = distribution(samples.E2el)
+
+	return summary
+}
+
+func distribution(values []float64) (float64, float64, float64, float64) {
+	return Mean(values), Percentile(values, 50), Percentile(values, 90), Percentile(values, 99)
+}
+
+// ValueOrZero returns the value of the pointer, the zero value when nil
+func ValueOrZero[T any](v *T) T {
+	var zero T
+	if v == nil {
+		return zero
+	}
+	return *v
+}
@@ -0,0 +1,82 @@
+package results
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/heka-ai/benchmark-cli/internal/jsonschema"
+)
+
+// CurrentSchemaVersion is the version of the results written by this version of the CLI
+const CurrentSchemaVersion = 2
+
+//go:embed schema/results.v2.json
+var schemaJSON []byte
+
+// Schema returns the JSON Schema of the current results version
+func Schema() []byte {
+	return schemaJSON
+}
+
+// ValidateDocument reports the structural problems of a results file with
+// their JSON paths. Older files are migrated before being checked.
+func ValidateDocument(data []byte) (ValidationErrors, error) {
+	document, err := decodeDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrate(document); err != nil {
+		return nil, err
+	}
+
+	schema, err := jsonschema.Parse(schemaJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := ValidationErrors{}
+	for _, e := range schema.Validate(document) {
+		errs = append(errs, ValidationError{Path: e.Path, Message: e.Message})
+	}
+
+	// the schema cannot express the cross field rules, only check them on a well formed file
+	if len(errs) == 0 {
+		results, err := fromDocument(document)
+		if err != nil {
+			return nil, err
+		}
+
+		var fieldErrs ValidationErrors
+		if err := results.Validate(); err != nil && errors.As(err, &fieldErrs) {
+			errs = append(errs, fieldErrs...)
+		}
+	}
+
+	return errs, nil
+}
+
+func decodeDocument(data []byte) (map[string]any, error) {
+	var document map[string]any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("not a JSON object: %v", err)
+	}
+
+	return document, nil
+}
+
+func fromDocument(document map[string]any) (*Results, error) {
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var results Results
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, err
+	}
+
+	return &results, nil
+}
@@ -0,0 +1,101 @@
+package results
+
+import (
+	"math"
+	"sort"
+)
+
+// Mean returns the arithmetic mean of the values, 0 when empty
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+
+	return sum / float64(len(values))
+}
+
+// Percentile returns the p-th percentile (0-100) of the values using linear
+// interpolation, the same method numpy uses in the benchmark script
+func Percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	if lower == upper {
+		return sorted[lower]
+	}
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// Samples are the latency values of the successful requests of a run in
+// milliseconds, the summary, the report and the gate are computed from them
+type Samples struct {
+	Ttft []float64
+	// only the requests with more than one output token
+	Tpot []float64
+	// the inter-token latencies of all the requests, flattened
+	Itl  []float64
+	E2el []float64
+}
+
+// SuccessfulSamples returns the latency samples of the successful requests,
+// the failed ones have zero or partial timings
+func (r *Results) SuccessfulSamples() Samples {
+	samples := Samples{Ttft: []float64{}, Tpot: []float64{}, Itl: []float64{}, E2el: []float64{}}
+
+	for _, row := range r.Rows() {
+		if !row.Success {
+			continue
+		}
+
+		samples.Ttft = append(samples.Ttft, row.TtftMs)
+		samples.E2el = append(samples.E2el, row.E2elMs)
+		if row.OutputLen > 1 {
+			samples.Tpot = append(samples.Tpot, row.TpotMs)
+		}
+		if r.Itls != nil && row.Index < len(*r.Itls) {
+			for _, v := range (*r.Itls)[row.Index] {
+				samples.Itl = append(samples.Itl, v*1000)
+			}
+		}
+	}
+
+	return samples
+}
+
+// ID returns the identifier of the run, the benchmark id when set or the date
+func (r *Results) ID() string {
+	if r.BenchmarkID != nil && *r.BenchmarkID != "" {
+		return *r.BenchmarkID
+	}
+
+	if r.Date != nil {
+		return *r.Date
+	}
+
+	return "unknown"
+}
+
+// AddressMode tells how the bench instance reached the LLM instance, empty
+// when it is not known
+func (r *Results) AddressMode() string {
+	if r.Environment == nil || r.Environment.AddressMode == nil {
+		return ""
+	}
+
+	return *r.Environment.AddressMode
+}
@@ -0,0 +1,101 @@
+package results
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError is a structural problem of a results file
+type ValidationError struct {
+	// JSON path of the invalid value
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors groups all the problems found in a results file
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks that the results can be published: the summary metrics
+// are set and the per-request arrays have one entry per request
+func (r *Results) Validate() error {
+	errs := ValidationErrors{}
+
+	required := []struct {
+		path string
+		set  bool
+	}{
+		{"$.date", r.Date != nil},
+		{"$.backend", r.Backend != nil},
+		{"$.model_id", r.ModelID != nil},
+		{"$.num_prompts", r.NumPrompts != nil},
+		{"$.completed", r.Completed != nil},
+		{"$.duration", r.Duration != nil},
+		{"$.request_throughput", r.RequestThroughput != nil},
+		{"$.output_throughput", r.OutputThroughput != nil},
+		{"$.total_token_throughput", r.TotalTokenThroughput != nil},
+	}
+
+	for _, field := range required {
+		if !field.set {
+			errs = append(errs, ValidationError{Path: field.path, Message: "is required"})
+		}
+	}
+
+	if r.Ttfts == nil {
+		errs = append(errs, ValidationError{Path: "$.ttfts", Message: "is required"})
+		return errs
+	}
+
+	count := len(*r.Ttfts)
+	arrays := []struct {
+		path   string
+		length int
+		set    bool
+	}{
+		{"$.input_lens", lenOf(r.InputLens), r.InputLens != nil},
+		{"$.output_lens", lenOf(r.OutputLens), r.OutputLens != nil},
+		{"$.itls", lenOf(r.Itls), r.Itls != nil},
+		{"$.errors", lenOf(r.Errors), r.Errors != nil},
+	}
+
+	for _, array := range arrays {
+		if !array.set {
+			errs = append(errs, ValidationError{Path: array.path, Message: "is required"})
+			continue
+		}
+
+		if array.length != count {
+			errs = append(errs, ValidationError{Path: array.path, Message: fmt.Sprintf("has %d entries, expected %d like $.ttfts", array.length, count)})
+		}
+	}
+
+	if r.GeneratedTexts != nil && len(*r.GeneratedTexts) != count {
+		errs = append(errs, ValidationError{Path: "$.generated_texts", Message: fmt.Sprintf("has %d entries, expected %d like $.ttfts", len(*r.GeneratedTexts), count)})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func lenOf[T any](values *[]T) int {
+	if values == nil {
+		return 0
+	}
+	return len(*values)
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/heka-ai/benchmark-cli/internal/engines"
+)
+
+var initialisms = map[string]string{
+	"cpu":   "CPU",
+	"gb":    "GB",
+	"gpu":   "GPU",
+	"hf":    "HF",
+	"id":    "ID",
+	"kv":    "KV",
+	"mm":    "MM",
+	"mqa":   "MQA",
+	"qlora": "QLora",
+	"url":   "URL",
+}
+
+// field is an argument merged across all the versions
+type field struct {
+	engines.Arg
+	// versions supporting the argument, oldest first
+	Versions []string
+	// choices accepted by at least one version, empty when a version accepts any value
+	AllChoices []string
+}
+
+// merge returns the arguments of all the versions, in the order of the newest
+// version, the arguments removed since are kept next to their old neighbours
+func merge(specs []*engines.Spec) []*field {
+	fields := []*field{}
+	index := map[string]*field{}
+
+	for i := len(specs) - 1; i >= 0; i-- {
+		previous := ""
+
+		for _, arg := range specs[i].Args {
+			f, ok := index[arg.Name]
+			if !ok {
+				f = &field{Arg: arg}
+				index[arg.Name] = f
+				fields = insertAfter(fields, previous, f)
+			}
+
+			previous = arg.Name
+		}
+	}
+
+	for _, spec := range specs {
+		for _, arg := range spec.Args {
+			f := index[arg.Name]
+			f.Versions = append(f.Versions, spec.Version)
+		}
+	}
+
+	for _, f := range fields {
+		f.AllChoices = choices(specs, f.Name)
+		f.Negatable = negatable(specs, f.Name)
+	}
+
+	return fields
+}
+
+func insertAfter(fields []*field, name string, f *field) []*field {
+	position := 0
+	for i, existing := range fields {
+		if existing.Name == name {
+			position = i + 1
+			break
+		}
+	}
+
+	fields = append(fields, nil)
+	copy(fields[position+1:], fields[position:])
+	fields[position] = f
+
+	return fields
+}
+
+func choices(specs []*engines.Spec, name string) []string {
+	all := []string{}
+
+	for _, spec := range specs {
+		arg := spec.Arg(name)
+		if arg == nil {
+			continue
+		}
+
+		if len(arg.Choices) == 0 {
+			return nil
+		}
+
+		for _, c := range arg.Choices {
+			if !slices.Contains(all, c) {
+				all = append(all, c)
+			}
+		}
+	}
+
+	return all
+}
+
+// negatable is true when every version having the argument accepts its
+// --no- form
+func negatable(specs []*engines.Spec, name string) bool {
+	for _, spec := range specs {
+		if arg := spec.Arg(name); arg != nil && !arg.Negatable {
+			return false
+		}
+	}
+
+	return true
+}
+
+func goName(flag string) string {
+	name := ""
+	for _, part := range strings.Split(flag, "-") {
+		if initialism, ok := initialisms[part]; ok {
+			name += initialism
+			continue
+		}
+		name += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return name
+}
+
+func goType(arg *field) string {
+	switch arg.Type {
+	case engines.Int:
+		return "*int"
+	case engines.Float:
+		return "*float64"
+	case engines.Bool:
+		return "*bool"
+	}
+	return "*string"
+}
+
+func tags(arg *field) string {
+	// the model is the positional argument of vllm serve
+	if arg.Name == "model" {
+		return `mapstructure:"model" validate:"required"`
+	}
+
+	tags := fmt.Sprintf(`mapstructure:"%s" json:"%s"`, arg.Name, arg.Name)
+
+	switch {
+	case arg.Type == engines.Bool && arg.Negatable:
+		tags += ` negatable:"true"`
+	case arg.Type == engines.Bool:
+	case len(arg.AllChoices) > 0:
+		tags += fmt.Sprintf(` validate:"omitempty,oneof=%s"`, strings.Join(arg.AllChoices, " "))
+	default:
+		tags += ` validate:"omitempty"`
+	}
+
+	return tags
+}
+
+var goTemplate = template.Must(template.New("go").Funcs(template.FuncMap{
+	"name": goName,
+	"type": func(f *field) string {
+		if f.Name == "model" {
+			return "string"
+		}
+		return goType(f)
+	},
+	"tags": tags,
+}).Parse(`// Code generated by vllmgen from internal/engines/vllm. DO NOT EDIT.
+
+package config
+
+// VLLMConfig holds the arguments of vllm serve, it covers every vLLM version
+// with a spec in internal/engines/vllm
+type VLLMConfig struct {
+{{- range .}}
+	// {{.Help}}
+	{{name .Name}} {{type .}} ` + "`{{tags .}}`" + `
+{{- end}}
+}
+`))
+
+func writeGo(path string, fields []*field) error {
+	var buf bytes.Buffer
+	if err := goTemplate.Execute(&buf, fields); err != nil {
+		return err
+	}
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format the generated code: %v", err)
+	}
+
+	return os.WriteFile(path, source, 0644)
+}
+
+var docsTemplate = template.Must(template.New("docs").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"versions": func(f *field, specs []*engines.Spec) string {
+		versions := []string{}
+		for _, spec := range specs {
+			arg := spec.Arg(f.Name)
+			if arg == nil {
+				continue
+			}
+			if arg.Deprecated {
+				versions = append(versions, spec.Version+" (deprecated)")
+				continue
+			}
+			versions = append(versions, spec.Version)
+		}
+		return strings.Join(versions, ", ")
+	},
+	"escape": func(s string) string { return strings.ReplaceAll(s, "|", `\|`) },
+}).Parse(`<!-- Code generated by vllmgen from internal/engines/vllm. DO NOT EDIT. -->
+
+# vLLM flags
+
+These are the keys accepted in the ` + "`[vllm]`" + ` section of the config. They are passed to ` + "`vllm serve`" + ` as ` + "`--<key>`" + `, see the [configuration](configuration.md#vllm-configuration) for how the command is generated.
+
+The keys come from the argument specs of the supported vLLM versions ({{range $i, $s := .Specs}}{{if $i}}, {{end}}` + "`{{$s.Version}}`" + `{{end}}). ` + "`bench validate`" + ` warns about the keys that are not supported, or deprecated, by the version set in ` + "`inference_engine_version`" + `.
+
+| Key | Type | Allowed values | Versions | Description |
+| --- | ---- | -------------- | -------- | ----------- |
+{{- range .Fields}}
+| ` + "`{{.Name}}`" + ` | {{.Type}}{{if .Negatable}} (false sets ` + "`--no-{{.Name}}`" + `){{end}} | {{if .AllChoices}}` + "`{{join .AllChoices \"`, `\"}}`" + `{{end}} | {{versions . $.Specs}} | {{escape .Help}} |
+{{- end}}
+`))
+
+func writeDocs(path string, specs []*engines.Spec, fields []*field) error {
+	var buf bytes.Buffer
+	err := docsTemplate.Execute(&buf, map[string]interface{}{
+		"Specs":  specs,
+		"Fields": fields,
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
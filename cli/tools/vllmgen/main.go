@@ -0,0 +1,154 @@
+// vllmgen generates the VLLMConfig struct and the vLLM flags documentation
+// from the argument specs of the supported vLLM versions.
+//
+// Import the arguments of a new version from a JSON dump (see
+// instance-builder/aws/ec2/gpu/dump_vllm_args.py) or from `vllm serve --help`,
+// the struct and the documentation are regenerated from all the specs:
+//
+//	go run ./tools/vllmgen -import help.txt -version 0.9.0
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/heka-ai/benchmark-cli/internal/engines"
+)
+
+func main() {
+	specsDir := flag.String("specs", "internal/engines/vllm", "Directory of the argument specs")
+	out := flag.String("out", "pkg/config/vllm.gen.go", "Path of the generated Go file")
+	docs := flag.String("docs", "docs/vllm-flags.md", "Path of the generated documentation")
+	importPath := flag.String("import", "", "JSON dump or --help output to add to the specs")
+	version := flag.String("version", "", "vLLM version of the imported arguments")
+	flag.Parse()
+
+	if *importPath != "" {
+		if err := importSpec(*specsDir, *importPath, *version); err != nil {
+			fail(err)
+		}
+	}
+
+	specs, err := readSpecs(*specsDir)
+	if err != nil {
+		fail(err)
+	}
+
+	fields := merge(specs)
+
+	if err := writeGo(*out, fields); err != nil {
+		fail(err)
+	}
+
+	if err := writeDocs(*docs, specs, fields); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "vllmgen:", err)
+	os.Exit(1)
+}
+
+// importSpec converts a dump or a --help output and saves it in the specs directory
+func importSpec(dir string, path string, version string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var spec *engines.Spec
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		spec, err = engines.ParseDump(data)
+	} else {
+		if version == "" {
+			return fmt.Errorf("-version is required to import a --help output")
+		}
+		spec, err = engines.ParseHelp("vllm", version, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+
+		err = inheritTypes(dir, spec)
+	}
+	if err != nil {
+		return err
+	}
+
+	if version != "" {
+		spec.Version = version
+	}
+
+	encoded, err := spec.MarshalIndent()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, spec.Version+".json"), encoded, 0644)
+}
+
+// inheritTypes takes the types that --help cannot tell from the newest
+// known spec, an argument without a default value is guessed as a string
+func inheritTypes(dir string, spec *engines.Spec) error {
+	known, err := readSpecs(dir)
+	if err != nil {
+		return err
+	}
+
+	for i, arg := range spec.Args {
+		if arg.Type != engines.String || len(arg.Choices) > 0 {
+			continue
+		}
+
+		for j := len(known) - 1; j >= 0; j-- {
+			if previous := known[j].Arg(arg.Name); previous != nil {
+				spec.Args[i].Type = previous.Type
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func readSpecs(dir string) ([]*engines.Spec, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[string]*engines.Spec{}
+	versions := []string{}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		spec, err := engines.ParseDump(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+		byVersion[spec.Version] = spec
+		versions = append(versions, spec.Version)
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no argument spec found in %s", dir)
+	}
+
+	engines.SortVersions(versions)
+
+	specs := []*engines.Spec{}
+	for _, v := range versions {
+		specs = append(specs, byVersion[v])
+	}
+
+	return specs, nil
+}
@@ -2,16 +2,19 @@
 
 echo "Installing the API"
 
-curl -L https://github.com/heka-ai/sia-benchmark/releases/latest/download/api-linux-amd64.tar.gz -o /tmp/api-linux-amd64.tar.gz
-tar -xzf /tmp/api-linux-amd64.tar.gz -C /home/ubuntu/
+# the image builder uploads a local build of the API with --api-binary
+if [ ! -x /home/ubuntu/api ]; then
+    curl -L https://github.com/heka-ai/sia-benchmark/releases/latest/download/api-linux-amd64.tar.gz -o /tmp/api-linux-amd64.tar.gz
+    tar -xzf /tmp/api-linux-amd64.tar.gz -C /home/ubuntu/
+fi
 
 chmod +x /home/ubuntu/api
 
 cp /home/ubuntu/ec2/api/bench.toml /home/ubuntu/config.toml
 
-sudo mv /home/ubuntu/ec2/api/api.service /etc/systemd/system/api.service
+sudo cp /home/ubuntu/ec2/api/api.service /etc/systemd/system/api.service
 sudo systemctl daemon-reload
 sudo systemctl enable api.service
-sudo systemctl start api.service
+sudo systemctl restart api.service
 
 echo "Installation complete"
@@ -0,0 +1,67 @@
+"""Dump the arguments of `vllm serve` for the installed vLLM version.
+
+The output is the JSON argument spec read by the vllmgen tool of the cli:
+
+    python3 dump_vllm_args.py > 0.8.5.post1.json
+    go run ./tools/vllmgen -import 0.8.5.post1.json
+"""
+
+import argparse
+import json
+import sys
+
+import vllm
+from vllm.entrypoints.openai.cli_args import make_arg_parser
+from vllm.utils import FlexibleArgumentParser
+
+# arguments of the OpenAI server itself, they are set by the benchmark api
+SKIPPED = {"help", "host", "port", "uvicorn-log-level", "api-key", "ssl-keyfile", "ssl-certfile",
+           "ssl-ca-certs", "ssl-cert-reqs", "root-path", "middleware", "allowed-origins",
+           "allow-credentials", "allowed-methods", "allowed-headers"}
+
+TYPES = {int: "int", float: "float"}
+
+
+def arg_type(action):
+    if isinstance(action, (argparse._StoreTrueAction, argparse.BooleanOptionalAction)):
+        return "bool"
+    return TYPES.get(action.type, "string")
+
+
+def main():
+    parser = make_arg_parser(FlexibleArgumentParser())
+
+    args = []
+    for action in parser._actions:
+        names = [o for o in action.option_strings if o.startswith("--") and not o.startswith("--no-")]
+        if not names:
+            continue
+
+        name = names[0][2:]
+        if name in SKIPPED:
+            continue
+
+        help_text = " ".join((action.help or "").split())
+        arg = {"name": name, "type": arg_type(action)}
+        if action.choices:
+            arg["choices"] = [str(c) for c in action.choices]
+        if help_text:
+            arg["help"] = help_text
+        if "deprecated" in help_text.lower():
+            arg["deprecated"] = True
+        # `--no-<name>` sets it to false, the default can be true
+        if isinstance(action, argparse.BooleanOptionalAction):
+            arg["negatable"] = True
+
+        args.append(arg)
+
+    # the model is the positional argument of vllm serve
+    if not any(a["name"] == "model" for a in args):
+        args.insert(0, {"name": "model", "type": "string", "help": "Name or path of the huggingface model to use."})
+
+    json.dump({"engine": "vllm", "version": vllm.__version__, "args": args}, sys.stdout, indent=2)
+    sys.stdout.write("\n")
+
+
+if __name__ == "__main__":
+    main()